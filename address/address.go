@@ -0,0 +1,61 @@
+// Package address converts between scriptPubKeys and the addresses that
+// represent them on a network.
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/base58"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/bech32"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+)
+
+// NonStandard is returned by FromScript for scripts without an address
+const NonStandard = "nonstandard"
+
+// FromScript returns the canonical address of a scriptPubKey, or NonStandard
+// if the script has no address form
+func FromScript(params *chaincfg.Params, scriptPubKey []byte) string {
+	switch script.ClassifyScript(scriptPubKey) {
+	case script.P2PKH:
+		return base58.CheckEncode(params.PubKeyHashAddrID, scriptPubKey[3:23])
+	case script.P2SH:
+		return base58.CheckEncode(params.ScriptHashAddrID, scriptPubKey[2:22])
+	}
+
+	// Witness programs of every version, including ones not yet given a meaning
+	address, err := bech32.AddressFromScript(params.Bech32HRP, scriptPubKey)
+	if err != nil {
+		return NonStandard
+	}
+	return address
+}
+
+// ToScript returns the scriptPubKey paying to a segwit or base58check address
+// of the given network
+func ToScript(params *chaincfg.Params, address string) ([]byte, error) {
+	if strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		version, program, err := bech32.DecodeSegWitAddress(params.Bech32HRP, address)
+		if err != nil {
+			return nil, err
+		}
+		return bech32.WitnessScript(version, program), nil
+	}
+	version, hash, err := base58.CheckDecode(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 20 {
+		return nil, fmt.Errorf("base58check payload of %d bytes, want 20", len(hash))
+	}
+	switch version {
+	case params.PubKeyHashAddrID:
+		return new(script.Builder).AddOp(script.OpDup).AddOp(script.OpHash160).AddData(hash).
+			AddOp(script.OpEqualVerify).AddOp(script.OpCheckSig).Script(), nil
+	case params.ScriptHashAddrID:
+		return new(script.Builder).AddOp(script.OpHash160).AddData(hash).AddOp(script.OpEqual).Script(), nil
+	}
+	return nil, fmt.Errorf("address version 0x%02x is not used on %s", version, params.Name)
+}
@@ -0,0 +1,40 @@
+package address
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+)
+
+// TestFromToScript round-trips the address of each script type on each network
+func TestFromToScript(t *testing.T) {
+	tests := []struct {
+		params  *chaincfg.Params
+		script  string
+		address string
+	}{
+		{&chaincfg.MainNetParams, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		{&chaincfg.MainNetParams, "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		{&chaincfg.MainNetParams, "0014751e76e8199196d454941c45d1b3a323f1433bd6", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{&chaincfg.MainNetParams, "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"},
+		{&chaincfg.TestNet3Params, "76a914243f1394f44554f4ce3fd68649c19adc483ce92488ac", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"},
+		{&chaincfg.RegTestParams, "0014751e76e8199196d454941c45d1b3a323f1433bd6", "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"},
+	}
+	for _, test := range tests {
+		scriptPubKey, _ := hex.DecodeString(test.script)
+		if got := FromScript(test.params, scriptPubKey); got != test.address {
+			t.Errorf("%s: address %s, want %s", test.script, got, test.address)
+		}
+		scriptPubKey, err := ToScript(test.params, test.address)
+		if err != nil {
+			t.Errorf("%s: %v", test.address, err)
+		} else if got := hex.EncodeToString(scriptPubKey); got != test.script {
+			t.Errorf("%s: script %s, want %s", test.address, got, test.script)
+		}
+	}
+	if got := FromScript(&chaincfg.MainNetParams, []byte{script.OpReturn}); got != NonStandard {
+		t.Errorf("OP_RETURN has address %s", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Package base58 implements the base58 and base58check encodings used by
+// legacy P2PKH and P2SH addresses.
+package base58
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+)
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var bigRadix = big.NewInt(58)
+
+// Encode encodes data in base58, keeping leading zero bytes as '1' characters
+func Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	mod := new(big.Int)
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, bigRadix, mod)
+		encoded = append(encoded, alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, alphabet[0])
+	}
+	for i := 0; i < len(encoded)/2; i++ {
+		encoded[i], encoded[len(encoded)-1-i] = encoded[len(encoded)-1-i], encoded[i]
+	}
+	return string(encoded)
+}
+
+// Decode decodes a base58 string
+func Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(alphabet, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("base58: invalid character %q", s[i])
+		}
+		n.Mul(n, bigRadix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	leadingZeros := 0
+	for leadingZeros < len(s) && s[leadingZeros] == alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), n.Bytes()...), nil
+}
+
+// checksum returns the first four bytes of the double SHA256 of data
+func checksum(data []byte) []byte {
+	hash := hashutil.Hash256(data)
+	return hash[:4]
+}
+
+// CheckEncode encodes a version byte and payload with a 4-byte checksum
+func CheckEncode(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return Encode(append(data, checksum(data)...))
+}
+
+// CheckDecode decodes a base58check string into its version byte and payload
+func CheckDecode(s string) (version byte, payload []byte, err error) {
+	data, err := Decode(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 5 {
+		return 0, nil, errors.New("base58: string too short for checksum")
+	}
+	body, sum := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(checksum(body), sum) {
+		return 0, nil, errors.New("base58: invalid checksum")
+	}
+	return body[0], body[1:], nil
+}
@@ -0,0 +1,41 @@
+package base58
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestCheckEncoding round-trips mainnet and testnet P2PKH and P2SH addresses
+// and checks corrupted ones are rejected
+func TestCheckEncoding(t *testing.T) {
+	tests := []struct {
+		address string
+		version byte
+		hash    string
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 0x00, "62e907b15cbf27d5425399ebf6f0fb50ebb88f18"},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", 0x00, "77bff20c60e522dfaa3350c39b030a5d004e839a"},
+		{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", 0x05, "b472a266d0bd89c13706a4132ccfb16f7c3b9fcb"},
+		{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", 0x6f, "243f1394f44554f4ce3fd68649c19adc483ce924"},
+		{"2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", 0xc4, "4e9f39ca4688ff102128ea4ccda34105324305b0"},
+	}
+	for _, test := range tests {
+		version, payload, err := CheckDecode(test.address)
+		if err != nil {
+			t.Errorf("%s: %v", test.address, err)
+			continue
+		}
+		if version != test.version || hex.EncodeToString(payload) != test.hash {
+			t.Errorf("%s: decoded version 0x%02x, payload %x", test.address, version, payload)
+		}
+		if address := CheckEncode(version, payload); address != test.address {
+			t.Errorf("%s: encoded as %s", test.hash, address)
+		}
+	}
+	// A bad checksum, a character outside the alphabet and a payload too short
+	for _, address := range []string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", "1A1zP1eP5QGefi2DMPTfTL5SLmv7Divf0a", "1111"} {
+		if _, _, err := CheckDecode(address); err == nil {
+			t.Errorf("%s: decoded without error", address)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package bech32 implements the bech32 (BIP173) and bech32m (BIP350) encodings
+// and the segwit addresses built on them.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Variant selects the checksum constant of an encoding
+type Variant int
+
+const (
+	Bech32  Variant = iota // BIP173, used for witness version 0
+	Bech32m                // BIP350, used for witness versions 1 through 16
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// maxLength is the BIP173 limit on the length of an encoded string
+const maxLength = 90
+
+// checksumConstant returns the value the polymod of a valid string ends in
+func (v Variant) checksumConstant() uint32 {
+	if v == Bech32m {
+		return 0x2bc830a3
+	}
+	return 1
+}
+
+// polymod computes the BCH checksum over values
+func polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// hrpExpand expands the human-readable part for checksum computation
+func hrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// Encode encodes 5-bit data groups under the human-readable part hrp
+func Encode(hrp string, data []byte, variant Variant) (string, error) {
+	if len(hrp)+1+len(data)+6 > maxLength {
+		return "", errors.New("bech32: encoded string too long")
+	}
+	hrp = strings.ToLower(hrp)
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ variant.checksumConstant()
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		if d >= 32 {
+			return "", fmt.Errorf("bech32: invalid data value %d", d)
+		}
+		sb.WriteByte(charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(charset[(mod>>(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// Decode decodes a bech32 or bech32m string into its human-readable part and
+// 5-bit data groups, without the checksum
+func Decode(s string) (hrp string, data []byte, variant Variant, err error) {
+	if len(s) > maxLength {
+		return "", nil, 0, errors.New("bech32: string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("bech32: mixed case")
+	}
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, 0, errors.New("bech32: missing separator or checksum")
+	}
+	hrp = s[:separator]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("bech32: invalid character %q in human-readable part", hrp[i])
+		}
+	}
+	for i := separator + 1; i < len(s); i++ {
+		d := strings.IndexByte(charset, s[i])
+		if d < 0 {
+			return "", nil, 0, fmt.Errorf("bech32: invalid character %q", s[i])
+		}
+		data = append(data, byte(d))
+	}
+	switch polymod(append(hrpExpand(hrp), data...)) {
+	case Bech32.checksumConstant():
+		variant = Bech32
+	case Bech32m.checksumConstant():
+		variant = Bech32m
+	default:
+		return "", nil, 0, errors.New("bech32: invalid checksum")
+	}
+	return hrp, data[:len(data)-6], variant, nil
+}
+
+// ConvertBits regroups data from fromBits-bit groups into toBits-bit groups.
+// Without pad, leftover bits must be zero and fewer than fromBits.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	var out []byte
+	maxValue := uint(1)<<toBits - 1
+	for _, value := range data {
+		if uint(value)>>fromBits != 0 {
+			return nil, fmt.Errorf("bech32: invalid %d-bit value %d", fromBits, value)
+		}
+		acc = acc<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte((acc>>bits)&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte((acc<<(toBits-bits))&maxValue))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxValue != 0 {
+		return nil, errors.New("bech32: invalid padding")
+	}
+	return out, nil
+}
+
+// checkWitnessProgram applies the BIP141 and BIP350 rules on witness versions
+// and program lengths
+func checkWitnessProgram(version byte, program []byte) error {
+	if version > 16 {
+		return fmt.Errorf("bech32: invalid witness version %d", version)
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("bech32: invalid witness program length %d", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("bech32: invalid witness v0 program length %d", len(program))
+	}
+	return nil
+}
+
+// EncodeSegWitAddress encodes a witness version and program as an address
+func EncodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
+	if err := checkWitnessProgram(version, program); err != nil {
+		return "", err
+	}
+	variant := Bech32m
+	if version == 0 {
+		variant = Bech32
+	}
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return Encode(hrp, append([]byte{version}, data...), variant)
+}
+
+// DecodeSegWitAddress decodes a segwit address for the network with prefix hrp
+// into its witness version and program
+func DecodeSegWitAddress(hrp, address string) (version byte, program []byte, err error) {
+	gotHRP, data, variant, err := Decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != hrp {
+		return 0, nil, fmt.Errorf("bech32: address prefix %q, want %q", gotHRP, hrp)
+	}
+	if len(data) < 1 {
+		return 0, nil, errors.New("bech32: missing witness version")
+	}
+	version = data[0]
+	if (version == 0) != (variant == Bech32) {
+		return 0, nil, fmt.Errorf("bech32: wrong checksum variant for witness version %d", version)
+	}
+	program, err = ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkWitnessProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+	return version, program, nil
+}
+
+// WitnessScript returns the scriptPubKey paying to a witness program:
+// OP_0 or OP_1..OP_16 followed by a push of the program
+func WitnessScript(version byte, program []byte) []byte {
+	opcode := version
+	if version > 0 {
+		opcode = 0x50 + version
+	}
+	return append([]byte{opcode, byte(len(program))}, program...)
+}
+
+// AddressFromScript encodes a witness scriptPubKey back into its address
+func AddressFromScript(hrp string, script []byte) (string, error) {
+	if len(script) < 4 || int(script[1]) != len(script)-2 {
+		return "", errors.New("bech32: not a witness program script")
+	}
+	var version byte
+	switch {
+	case script[0] == 0:
+	case script[0] >= 0x51 && script[0] <= 0x60:
+		version = script[0] - 0x50
+	default:
+		return "", errors.New("bech32: not a witness program script")
+	}
+	return EncodeSegWitAddress(hrp, version, script[2:])
+}
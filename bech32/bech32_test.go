@@ -0,0 +1,73 @@
+package bech32
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestBech32Vectors runs the segwit address test vectors of BIP173 and BIP350
+func TestBech32Vectors(t *testing.T) {
+	valid := []struct {
+		hrp, address, script string
+	}{
+		{"bc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"bc", "BC1SW50QGDZ25J", "6002751e"},
+		{"bc", "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+		{"tb", "tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		{"tb", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+	for _, vector := range valid {
+		t.Run(vector.address, func(t *testing.T) {
+			version, program, err := DecodeSegWitAddress(vector.hrp, vector.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(WitnessScript(version, program)); got != vector.script {
+				t.Errorf("decoded to %s, want %s", got, vector.script)
+			}
+			script, _ := hex.DecodeString(vector.script)
+			address, err := AddressFromScript(vector.hrp, script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != strings.ToLower(vector.address) {
+				t.Errorf("%s encoded as %s", vector.script, address)
+			}
+		})
+	}
+
+	invalid := []struct {
+		address, reason string
+	}{
+		{"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut", "invalid human-readable part"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "bech32 checksum for v1"},
+		{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", "bech32 checksum for v2"},
+		{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", "bech32 checksum for v16"},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "bech32m checksum for v0"},
+		{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", "bech32m checksum for v0"},
+		{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", "invalid character"},
+		{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", "invalid witness version"},
+		{"bc1pw5dgrnzv", "program too short"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", "program too long"},
+		{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "invalid v0 program length"},
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", "mixed case"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", "more than 4 padding bits"},
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", "non-zero padding"},
+		{"bc1gmk9yu", "empty data section"},
+	}
+	for _, vector := range invalid {
+		t.Run(vector.address, func(t *testing.T) {
+			hrp := "bc"
+			if strings.HasPrefix(strings.ToLower(vector.address), "tb") {
+				hrp = "tb"
+			}
+			if _, _, err := DecodeSegWitAddress(hrp, vector.address); err == nil {
+				t.Errorf("decoded without error despite %s", vector.reason)
+			}
+		})
+	}
+}
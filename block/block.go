@@ -0,0 +1,184 @@
+// Package block defines blocks and block headers, their serialization and the
+// output file format expected by the challenge grader.
+package block
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// MaxBlockSigOpsCost is the largest total signature operation cost of a block (BIP141)
+const MaxBlockSigOpsCost = 80000
+
+// Block represents a block containing transactions
+type Block struct {
+	Size             uint64
+	Header           Header
+	TransactionCount uint64
+	Transactions     []tx.Transaction
+}
+
+// Header represents the header of a block
+type Header struct {
+	Version           uint32
+	PreviousBlockHash [32]byte
+	MerkleRoot        [32]byte
+	Timestamp         uint32
+	Bits              uint32 // difficulty target in compact form, see TargetToCompact
+	Nonce             uint32
+}
+
+// HeaderSize is the size of a serialized block header in bytes
+const HeaderSize = 80
+
+// NonceOffset is where the nonce, the last field, starts in a serialized header
+const NonceOffset = HeaderSize - 4
+
+// SerializeHeader serializes the block header
+func SerializeHeader(header Header) []byte {
+	return AppendHeader(make([]byte, 0, HeaderSize), header)
+}
+
+// AppendHeader appends the serialized block header to dst. Hashing loops pass
+// the same buffer every time so that serialization does not allocate.
+func AppendHeader(dst []byte, header Header) []byte {
+	// Serialize each field of the block header
+	dst = binary.LittleEndian.AppendUint32(dst, header.Version)
+	dst = append(dst, header.PreviousBlockHash[:]...)
+	dst = append(dst, header.MerkleRoot[:]...)
+	dst = binary.LittleEndian.AppendUint32(dst, header.Timestamp)
+	dst = binary.LittleEndian.AppendUint32(dst, header.Bits)
+	return binary.LittleEndian.AppendUint32(dst, header.Nonce)
+}
+
+// TargetToCompact encodes a big-endian target in the compact "nBits" form: a
+// size byte followed by the three most significant bytes of the target
+func TargetToCompact(target [32]byte) uint32 {
+	i := 0
+	for i < len(target) && target[i] == 0 {
+		i++
+	}
+	size := uint32(len(target) - i)
+	var mantissa uint32
+	for j := 0; j < 3; j++ {
+		mantissa <<= 8
+		if i+j < len(target) {
+			mantissa |= uint32(target[i+j])
+		}
+	}
+
+	// The mantissa is signed, so a set top bit needs an extra byte
+	if mantissa&0x800000 != 0 {
+		mantissa >>= 8
+		size++
+	}
+	if size == 0 {
+		return 0
+	}
+	return size<<24 | mantissa
+}
+
+// CompactToTarget decodes a compact "nBits" target into a big-endian target
+func CompactToTarget(bits uint32) ([32]byte, error) {
+	var target [32]byte
+	size := int(bits >> 24)
+	mantissa := bits & 0x007fffff
+	if bits&0x00800000 != 0 && mantissa != 0 {
+		return target, fmt.Errorf("negative compact target %#08x", bits)
+	}
+	if size <= 3 {
+		mantissa >>= 8 * (3 - size)
+		size = 3
+	}
+	for j := 0; j < 3; j++ {
+		b := byte(mantissa >> (8 * (2 - j)))
+		position := len(target) - size + j
+		if position < 0 {
+			if b != 0 {
+				return target, fmt.Errorf("compact target %#08x overflows 256 bits", bits)
+			}
+			continue
+		}
+		target[position] = b
+	}
+	return target, nil
+}
+
+// HashHeader hashes the serialized block header twice using SHA256
+func HashHeader(serializedHeader []byte) [32]byte {
+	return hashutil.Hash256(serializedHeader)
+}
+
+// HashToString returns the byte-reversed hex encoding used to display hashes
+func HashToString(hash [32]byte) string {
+	for i := 0; i < len(hash)/2; i++ {
+		hash[i], hash[len(hash)-1-i] = hash[len(hash)-1-i], hash[i]
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateCoinbaseTransaction creates a coinbase transaction
+func CreateCoinbaseTransaction() tx.Transaction {
+	coinbaseTx := tx.Transaction{
+		Version:  1,
+		Locktime: 0,
+		Vin: []tx.TxInput{
+			{
+				Txid:       strings.Repeat("0", 64),
+				Vout:       -1,
+				ScriptSig:  nil,
+				Witness:    nil,
+				IsCoinbase: true,
+				Sequence:   0xFFFFFFFF,
+				PrevOut: tx.Prevout{
+					ScriptPubKey:     nil,
+					ScriptPubKeyASM:  "",
+					ScriptPubKeyType: "",
+					ScriptPubKeyAddr: "",
+					Value:            0,
+				},
+			},
+		},
+		Vout: []tx.TxOutput{
+			{
+				ScriptPubKey:     nil,
+				ScriptPubKeyASM:  "",
+				ScriptPubKeyType: "",
+				ScriptPubKeyAddr: "",
+				Value:            0,
+			},
+		},
+	}
+	return coinbaseTx
+}
+
+// WriteOutputFile writes a mined block in the format the challenge grader
+// reads: the serialized header and the serialized coinbase with its witness,
+// both in hex, then the txid of every transaction in block order, the
+// coinbase first
+func WriteOutputFile(outputPath string, block Block) error {
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("block without a coinbase")
+	}
+	var output strings.Builder
+	output.WriteString(hex.EncodeToString(SerializeHeader(block.Header)) + "\n")
+	coinbase, err := tx.AppendSerialized(nil, block.Transactions[0], true)
+	if err != nil {
+		return fmt.Errorf("coinbase: %w", err)
+	}
+	output.WriteString(hex.EncodeToString(coinbase) + "\n")
+	for i, transaction := range block.Transactions {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+		output.WriteString(txid + "\n")
+	}
+	return os.WriteFile(outputPath, []byte(output.String()), 0o644)
+}
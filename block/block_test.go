@@ -0,0 +1,123 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// genesisHash is the hash of the mainnet genesis block
+const genesisHash = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+
+// genesisBlock returns the serialization of the mainnet genesis block
+func genesisBlock(t *testing.T) []byte {
+	t.Helper()
+	scriptSig, _ := hex.DecodeString("04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73")
+	scriptPubKey, _ := hex.DecodeString("4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac")
+	coinbase, err := tx.Serialize(tx.Transaction{
+		Version: 1,
+		Vin: []tx.TxInput{{
+			Txid:       "0000000000000000000000000000000000000000000000000000000000000000",
+			Vout:       -1,
+			ScriptSig:  scriptSig,
+			IsCoinbase: true,
+			Sequence:   0xFFFFFFFF,
+		}},
+		Vout: []tx.TxOutput{{ScriptPubKey: scriptPubKey, Value: 5000000000}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := hex.DecodeString("0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c")
+	return append(append(header, 1), coinbase...)
+}
+
+// TestHashHeader checks the double SHA256 of the empty string
+func TestHashHeader(t *testing.T) {
+	hash := HashHeader(nil)
+	if got, want := hex.EncodeToString(hash[:]), "5df6e0e2761359d30a8275058e299fcc0381534545f55cf43e41983f5d4c9456"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// TestGenesisBlockRoundTrip parses the genesis block, checks its hash, both
+// at once and from the midstate the nonce search uses, and serializes it back
+func TestGenesisBlockRoundTrip(t *testing.T) {
+	serializedBlock := genesisBlock(t)
+	parsed, err := ParseBlock(serializedBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash := HashToString(HashHeader(SerializeHeader(parsed.Header))); hash != genesisHash {
+		t.Errorf("genesis block hash %s", hash)
+	}
+	header := serializedBlock[:HeaderSize]
+	if hash := HashToString(hashutil.NewMidstate(header[:64]).Hash256(header[64:])); hash != genesisHash {
+		t.Errorf("genesis block hash from the midstate %s", hash)
+	}
+	if parsed.Header.Bits != 0x1d00ffff || parsed.Header.Nonce != 2083236893 || len(parsed.Transactions) != 1 {
+		t.Errorf("parsed header %+v with %d transactions", parsed.Header, len(parsed.Transactions))
+	}
+	reserialized, err := SerializeBlock(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reserialized, serializedBlock) {
+		t.Error("serialized block differs after round trip")
+	}
+	if _, err := ParseBlock(append(serializedBlock, 0)); err == nil {
+		t.Error("trailing byte accepted")
+	}
+}
+
+// TestCompactTarget round-trips compact targets and rejects a negative one
+func TestCompactTarget(t *testing.T) {
+	tests := []struct {
+		bits   uint32
+		target string
+	}{
+		{0x1d00ffff, "00000000ffff0000000000000000000000000000000000000000000000000000"},
+		{0x1f00ffff, "0000ffff00000000000000000000000000000000000000000000000000000000"},
+		{0x207fffff, "7fffff0000000000000000000000000000000000000000000000000000000000"},
+		{0x1b0404cb, "00000000000404cb000000000000000000000000000000000000000000000000"},
+		{0x03123456, "0000000000000000000000000000000000000000000000000000000000123456"},
+	}
+	for _, test := range tests {
+		target, err := CompactToTarget(test.bits)
+		if err != nil {
+			t.Errorf("%#08x: %v", test.bits, err)
+			continue
+		}
+		if got := hex.EncodeToString(target[:]); got != test.target {
+			t.Errorf("%#08x: target %s, want %s", test.bits, got, test.target)
+		}
+		if bits := TargetToCompact(target); bits != test.bits {
+			t.Errorf("%s: encoded as %#08x", test.target, bits)
+		}
+	}
+	if _, err := CompactToTarget(0x04923456); err == nil {
+		t.Error("negative compact target accepted")
+	}
+}
+
+// BenchmarkHashHeader measures setting the nonce of a serialized block header
+// and double hashing it from the midstate of its first 64 bytes, the mining
+// hot path
+func BenchmarkHashHeader(b *testing.B) {
+	header := Header{
+		Version:   1,
+		Timestamp: 1713744000,
+		Bits:      0x1f00ffff,
+	}
+	serialized := SerializeHeader(header)
+	midstate := hashutil.NewMidstate(serialized[:64])
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		binary.LittleEndian.PutUint32(serialized[NonceOffset:], uint32(i))
+		midstate.Hash256(serialized[64:])
+	}
+}
@@ -0,0 +1,26 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzParseBlock checks that any block ParseBlock accepts serializes back to
+// the same bytes. The corpus in testdata/fuzz wraps one mempool transaction
+// of each input type in a block.
+func FuzzParseBlock(f *testing.F) {
+	f.Add(make([]byte, HeaderSize+1))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parsed, err := ParseBlock(data)
+		if err != nil {
+			return
+		}
+		serialized, err := SerializeBlock(parsed)
+		if err != nil {
+			t.Fatalf("parsed block does not serialize: %v", err)
+		}
+		if !bytes.Equal(serialized, data) {
+			t.Fatalf("round trip mismatch:\n got %x\nwant %x", serialized, data)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// SerializeBlock serializes a block in the Bitcoin wire format: the header, the
+// transaction count and every transaction including its witness data
+func SerializeBlock(block Block) ([]byte, error) {
+	// Sized up front, so the transactions are appended without regrowing
+	size := HeaderSize + tx.VarIntSize(uint64(len(block.Transactions)))
+	for _, transaction := range block.Transactions {
+		size += tx.SerializedSize(transaction, true)
+	}
+	serializedBlock := AppendHeader(make([]byte, 0, size), block.Header)
+	serializedBlock = tx.AppendVarInt(serializedBlock, uint64(len(block.Transactions)))
+	for i, transaction := range block.Transactions {
+		var err error
+		if serializedBlock, err = tx.AppendSerialized(serializedBlock, transaction, true); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	return serializedBlock, nil
+}
+
+// ParseHeader deserializes an 80-byte block header
+func ParseHeader(data []byte) (Header, error) {
+	var header Header
+	if len(data) != HeaderSize {
+		return header, fmt.Errorf("block header of %d bytes, want %d", len(data), HeaderSize)
+	}
+	header.Version = binary.LittleEndian.Uint32(data[0:])
+	copy(header.PreviousBlockHash[:], data[4:36])
+	copy(header.MerkleRoot[:], data[36:68])
+	header.Timestamp = binary.LittleEndian.Uint32(data[68:])
+	header.Bits = binary.LittleEndian.Uint32(data[72:])
+	header.Nonce = binary.LittleEndian.Uint32(data[76:])
+	return header, nil
+}
+
+// ParseBlock deserializes a block produced by SerializeBlock or by any other
+// Bitcoin implementation. Trailing bytes after the last transaction are rejected.
+func ParseBlock(data []byte) (Block, error) {
+	var block Block
+	if len(data) < HeaderSize {
+		return block, fmt.Errorf("block of %d bytes is shorter than its header", len(data))
+	}
+	header, err := ParseHeader(data[:HeaderSize])
+	if err != nil {
+		return block, err
+	}
+	block.Header = header
+
+	r := bytes.NewReader(data[HeaderSize:])
+	count, err := tx.ReadVarInt(r)
+	if err != nil {
+		return block, fmt.Errorf("transaction count: %w", err)
+	}
+
+	// Every transaction takes at least 60 bytes, which bounds the allocation
+	pos := len(data) - r.Len()
+	if count > uint64(len(data)-pos)/60 {
+		return block, fmt.Errorf("transaction count %d exceeds block size", count)
+	}
+	block.TransactionCount = count
+	block.Transactions = make([]tx.Transaction, 0, count)
+	for i := uint64(0); i < count; i++ {
+		transaction, n, err := tx.ParsePrefix(data[pos:])
+		if err != nil {
+			return block, fmt.Errorf("transaction %d at offset %d: %w", i, pos, err)
+		}
+		block.Transactions = append(block.Transactions, transaction)
+		pos += n
+	}
+	if pos != len(data) {
+		return block, fmt.Errorf("%d trailing bytes after block", len(data)-pos)
+	}
+	block.Size = uint64(len(data))
+	return block, nil
+}
@@ -0,0 +1,24 @@
+package block
+
+import "slices"
+
+// Header timestamp rules: a timestamp must be later than the median of the
+// previous MedianTimeSpan blocks and at most MaxFutureBlockTime seconds ahead
+// of the validating node's clock
+const (
+	MedianTimeSpan     = 11
+	MaxFutureBlockTime = 2 * 60 * 60
+)
+
+// MedianTimePast returns the median timestamp of the last MedianTimeSpan
+// blocks, given the timestamps of the blocks before the one being built in
+// chain order. Fewer timestamps, as near the genesis block, give the median of
+// those; none give 0.
+func MedianTimePast(timestamps []uint32) uint32 {
+	if len(timestamps) == 0 {
+		return 0
+	}
+	last := slices.Clone(timestamps[max(len(timestamps)-MedianTimeSpan, 0):])
+	slices.Sort(last)
+	return last[len(last)/2]
+}
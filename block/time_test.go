@@ -0,0 +1,14 @@
+package block
+
+import "testing"
+
+// TestMedianTimePast checks only the last 11 timestamps count, in sorted order
+func TestMedianTimePast(t *testing.T) {
+	timestamps := []uint32{1, 100, 110, 105, 120, 115, 130, 125, 140, 135, 150, 145}
+	if mtp := MedianTimePast(timestamps); mtp != 125 {
+		t.Errorf("median time past %d, want 125", mtp)
+	}
+	if mtp := MedianTimePast(timestamps[:2]); mtp != 100 {
+		t.Errorf("median time past of two blocks %d, want 100", mtp)
+	}
+}
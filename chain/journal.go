@@ -0,0 +1,71 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// maxJournalLine bounds a journal line, far above the largest output script
+const maxJournalLine = 1 << 20
+
+// ErrTruncatedJournal is returned when a journal ends in a line without its
+// newline, which only a write interrupted by a crash leaves behind. The lines
+// before it are complete; the error tells how many bytes they take, so the
+// journal can be cut back to them once the blocks of the lost records are
+// known.
+var ErrTruncatedJournal = errors.New("journal ends in an incomplete line, left by an interrupted write")
+
+// openJournal opens the JSON lines journal at path for appending, creating it
+// if it does not exist, and calls apply with every line already in it
+func openJournal(path string, apply func(line []byte) error) (*os.File, error) {
+	journal, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(journal)
+	scanner.Buffer(nil, maxJournalLine)
+	complete := 0 // bytes of the lines read so far, newlines included
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) > 0 && bytes.IndexByte(data, '\n') < 0 {
+			return 0, nil, ErrTruncatedJournal
+		}
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		complete += advance
+		return advance, token, err
+	})
+	line := 1
+	for ; scanner.Scan(); line++ {
+		if err := apply(scanner.Bytes()); err != nil {
+			journal.Close()
+			return nil, fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		journal.Close()
+		if errors.Is(err, ErrTruncatedJournal) {
+			return nil, fmt.Errorf("%s: line %d: %w; the %d bytes before it are complete", path, line, err, complete)
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return journal, nil
+}
+
+// appendJournal appends records to a journal as JSON lines and syncs it, so
+// records of one call are on disk together before any is applied
+func appendJournal[T any](journal *os.File, records []T) error {
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	if _, err := journal.Write(lines.Bytes()); err != nil {
+		return err
+	}
+	return journal.Sync()
+}
@@ -0,0 +1,58 @@
+// Package chain describes the chain a new block extends and where to learn
+// about it.
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Tip is what a new block needs to know about the chain it extends
+type Tip struct {
+	PrevBlockHash  [32]byte // hash of the tip in internal byte order, as the header holds it
+	Height         uint32   // height of the new block, one above the tip
+	MedianTimePast uint32   // median time past of the tip, see block.MedianTimePast
+}
+
+// TipSource provides the tip a new block extends
+type TipSource interface {
+	Tip(ctx context.Context) (Tip, error)
+}
+
+// StaticTip is a tip given up front, e.g. on the command line
+type StaticTip Tip
+
+// Tip returns the static tip
+func (t StaticTip) Tip(ctx context.Context) (Tip, error) {
+	return Tip(t), ctx.Err()
+}
+
+// RPCTipSource asks a Bitcoin Core node for its best block
+type RPCTipSource struct {
+	Node txpool.RPCSource
+}
+
+// Tip returns the node's best block from getblockchaininfo
+func (s RPCTipSource) Tip(ctx context.Context) (Tip, error) {
+	result, err := s.Node.Call(ctx, "getblockchaininfo")
+	if err != nil {
+		return Tip{}, err
+	}
+	var info struct {
+		Blocks        uint32 `json:"blocks"`
+		BestBlockHash string `json:"bestblockhash"`
+		MedianTime    uint32 `json:"mediantime"`
+	}
+	if err := json.Unmarshal(result, &info); err != nil {
+		return Tip{}, fmt.Errorf("decoding getblockchaininfo result: %w", err)
+	}
+	hash, err := merkle.ParseHash(info.BestBlockHash)
+	if err != nil {
+		return Tip{}, fmt.Errorf("best block hash: %w", err)
+	}
+	return Tip{PrevBlockHash: hash, Height: info.Blocks + 1, MedianTimePast: info.MedianTime}, nil
+}
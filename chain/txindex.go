@@ -0,0 +1,97 @@
+package chain
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TxLocation is where a mined block included a transaction
+type TxLocation struct {
+	BlockHash string `json:"block_hash"` // display hex
+	Height    uint32 `json:"height"`
+	Position  int    `json:"position"` // index in the block, the coinbase at 0
+}
+
+// txIndexRecord is one line of the transaction index journal
+type txIndexRecord struct {
+	Txid string `json:"txid"`
+	TxLocation
+}
+
+// TxIndex maps the txids of the transactions in mined blocks to where they
+// were included, kept across runs in a journal file with one JSON line per
+// transaction. A txid mined more than once, as when runs share no UTXO set,
+// maps to the block recorded last. It is safe for concurrent use.
+type TxIndex struct {
+	mu        sync.RWMutex
+	locations map[string]TxLocation
+	journal   *os.File
+}
+
+// OpenTxIndex opens the index journaled at path, creating an empty one if the
+// file does not exist
+func OpenTxIndex(path string) (*TxIndex, error) {
+	index := &TxIndex{locations: make(map[string]TxLocation)}
+	journal, err := openJournal(path, func(line []byte) error {
+		var record txIndexRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		index.locations[record.Txid] = record.TxLocation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	index.journal = journal
+	return index, nil
+}
+
+// AddBlock records the transactions of a mined block with hash, in internal
+// byte order, at height, writing them to the journal before indexing them
+func (x *TxIndex) AddBlock(mined block.Block, hash [32]byte, height uint32) error {
+	blockHash := block.HashToString(hash)
+	records := make([]txIndexRecord, len(mined.Transactions))
+	for i, transaction := range mined.Transactions {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return err
+		}
+		records[i] = txIndexRecord{Txid: txid, TxLocation: TxLocation{BlockHash: blockHash, Height: height, Position: i}}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := appendJournal(x.journal, records); err != nil {
+		return err
+	}
+	for _, record := range records {
+		x.locations[record.Txid] = record.TxLocation
+	}
+	return nil
+}
+
+// Lookup returns where a transaction was mined, reporting false if no
+// indexed block included it
+func (x *TxIndex) Lookup(txid string) (TxLocation, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	location, ok := x.locations[txid]
+	return location, ok
+}
+
+// Len returns the number of indexed transactions
+func (x *TxIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.locations)
+}
+
+// Close closes the journal
+func (x *TxIndex) Close() error {
+	return x.journal.Close()
+}
@@ -0,0 +1,38 @@
+package chain
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTxIndexReplay indexes a block and checks the reopened journal replays to
+// the same locations
+func TestTxIndexReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "txindex.jsonl")
+	mined, _, spendTxid := testBlock(t)
+	hash := [32]byte{0: 0xab, 31: 0x01}
+	index, err := OpenTxIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.AddBlock(mined, hash, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err = OpenTxIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	want := TxLocation{BlockHash: "01" + strings.Repeat("00", 30) + "ab", Height: 7, Position: 1}
+	if location, ok := index.Lookup(spendTxid); !ok || location != want {
+		t.Errorf("lookup: got %+v, %v, want %+v", location, ok, want)
+	}
+	if _, ok := index.Lookup(outsideTxid); ok {
+		t.Error("found a txid no block included")
+	}
+}
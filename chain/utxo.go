@@ -0,0 +1,177 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Outpoint identifies a transaction output
+type Outpoint struct {
+	Txid string
+	Vout int
+}
+
+func (o Outpoint) String() string {
+	return fmt.Sprintf("%s:%d", o.Txid, o.Vout)
+}
+
+// Coin is an output created or spent by a mined block. Of an output spent but
+// not created by one, only Spent is known.
+type Coin struct {
+	Value        int64
+	ScriptPubKey tx.HexBytes
+	Height       uint32 // height of the block that created it
+	Coinbase     bool   // created by a coinbase, so spendable only once mature
+	Spent        bool   // spent by a later mined block
+}
+
+// utxoRecord is one line of the UTXO journal: an output created or spent
+type utxoRecord struct {
+	Op           string      `json:"op"` // "create" or "spend"
+	Txid         string      `json:"txid"`
+	Vout         int         `json:"vout"`
+	Value        int64       `json:"value,omitempty"`
+	ScriptPubKey tx.HexBytes `json:"scriptpubkey,omitempty"`
+	Height       uint32      `json:"height,omitempty"`
+	Coinbase     bool        `json:"coinbase,omitempty"`
+}
+
+// UTXOSet tracks the outputs created and spent by the blocks mined so far,
+// kept across runs in a journal file: each connected block appends one JSON
+// line per output it creates or spends, and opening the set replays them. It
+// remembers spent outputs too, including ones created before the first
+// connected block, so a transaction spending one again can be told apart
+// from one spending an output the set never saw. It is safe for concurrent
+// use.
+type UTXOSet struct {
+	mu      sync.RWMutex
+	coins   map[Outpoint]Coin
+	journal *os.File
+}
+
+// OpenUTXOSet opens the set journaled at path, creating an empty one if the
+// file does not exist
+func OpenUTXOSet(path string) (*UTXOSet, error) {
+	set := &UTXOSet{coins: make(map[Outpoint]Coin)}
+	journal, err := openJournal(path, func(line []byte) error {
+		var record utxoRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		return set.apply(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	set.journal = journal
+	return set, nil
+}
+
+// Errors of connecting a block to a UTXOSet
+var (
+	ErrUnknownRecord = errors.New("unknown utxo journal record")
+	ErrCoinExists    = errors.New("output already created")
+)
+
+// apply updates the coins with one record
+func (s *UTXOSet) apply(record utxoRecord) error {
+	outpoint := Outpoint{record.Txid, record.Vout}
+	switch record.Op {
+	case "create":
+		if _, ok := s.coins[outpoint]; ok {
+			return fmt.Errorf("%w: %s", ErrCoinExists, outpoint)
+		}
+		s.coins[outpoint] = Coin{Value: record.Value, ScriptPubKey: record.ScriptPubKey, Height: record.Height, Coinbase: record.Coinbase}
+	case "spend":
+		// An output created before the first connected block is only known
+		// to be spent
+		coin := s.coins[outpoint]
+		coin.Spent = true
+		s.coins[outpoint] = coin
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownRecord, record.Op)
+	}
+	return nil
+}
+
+// Coin returns the output at an outpoint, reporting false if no mined block
+// created or spent it
+func (s *UTXOSet) Coin(txid string, vout int) (Coin, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	coin, ok := s.coins[Outpoint{txid, vout}]
+	return coin, ok
+}
+
+// Len returns the number of unspent outputs
+func (s *UTXOSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	unspent := 0
+	for _, coin := range s.coins {
+		if !coin.Spent {
+			unspent++
+		}
+	}
+	return unspent
+}
+
+// ConnectBlock records the outputs a mined block at height spends and
+// creates, writing them to the journal before applying them. Provably
+// unspendable OP_RETURN outputs are not recorded.
+func (s *UTXOSet) ConnectBlock(mined block.Block, height uint32) error {
+	var records []utxoRecord
+	for _, transaction := range mined.Transactions {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return err
+		}
+		coinbase := len(transaction.Vin) > 0 && transaction.Vin[0].IsCoinbase
+		if !coinbase {
+			for _, vin := range transaction.Vin {
+				records = append(records, utxoRecord{Op: "spend", Txid: vin.Txid, Vout: vin.Vout})
+			}
+		}
+		for vout, output := range transaction.Vout {
+			if len(output.ScriptPubKey) > 0 && output.ScriptPubKey[0] == script.OpReturn {
+				continue
+			}
+			records = append(records, utxoRecord{
+				Op: "create", Txid: txid, Vout: vout, Value: output.Value,
+				ScriptPubKey: output.ScriptPubKey, Height: height, Coinbase: coinbase,
+			})
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A record that cannot be applied must not reach the journal, or every
+	// later replay would fail on it
+	for _, record := range records {
+		outpoint := Outpoint{record.Txid, record.Vout}
+		if _, ok := s.coins[outpoint]; ok && record.Op == "create" {
+			return fmt.Errorf("%w: %s", ErrCoinExists, outpoint)
+		}
+	}
+	if err := appendJournal(s.journal, records); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := s.apply(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the journal
+func (s *UTXOSet) Close() error {
+	return s.journal.Close()
+}
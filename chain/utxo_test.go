@@ -0,0 +1,151 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// outsideTxid is the txid of an output created before the first connected block
+var outsideTxid = strings.Repeat("11", 32)
+
+// testBlock returns a block with a coinbase paying 50 BTC and a transaction
+// spending an outside output and paying a new one, and the txids of both
+func testBlock(t *testing.T) (block.Block, string, string) {
+	t.Helper()
+	script, _ := hex.DecodeString("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a")
+	coinbase := block.CreateCoinbaseTransaction()
+	coinbase.Vin[0].ScriptSig = []byte{0x01, 100}
+	coinbase.Vout[0].ScriptPubKey = script
+	coinbase.Vout[0].Value = 5000000000
+	spend := tx.Transaction{
+		Version: 2,
+		Vin:     []tx.TxInput{{Txid: outsideTxid, PrevOut: tx.Prevout{ScriptPubKey: script, Value: 100000}}},
+		Vout: []tx.TxOutput{
+			{ScriptPubKey: script, Value: 90000},
+			{ScriptPubKey: []byte{0x6a, 0x01, 0x00}}, // OP_RETURN, never recorded
+		},
+	}
+	coinbaseTxid, err := tx.Txid(coinbase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendTxid, err := tx.Txid(spend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block.Block{Transactions: []tx.Transaction{coinbase, spend}}, coinbaseTxid, spendTxid
+}
+
+func TestUTXOSetReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utxo.jsonl")
+	mined, coinbaseTxid, spendTxid := testBlock(t)
+
+	coins, err := OpenUTXOSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coins.ConnectBlock(mined, 100); err != nil {
+		t.Fatal(err)
+	}
+	// The second time creates outputs that exist, and writes nothing
+	if err := coins.ConnectBlock(mined, 100); !errors.Is(err, ErrCoinExists) {
+		t.Errorf("connecting the block twice: got %v, want %v", err, ErrCoinExists)
+	}
+	coins.Close()
+
+	// The journal replays to the same state
+	coins, err = OpenUTXOSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer coins.Close()
+	tests := []struct {
+		name     string
+		outpoint Outpoint
+		want     Coin
+		ok       bool
+	}{
+		{"coinbase output", Outpoint{coinbaseTxid, 0}, Coin{Value: 5000000000, Height: 100, Coinbase: true}, true},
+		{"created output", Outpoint{spendTxid, 0}, Coin{Value: 90000, Height: 100}, true},
+		{"OP_RETURN output", Outpoint{spendTxid, 1}, Coin{}, false},
+		{"spent outside output", Outpoint{outsideTxid, 0}, Coin{Spent: true}, true},
+		{"unknown output", Outpoint{outsideTxid, 1}, Coin{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			coin, ok := coins.Coin(test.outpoint.Txid, test.outpoint.Vout)
+			coin.ScriptPubKey = nil
+			if ok != test.ok || !reflect.DeepEqual(coin, test.want) {
+				t.Errorf("got %+v, %v, want %+v, %v", coin, ok, test.want, test.ok)
+			}
+		})
+	}
+	if coins.Len() != 2 {
+		t.Errorf("%d unspent outputs, want 2", coins.Len())
+	}
+}
+
+func TestOpenUTXOSetTruncatedJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utxo.jsonl")
+	mined, _, _ := testBlock(t)
+	coins, err := OpenUTXOSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coins.ConnectBlock(mined, 100); err != nil {
+		t.Fatal(err)
+	}
+	coins.Close()
+	journal, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(journal), "\n")
+	if len(lines) != 4 || lines[3] != "" {
+		t.Fatalf("journal has lines %q, want three records", lines)
+	}
+
+	tests := []struct {
+		name      string
+		contents  string
+		message   string // of the error, empty if the set opens
+		truncated bool
+	}{
+		{"complete", lines[0] + lines[1], "", false},
+		{"torn record", lines[0] + lines[1] + lines[2][:10], fmt.Sprintf("line 3: %v; the %d bytes before it are complete", ErrTruncatedJournal, len(lines[0])+len(lines[1])), true},
+		{"newline missing", lines[0] + strings.TrimSuffix(lines[1], "\n"), fmt.Sprintf("line 2: %v; the %d bytes", ErrTruncatedJournal, len(lines[0])), true},
+		{"corrupt complete record", lines[0] + "{\n", "line 2: unexpected end of JSON input", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(test.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			coins, err := OpenUTXOSet(path)
+			if err == nil {
+				coins.Close()
+			}
+			if test.message == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("got %v, want it to contain %q", err, test.message)
+			}
+			if errors.Is(err, ErrTruncatedJournal) != test.truncated {
+				t.Errorf("got %v, truncated %v", err, test.truncated)
+			}
+		})
+	}
+}
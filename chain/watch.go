@@ -0,0 +1,43 @@
+package chain
+
+import (
+	"context"
+	"time"
+)
+
+// WatchTip polls source every interval and sends each tip whose previous
+// block hash differs from the last one seen, starting from current. Errors
+// reading the tip are passed to onError and do not stop the polling. The
+// channel is closed once ctx is done.
+func WatchTip(ctx context.Context, source TipSource, current [32]byte, interval time.Duration, onError func(error)) <-chan Tip {
+	tips := make(chan Tip)
+	go func() {
+		defer close(tips)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			tip, err := source.Tip(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					onError(err)
+				}
+				continue
+			}
+			if tip.PrevBlockHash == current {
+				continue
+			}
+			select {
+			case tips <- tip:
+				current = tip.PrevBlockHash
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return tips
+}
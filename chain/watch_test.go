@@ -0,0 +1,38 @@
+package chain
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// tipSequence is a TipSource returning its tips in turn, then the last one
+type tipSequence struct {
+	tips  []Tip
+	calls *int
+}
+
+func (t tipSequence) Tip(ctx context.Context) (Tip, error) {
+	tip := t.tips[min(*t.calls, len(t.tips)-1)]
+	*t.calls++
+	return tip, ctx.Err()
+}
+
+// Polls seeing the current tip, a new one twice and a third report only the
+// two changes
+func TestWatchTip(t *testing.T) {
+	tips := []Tip{{Height: 1}, {PrevBlockHash: [32]byte{1}, Height: 2}, {PrevBlockHash: [32]byte{1}, Height: 2}, {PrevBlockHash: [32]byte{2}, Height: 3}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var calls int
+	var heights []uint32
+	for tip := range WatchTip(ctx, tipSequence{tips, &calls}, [32]byte{}, time.Millisecond, func(error) {}) {
+		if heights = append(heights, tip.Height); len(heights) == 2 {
+			cancel()
+		}
+	}
+	if !slices.Equal(heights, []uint32{2, 3}) {
+		t.Errorf("new tips at heights %v, want [2 3]", heights)
+	}
+}
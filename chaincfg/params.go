@@ -0,0 +1,113 @@
+// Package chaincfg defines the parameters of the Bitcoin networks the miner can
+// build blocks for.
+package chaincfg
+
+import "fmt"
+
+// Params describes a Bitcoin network
+type Params struct {
+	Name        string
+	NetMagic    uint32 // P2P message start bytes, little-endian
+	DefaultPort string
+
+	// Address encoding
+	Bech32HRP        string
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+
+	// Subsidy schedule: BaseSubsidy halves every SubsidyHalvingInterval blocks
+	BaseSubsidy            int64
+	SubsidyHalvingInterval uint32
+	CoinbaseMaturity       uint32
+
+	// DefaultTarget is the big-endian target mined against when none is given.
+	// It is far easier than the real network difficulty so blocks can be found
+	// on a CPU; for mainnet it is the challenge target.
+	DefaultTarget [32]byte
+	MaxWeight     int
+
+	// MinBlockVersion is the lowest header version accepted, 4 once BIP34,
+	// BIP66 and BIP65 are active; BIP9 versions are above it
+	MinBlockVersion int32
+}
+
+// challengeTarget is the difficulty target of the Summer of Bitcoin challenge
+var challengeTarget = [32]byte{0x00, 0x00, 0xff, 0xff}
+
+// MainNetParams are the parameters of the main network
+var MainNetParams = Params{
+	Name:                   "mainnet",
+	NetMagic:               0xd9b4bef9,
+	DefaultPort:            "8333",
+	Bech32HRP:              "bc",
+	PubKeyHashAddrID:       0x00,
+	ScriptHashAddrID:       0x05,
+	BaseSubsidy:            50 * 100000000,
+	SubsidyHalvingInterval: 210000,
+	CoinbaseMaturity:       100,
+	DefaultTarget:          challengeTarget,
+	MaxWeight:              4000000,
+	MinBlockVersion:        4,
+}
+
+// TestNet3Params are the parameters of the version 3 test network
+var TestNet3Params = Params{
+	Name:                   "testnet",
+	NetMagic:               0x0709110b,
+	DefaultPort:            "18333",
+	Bech32HRP:              "tb",
+	PubKeyHashAddrID:       0x6f,
+	ScriptHashAddrID:       0xc4,
+	BaseSubsidy:            50 * 100000000,
+	SubsidyHalvingInterval: 210000,
+	CoinbaseMaturity:       100,
+	DefaultTarget:          challengeTarget,
+	MaxWeight:              4000000,
+	MinBlockVersion:        4,
+}
+
+// SigNetParams are the parameters of the default signet
+var SigNetParams = Params{
+	Name:                   "signet",
+	NetMagic:               0x40cf030a,
+	DefaultPort:            "38333",
+	Bech32HRP:              "tb",
+	PubKeyHashAddrID:       0x6f,
+	ScriptHashAddrID:       0xc4,
+	BaseSubsidy:            50 * 100000000,
+	SubsidyHalvingInterval: 210000,
+	CoinbaseMaturity:       100,
+	DefaultTarget:          challengeTarget,
+	MaxWeight:              4000000,
+	MinBlockVersion:        4,
+}
+
+// RegTestParams are the parameters of the regression test network, whose
+// target is its proof-of-work limit so blocks are found almost immediately
+var RegTestParams = Params{
+	Name:                   "regtest",
+	NetMagic:               0xdab5bffa,
+	DefaultPort:            "18444",
+	Bech32HRP:              "bcrt",
+	PubKeyHashAddrID:       0x6f,
+	ScriptHashAddrID:       0xc4,
+	BaseSubsidy:            50 * 100000000,
+	SubsidyHalvingInterval: 150,
+	CoinbaseMaturity:       100,
+	DefaultTarget: [32]byte{
+		0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	},
+	MaxWeight:       4000000,
+	MinBlockVersion: 4,
+}
+
+// ParamsByName returns the parameters of a network by its --network name
+func ParamsByName(name string) (*Params, error) {
+	for _, params := range []*Params{&MainNetParams, &TestNet3Params, &SigNetParams, &RegTestParams} {
+		if params.Name == name {
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown network %q", name)
+}
@@ -0,0 +1,13 @@
+package chaincfg
+
+// BlockSubsidy returns the newly created coins a block at height may pay
+// itself in satoshis: BaseSubsidy halved once every SubsidyHalvingInterval
+// blocks, rounding down, and nothing once it has been halved 64 times
+func BlockSubsidy(height uint32, params *Params) int64 {
+	halvings := height / params.SubsidyHalvingInterval
+	if halvings >= 64 {
+		// Shifting by 64 or more is undefined in Bitcoin Core's C++
+		return 0
+	}
+	return params.BaseSubsidy >> halvings
+}
@@ -0,0 +1,36 @@
+package chaincfg
+
+import "testing"
+
+// TestBlockSubsidy checks the subsidy on both sides of the halvings
+func TestBlockSubsidy(t *testing.T) {
+	tests := []struct {
+		params  *Params
+		height  uint32
+		subsidy int64
+	}{
+		{&MainNetParams, 0, 50e8},
+		{&MainNetParams, 209999, 50e8},
+		{&MainNetParams, 210000, 25e8},
+		{&MainNetParams, 419999, 25e8},
+		{&MainNetParams, 420000, 12.5e8},
+		{&MainNetParams, 629999, 12.5e8},
+		{&MainNetParams, 630000, 6.25e8},
+		{&MainNetParams, 839999, 6.25e8},
+		{&MainNetParams, 840000, 3.125e8},
+		// The last satoshi is paid before the 33rd halving
+		{&MainNetParams, 6929999, 1},
+		{&MainNetParams, 6930000, 0},
+		{&MainNetParams, 63*210000 + 209999, 0},
+		{&MainNetParams, 64 * 210000, 0},
+		{&MainNetParams, 1<<32 - 1, 0},
+		// Regtest halves every 150 blocks
+		{&RegTestParams, 149, 50e8},
+		{&RegTestParams, 150, 25e8},
+	}
+	for _, test := range tests {
+		if subsidy := BlockSubsidy(test.height, test.params); subsidy != test.subsidy {
+			t.Errorf("%s height %d: got subsidy %d, want %d", test.params.Name, test.height, subsidy, test.subsidy)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package chaincfg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BIP9 header versions: the top three bits are 001 and each of the other 29
+// bits signals readiness for one deployment
+const (
+	VersionBitsTopBits = 0x20000000
+	VersionBitsTopMask = 0xe0000000
+	VersionBitsNumBits = 29
+)
+
+// ErrBlockVersion is returned for a header version the network rejects
+var ErrBlockVersion = errors.New("invalid block version")
+
+// SignalingVersion returns the BIP9 header version signaling the deployment bits
+func SignalingVersion(bits []uint) (uint32, error) {
+	version := uint32(VersionBitsTopBits)
+	for _, bit := range bits {
+		if bit >= VersionBitsNumBits {
+			return 0, fmt.Errorf("%w: deployment bit %d, must be below %d", ErrBlockVersion, bit, VersionBitsNumBits)
+		}
+		version |= 1 << bit
+	}
+	return version, nil
+}
+
+// CheckBlockVersion verifies that a header version, read as a signed number
+// as nodes do, is at least MinBlockVersion (bad-version)
+func (p *Params) CheckBlockVersion(version uint32) error {
+	if int32(version) < p.MinBlockVersion {
+		return fmt.Errorf("%w: %#08x is below version %d required on %s", ErrBlockVersion, version, p.MinBlockVersion, p.Name)
+	}
+	return nil
+}
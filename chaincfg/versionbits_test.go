@@ -0,0 +1,38 @@
+package chaincfg
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSignalingVersion checks deployment bits are set under the top bits and
+// that the top bits themselves cannot be signaled
+func TestSignalingVersion(t *testing.T) {
+	version, err := SignalingVersion([]uint{1, 28})
+	if err != nil || version != 0x30000002 {
+		t.Errorf("signaling bits 1 and 28: %#08x, %v", version, err)
+	}
+	if _, err := SignalingVersion([]uint{29}); !errors.Is(err, ErrBlockVersion) {
+		t.Errorf("bit 29 is a top bit: got %v", err)
+	}
+}
+
+// TestCheckBlockVersion checks the minimum mainnet version, and that versions
+// are signed: a set top bit makes one negative
+func TestCheckBlockVersion(t *testing.T) {
+	tests := []struct {
+		version uint32
+		valid   bool
+	}{
+		{1, false},
+		{3, false},
+		{4, true},
+		{VersionBitsTopBits, true},
+		{0x80000000, false},
+	}
+	for _, test := range tests {
+		if err := MainNetParams.CheckBlockVersion(test.version); (err == nil) != test.valid {
+			t.Errorf("version %#08x: got %v", test.version, err)
+		}
+	}
+}
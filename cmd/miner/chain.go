@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// runChain mines blocks consecutive blocks, each extending the one before.
+// The mempool is loaded once and every mined block takes its transactions
+// out of it, so later blocks are filled from what is left. Block i is
+// written to the output path with -i before its extension; the other files
+// the pipeline writes per block end up describing the last one. It returns
+// the blocks mined before any error.
+func runChain(ctx context.Context, config PipelineConfig, blocks int) ([]miner.Result, error) {
+	config, err := config.withTip(ctx)
+	if err != nil {
+		slog.Error("error reading chain tip", "err", err)
+		return nil, err
+	}
+	config.Tip = nil // the rest of the chain extends the blocks mined here
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return nil, fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+
+	// Only the median time past of the tip is known, which stands in for the
+	// timestamps before it. That keeps every header later than a median no
+	// earlier than the real one.
+	timestamps := []uint32{config.MedianTimePast}
+	outputPath := config.OutputPath
+	var results []miner.Result
+	for i := 1; i <= blocks; i++ {
+		config.Source = txpool.MemorySource(transactions)
+		config.OutputPath = chainOutputPath(outputPath, i)
+		result, err := runPipeline(ctx, config)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		slog.Info("block added to chain", "block", i, "blocks", blocks, "height", config.Height,
+			"hash", block.HashToString(result.Hash), "file", config.OutputPath)
+
+		transactions = withoutMined(transactions, result.Block)
+		config.PrevBlockHash = result.Hash
+		config.Height++
+		timestamps = append(timestamps, result.Block.Header.Timestamp)
+		config.MedianTimePast = block.MedianTimePast(timestamps)
+	}
+	return results, nil
+}
+
+// chainOutputPath returns the output file of block i of a chain: path with
+// -i inserted before its extension
+func chainOutputPath(path string, i int) string {
+	extension := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, extension), i, extension)
+}
+
+// withoutMined returns the transactions not confirmed by mined, which spend
+// outputs of confirmed ones like any other confirmed output
+func withoutMined(transactions []tx.Transaction, mined block.Block) []tx.Transaction {
+	confirmed := make(map[string]bool, len(mined.Transactions))
+	for _, transaction := range mined.Transactions[1:] {
+		if txid, err := tx.Txid(transaction); err == nil {
+			confirmed[txid] = true
+		}
+	}
+	remaining := make([]tx.Transaction, 0, len(transactions)-len(confirmed))
+	for _, transaction := range transactions {
+		if txid, err := tx.Txid(transaction); err != nil || !confirmed[txid] {
+			remaining = append(remaining, transaction)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+)
+
+// checkpointInterval is how often the proof-of-work state is persisted
+const checkpointInterval = 10 * time.Second
+
+// checkpoint is the persisted state of an interrupted proof-of-work search.
+// The template hash is the header hash with a zero nonce; it covers the merkle
+// root, and so the coinbase and its extranonce, and the timestamp.
+type checkpoint struct {
+	TemplateHash string  `json:"template_hash"`
+	Timestamp    uint32  `json:"timestamp"`
+	NextNonce    uint32  `json:"next_nonce"`
+	LastNonce    uint32  `json:"last_nonce"` // end of the nonce range being searched
+	Hashes       uint64  `json:"hashes"`     // attempts over all resumed runs
+	Elapsed      float64 `json:"elapsed_seconds"`
+}
+
+// templateHash identifies the template a header belongs to, whatever its nonce
+func templateHash(header block.Header) string {
+	header.Nonce = 0
+	return block.HashToString(block.HashHeader(block.SerializeHeader(header)))
+}
+
+// readCheckpoint loads a checkpoint, returning nil without error when none exists
+func readCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state checkpoint
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// write persists a checkpoint atomically, so an interruption never leaves a torn file
+func (state checkpoint) write(path string) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// checkpointSolver is a CPUSolver that periodically persists its progress to
+// path and, given the checkpoint of an earlier run over the same template,
+// continues from its next nonce. The checkpoint is removed once a block is found.
+type checkpointSolver struct {
+	path   string
+	solver miner.CPUSolver
+	resume *checkpoint
+}
+
+// Solve implements miner.PowSolver
+func (s checkpointSolver) Solve(ctx context.Context, header *block.Header, target [32]byte, report func(miner.MiningProgress)) ([32]byte, error) {
+	nonces := s.solver.Range
+	if nonces == (miner.NonceRange{}) {
+		nonces = miner.NonceRange{First: header.Nonce, Last: 0xFFFFFFFF}
+	}
+	state := checkpoint{TemplateHash: templateHash(*header), Timestamp: header.Timestamp, NextNonce: nonces.First, LastNonce: nonces.Last}
+	if s.resume != nil {
+		if s.resume.TemplateHash == state.TemplateHash && s.resume.LastNonce == nonces.Last &&
+			s.resume.NextNonce >= nonces.First && s.resume.NextNonce <= nonces.Last {
+			slog.Info("resuming proof of work", "checkpoint", s.path, "next_nonce", s.resume.NextNonce, "hashes", s.resume.Hashes)
+			nonces.First = s.resume.NextNonce
+			state = *s.resume
+		} else {
+			slog.Warn("checkpoint is for another template or nonce range, starting over", "checkpoint", s.path)
+		}
+	}
+	hashesBefore, elapsedBefore := state.Hashes, state.Elapsed
+
+	// Workers add their hashes in batches and interleave, so the counted
+	// hashes trail the nonces actually covered. Resume a little early.
+	margin := uint64(max(s.solver.Workers, 1)) * 8192
+	save := func(p miner.MiningProgress) {
+		next := uint64(nonces.First)
+		if p.Hashes > margin {
+			next += p.Hashes - margin
+		}
+		state.NextNonce = uint32(min(next, uint64(nonces.Last)))
+		state.Hashes = hashesBefore + p.Hashes
+		state.Elapsed = elapsedBefore + p.Elapsed.Seconds()
+		if err := state.write(s.path); err != nil {
+			slog.Warn("error writing checkpoint", "checkpoint", s.path, "err", err)
+		}
+	}
+
+	lastSave := time.Now()
+	var last miner.MiningProgress
+	solver := s.solver
+	solver.Range = nonces
+	hash, err := solver.Solve(ctx, header, target, func(p miner.MiningProgress) {
+		last = p
+		if time.Since(lastSave) >= checkpointInterval {
+			save(p)
+			lastSave = time.Now()
+		}
+		if report != nil {
+			report(p)
+		}
+	})
+	if err != nil {
+		save(last)
+		slog.Info("checkpoint written", "checkpoint", s.path, "next_nonce", state.NextNonce, "hashes", state.Hashes)
+		return hash, err
+	}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("error removing checkpoint", "checkpoint", s.path, "err", err)
+	}
+	return hash, nil
+}
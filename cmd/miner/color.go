@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// ANSI escape sequences of the console styles
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// palette styles console text with ANSI escapes, or leaves it plain when false
+type palette bool
+
+// Palettes of stdout and stderr, set up by setupColors
+var stdoutColors, stderrColors palette
+
+func (p palette) paint(style, text string) string {
+	if !p || style == "" {
+		return text
+	}
+	return style + text + ansiReset
+}
+
+func (p palette) bold(text string) string   { return p.paint(ansiBold, text) }
+func (p palette) red(text string) string    { return p.paint(ansiRed, text) }
+func (p palette) green(text string) string  { return p.paint(ansiGreen, text) }
+func (p palette) yellow(text string) string { return p.paint(ansiYellow, text) }
+
+// setupColors enables the palette of stdout and stderr when each is a
+// terminal, unless disabled by noColor, the NO_COLOR environment variable or
+// TERM=dumb
+func setupColors(noColor bool) {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return
+	}
+	stdoutColors, stderrColors = palette(isTerminal(os.Stdout)), palette(isTerminal(os.Stderr))
+}
+
+// isTerminal reports whether file is a terminal rather than a pipe or a file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorOutput is where the text handler of a colorHandler and its derived
+// handlers write a record, before it is styled and copied to the console
+type colorOutput struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+	w      io.Writer
+}
+
+// colorHandler is a slog text handler whose warnings print in yellow and
+// errors in red
+type colorHandler struct {
+	slog.Handler
+	out *colorOutput
+}
+
+// newColorHandler returns a text handler writing styled records to w
+func newColorHandler(w io.Writer, options *slog.HandlerOptions) colorHandler {
+	out := &colorOutput{w: w}
+	return colorHandler{slog.NewTextHandler(&out.buffer, options), out}
+}
+
+func (h colorHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+	h.out.buffer.Reset()
+	if err := h.Handler.Handle(ctx, record); err != nil {
+		return err
+	}
+	line := bytes.TrimSuffix(h.out.buffer.Bytes(), []byte("\n"))
+	style := ""
+	switch {
+	case record.Level >= slog.LevelError:
+		style = ansiRed
+	case record.Level >= slog.LevelWarn:
+		style = ansiYellow
+	}
+	_, err := io.WriteString(h.out.w, palette(true).paint(style, string(line))+"\n")
+	return err
+}
+
+func (h colorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return colorHandler{h.Handler.WithAttrs(attrs), h.out}
+}
+
+func (h colorHandler) WithGroup(name string) slog.Handler {
+	return colorHandler{h.Handler.WithGroup(name), h.out}
+}
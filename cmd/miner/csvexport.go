@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// csvHeader names the columns of the CSV export
+var csvHeader = []string{"position", "txid", "wtxid", "fee", "weight", "fee_rate", "input_types", "output_types"}
+
+// writeCSV writes one CSV row per transaction a mined block selected, the
+// coinbase aside, in block order. Fee rates are in sat/vB; the script types of
+// the prevouts spent and of the outputs are listed in order, separated by
+// semicolons.
+func writeCSV(path string, minedBlock block.Block) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write(csvHeader)
+	for position := 1; position < len(minedBlock.Transactions); position++ {
+		transaction := minedBlock.Transactions[position]
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", position, err)
+		}
+		wtxid, err := tx.Wtxid(transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", position, err)
+		}
+		weight, err := tx.Weight(transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", position, err)
+		}
+		inputTypes := make([]string, len(transaction.Vin))
+		for i, vin := range transaction.Vin {
+			inputTypes[i] = script.ClassifyScript(vin.PrevOut.ScriptPubKey).String()
+		}
+		outputTypes := make([]string, len(transaction.Vout))
+		for i, vout := range transaction.Vout {
+			outputTypes[i] = script.ClassifyScript(vout.ScriptPubKey).String()
+		}
+		w.Write([]string{
+			strconv.Itoa(position), txid, wtxid,
+			strconv.FormatInt(tx.Fee(transaction), 10), strconv.Itoa(weight),
+			strconv.FormatFloat(tx.FeeRate(transaction), 'f', 2, 64),
+			strings.Join(inputTypes, ";"), strings.Join(outputTypes, ";"),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
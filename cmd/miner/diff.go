@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// diffBlock is what the diff command knows of one of the blocks it compares
+type diffBlock struct {
+	Path     string
+	Header   *block.Header // nil when the file only holds the header hash
+	Hash     string
+	Coinbase tx.Transaction
+	Txids    []string                  // transactions after the coinbase, in block order
+	Known    map[string]tx.Transaction // the transactions whose fee and weight are known, by txid
+}
+
+// readDiffBlock reads a block from an output file, a hex block such as
+// --compact-block's input or a raw binary block
+func readDiffBlock(path string) (diffBlock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diffBlock{}, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if raw, err := hex.DecodeString(string(trimmed)); err == nil && len(raw) > block.HeaderSize {
+		data = raw
+	}
+	if parsed, err := block.ParseBlock(data); err == nil {
+		return newDiffBlock(path, parsed)
+	}
+	return readOutputFile(path, trimmed)
+}
+
+// newDiffBlock describes a whole block. Its transactions carry no prevouts,
+// so their fees are looked up in the mempool as those of an output file.
+func newDiffBlock(path string, parsed block.Block) (diffBlock, error) {
+	if len(parsed.Transactions) == 0 {
+		return diffBlock{}, fmt.Errorf("%s: block without a coinbase", path)
+	}
+	hash := block.HashHeader(block.SerializeHeader(parsed.Header))
+	b := diffBlock{Path: path, Header: &parsed.Header, Hash: block.HashToString(hash), Coinbase: parsed.Transactions[0]}
+	for i, transaction := range parsed.Transactions[1:] {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return diffBlock{}, fmt.Errorf("%s: transaction %d: %w", path, i+1, err)
+		}
+		b.Txids = append(b.Txids, txid)
+	}
+	return b, nil
+}
+
+// readOutputFile parses the lines of an output file: the block header or its
+// hash, the coinbase as hex or JSON, and the txids, the coinbase's first if
+// listed
+func readOutputFile(path string, data []byte) (diffBlock, error) {
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 2 {
+		return diffBlock{}, fmt.Errorf("%s: neither a block nor an output file", path)
+	}
+	b := diffBlock{Path: path}
+	first, err := hex.DecodeString(strings.TrimSpace(lines[0]))
+	switch {
+	case err != nil:
+		return diffBlock{}, fmt.Errorf("%s: line 1: %w", path, err)
+	case len(first) == block.HeaderSize:
+		header, _ := block.ParseHeader(first)
+		b.Header, b.Hash = &header, block.HashToString(block.HashHeader(first))
+	case len(first) == 32:
+		b.Hash = block.HashToString([32]byte(first))
+	default:
+		return diffBlock{}, fmt.Errorf("%s: line 1 is neither a block header nor a hash", path)
+	}
+
+	coinbase := strings.TrimSpace(lines[1])
+	if strings.HasPrefix(coinbase, "{") {
+		err = json.Unmarshal([]byte(coinbase), &b.Coinbase)
+	} else if raw, hexErr := hex.DecodeString(coinbase); hexErr != nil {
+		err = hexErr
+	} else {
+		b.Coinbase, err = tx.Parse(raw)
+	}
+	if err != nil {
+		return diffBlock{}, fmt.Errorf("%s: coinbase: %w", path, err)
+	}
+	coinbaseTxid, _ := tx.Txid(b.Coinbase)
+	for i, line := range lines[2:] {
+		txid := strings.TrimSpace(line)
+		if txid == "" || i == 0 && txid == coinbaseTxid {
+			continue
+		}
+		b.Txids = append(b.Txids, txid)
+	}
+	return b, nil
+}
+
+// total returns the sums of the fees and weights of the transactions of b,
+// coinbase aside, and how many of them are known
+func (b diffBlock) total() (fees int64, weight, known int) {
+	for _, txid := range b.Txids {
+		if transaction, ok := b.Known[txid]; ok {
+			fees += tx.Fee(transaction)
+			w, _ := tx.Weight(transaction)
+			weight += w
+			known++
+		}
+	}
+	return fees, weight, known
+}
+
+// coinbaseValue returns the total value of the coinbase outputs, the subsidy
+// plus the fees the block claims
+func (b diffBlock) coinbaseValue() int64 {
+	var value int64
+	for _, output := range b.Coinbase.Vout {
+		value += output.Value
+	}
+	return value
+}
+
+// headerDifferences lists the header fields of a and b that differ, as
+// name, value in a and value in b
+func headerDifferences(a, b diffBlock) [][3]string {
+	var differences [][3]string
+	differ := func(name, valueA, valueB string) {
+		if valueA != valueB {
+			differences = append(differences, [3]string{name, valueA, valueB})
+		}
+	}
+	differ("hash", a.Hash, b.Hash)
+	if a.Header == nil || b.Header == nil {
+		return differences
+	}
+	differ("version", fmt.Sprintf("%#08x", a.Header.Version), fmt.Sprintf("%#08x", b.Header.Version))
+	differ("prevhash", block.HashToString(a.Header.PreviousBlockHash), block.HashToString(b.Header.PreviousBlockHash))
+	differ("merkle_root", block.HashToString(a.Header.MerkleRoot), block.HashToString(b.Header.MerkleRoot))
+	differ("timestamp", fmt.Sprint(a.Header.Timestamp), fmt.Sprint(b.Header.Timestamp))
+	differ("bits", fmt.Sprintf("%08x", a.Header.Bits), fmt.Sprintf("%08x", b.Header.Bits))
+	differ("nonce", fmt.Sprint(a.Header.Nonce), fmt.Sprint(b.Header.Nonce))
+	return differences
+}
+
+// onlyIn returns the txids of a that b does not include, in block order
+func onlyIn(a, b diffBlock) []string {
+	inB := make(map[string]bool, len(b.Txids))
+	for _, txid := range b.Txids {
+		inB[txid] = true
+	}
+	only := []string{}
+	for _, txid := range a.Txids {
+		if !inB[txid] {
+			only = append(only, txid)
+		}
+	}
+	return only
+}
+
+// runDiff compares the blocks of two files: their header fields, the
+// transactions only one of them includes and their fee and weight totals.
+// Fees and weights of the transactions are looked up in the mempool, when it
+// can be loaded. It reports false if a file cannot be read.
+func runDiff(ctx context.Context, paths []string) bool {
+	if len(paths) != 2 {
+		slog.Error("diff needs two files to compare")
+		return false
+	}
+	a, err := readDiffBlock(paths[0])
+	if err != nil {
+		slog.Error("error reading block", "err", err)
+		return false
+	}
+	b, err := readDiffBlock(paths[1])
+	if err != nil {
+		slog.Error("error reading block", "err", err)
+		return false
+	}
+
+	known := make(map[string]tx.Transaction)
+	if transactions, err := txSource().Transactions(ctx); err != nil {
+		slog.Warn("mempool not loaded, fees and weights of the transactions are unknown", "err", err)
+	} else {
+		for _, transaction := range transactions {
+			if txid, err := tx.Txid(transaction); err == nil {
+				known[txid] = transaction
+			}
+		}
+	}
+	a.Known, b.Known = known, known
+
+	view := newDiffView(a, b)
+	if *jsonOutput {
+		printJSON(view)
+		return true
+	}
+	printDiff(view)
+	return true
+}
+
+// printDiff prints a block comparison, what only A has in red and what only B
+// has in green
+func printDiff(view diffView) {
+	fmt.Println(stdoutColors.bold("A: " + view.A.Path))
+	fmt.Println(stdoutColors.bold("B: " + view.B.Path))
+	fmt.Println()
+	if len(view.Header) == 0 {
+		fmt.Println("Headers: identical")
+	} else {
+		fmt.Printf("%-12s %-64s %s\n", "field", "A", "B")
+		for _, field := range view.Header {
+			fmt.Printf("%-12s %-64s %s\n", field.Field, field.A, field.B)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("%-22s %16s %16s %16s\n", "", "A", "B", "B-A")
+	row := func(name string, a, b int64) {
+		fmt.Printf("%-22s %16d %16d %+16d\n", name, a, b, b-a)
+	}
+	row("transactions", int64(view.A.Transactions), int64(view.B.Transactions))
+	row("coinbase value", view.A.CoinbaseValue, view.B.CoinbaseValue)
+	row("fees (known txs)", view.A.Fees, view.B.Fees)
+	row("weight (known txs)", int64(view.A.Weight), int64(view.B.Weight))
+	row("known txs", int64(view.A.Known), int64(view.B.Known))
+
+	only := func(name string, txs []diffTxView, paint func(string) string) {
+		fmt.Println()
+		fmt.Printf("Only in %s: %d\n", name, len(txs))
+		for _, view := range txs {
+			line := view.Txid
+			if view.Known {
+				line = fmt.Sprintf("%-64s %10d %8d", view.Txid, view.Fee, view.Weight)
+			}
+			fmt.Println(paint(line))
+		}
+	}
+	only("A", view.OnlyA, stdoutColors.red)
+	only("B", view.OnlyB, stdoutColors.green)
+}
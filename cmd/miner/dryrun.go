@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+)
+
+// runDryRun runs the pipeline up to selection and prints the composition of
+// the block it would mine, without hashing or writing anything, along with
+// the candidates recorded in rejected. Errors are logged before being
+// returned.
+func runDryRun(ctx context.Context, config PipelineConfig, rejected *rejections) error {
+	start := time.Now()
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+	logStage("load", start, "transactions", len(transactions))
+	if config.Deterministic {
+		sortByTxid(transactions)
+	}
+	if config, err = config.withTip(ctx); err != nil {
+		slog.Error("error reading chain tip", "err", err)
+		return err
+	}
+
+	// With every candidate rejected the empty block is still shown, and the
+	// error still returned
+	template, err := miner.New(config.minerOptions()).BuildTemplate(ctx, transactions)
+	if errors.Is(err, miner.ErrNoValidTransactions) {
+		slog.Error("error selecting transactions", "err", err)
+	} else if err != nil {
+		if !logInterrupted("selection", err) {
+			slog.Error("error selecting transactions", "err", err)
+		}
+		return err
+	}
+	slog.Info("stage completed", "stage", "selection", "duration", template.SelectionTime,
+		"selected", template.Block.TransactionCount-1, "invalid", template.Rejected, "weight", template.Weight, "fees", template.Fees)
+	logWeightBudget(template)
+	if *jsonOutput {
+		view := dryRunView{Template: newBlockView(template, false), Selected: []txView{}, Rejected: rejected.views()}
+		for _, transaction := range template.Block.Transactions[1:] {
+			view.Selected = append(view.Selected, newTxView(transaction))
+		}
+		printJSON(view)
+		return err
+	}
+	printBlockComposition(config, template, rejected.views())
+	return err
+}
+
+// printBlockComposition prints the totals of a block template, the txid, fee,
+// weight and fee rate of each of its transactions in block order, in green,
+// and the rejected candidates with their reason, in red
+func printBlockComposition(config PipelineConfig, template miner.Result, rejected []rejectionView) {
+	fmt.Println(stdoutColors.bold(fmt.Sprintf("Block at height %d extending %s", config.Height, block.HashToString(template.Block.Header.PreviousBlockHash))))
+	fmt.Println(stdoutColors.bold(fmt.Sprint("Number of selected transactions: ", template.Block.TransactionCount-1)))
+	fmt.Println(stdoutColors.bold(fmt.Sprint("Number of rejected transactions: ", template.Rejected)))
+	fmt.Println(stdoutColors.bold(fmt.Sprint("Block weight: ", template.Weight)))
+	fmt.Println(stdoutColors.bold(fmt.Sprint("Block fees: ", template.Fees, " sats")))
+	budget := newWeightBudgetView(template)
+	fmt.Println()
+	fmt.Printf("Weight limit: %d\n", budget.Limit)
+	fmt.Printf("  coinbase: %d\n", budget.Coinbase)
+	fmt.Printf("  selected transactions: %d\n", budget.Selected)
+	fmt.Printf("  slack: %d\n", budget.Slack)
+	if budget.MarginalTxid != "" {
+		fmt.Printf("Marginal excluded transaction: %s at %.2f sat/vB\n", budget.MarginalTxid, budget.MarginalFeeRate)
+	}
+	fmt.Println()
+	fmt.Printf("%-64s %10s %8s %10s\n", "txid", "fee", "weight", "sat/vB")
+	for _, transaction := range template.Block.Transactions[1:] {
+		view := newTxView(transaction)
+		fmt.Println(stdoutColors.green(fmt.Sprintf("%-64s %10d %8d %10.2f", view.Txid, view.Fee, view.Weight, view.FeeRate)))
+	}
+	if len(rejected) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Printf("%-64s %s\n", "rejected txid", "reason")
+	for _, view := range rejected {
+		reason := view.Reason
+		if view.Input != nil {
+			reason = fmt.Sprintf("input %d: %s", *view.Input, reason)
+		}
+		if view.File != "" {
+			reason = view.File + ": " + reason
+		}
+		fmt.Println(stdoutColors.red(fmt.Sprintf("%-64s %s", view.Txid, reason)))
+	}
+}
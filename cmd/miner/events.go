@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types streamed on GET /events
+const (
+	eventTransactionsLoaded  = "transactions_loaded"
+	eventTransactionRejected = "transaction_rejected"
+	eventSelectionDone       = "selection_done"
+	eventNonceMilestone      = "nonce_milestone"
+	eventMiningProgress      = "mining_progress"
+	eventBlockFound          = "block_found"
+	eventTipChanged          = "tip_changed"
+)
+
+// nonceMilestone is the number of hashes between two nonce_milestone events
+const nonceMilestone = 1 << 20
+
+// subscriberBuffer is the number of events queued for a slow subscriber
+// before further events are dropped for it
+const subscriberBuffer = 4096
+
+// Event is a pipeline progress event, sent to subscribers as a JSON text message
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// eventHub fans pipeline events out to the connected subscribers
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+}
+
+// events is the process-wide event hub the pipeline publishes to
+var events = &eventHub{subscribers: make(map[chan []byte]struct{})}
+
+// Active reports whether anyone is subscribed, so publishers can skip
+// building events nobody receives
+func (h *eventHub) Active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers) > 0
+}
+
+// Publish sends an event to every subscriber without blocking. Subscribers
+// whose buffer is full miss the event.
+func (h *eventHub) Publish(eventType string, data any) {
+	if !h.Active() {
+		return
+	}
+	message, err := json.Marshal(Event{Type: eventType, Time: time.Now().UTC(), Data: data})
+	if err != nil {
+		slog.Error("error encoding event", "type", eventType, "err", err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns its channel of encoded events
+// and a function that unregisters it
+func (h *eventHub) Subscribe() (<-chan []byte, func()) {
+	subscriber := make(chan []byte, subscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[subscriber] = struct{}{}
+	h.mu.Unlock()
+	return subscriber, func() {
+		h.mu.Lock()
+		delete(h.subscribers, subscriber)
+		h.mu.Unlock()
+	}
+}
+
+// handleEvents serves GET /events: a WebSocket streaming every pipeline event
+// as a JSON text message until the client disconnects or the server stops
+func (s *apiServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		slog.Debug("rejected event stream", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+	defer conn.Close()
+	subscription, unsubscribe := events.Subscribe()
+	defer unsubscribe()
+
+	// Answer pings and notice when the client goes away
+	clientDone := make(chan struct{})
+	go func() {
+		defer close(clientDone)
+		for {
+			opcode, payload, err := conn.ReadFrame()
+			if err != nil || opcode == opClose {
+				return
+			}
+			if opcode == opPing {
+				conn.WriteFrame(opPong, payload)
+			}
+		}
+	}()
+
+	slog.Debug("event stream opened", "remote", r.RemoteAddr)
+	for {
+		select {
+		case message := <-subscription:
+			if err := conn.WriteFrame(opText, message); err != nil {
+				return
+			}
+		case <-clientDone:
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// runCompareGBT assembles a block from the mempool of the --rpc-url node, at
+// the height and tip of the node's own getblocktemplate, and reports how the
+// two differ in fees, weight and transactions. It reports false if either
+// template cannot be built.
+func runCompareGBT(ctx context.Context) bool {
+	if *rpcURL == "" {
+		slog.Error("compare-gbt needs --rpc-url")
+		return false
+	}
+	config, ok := pipelineConfig()
+	if !ok {
+		return false
+	}
+	node := txpool.RPCSource{URL: *rpcURL, User: *rpcUser, Password: *rpcPassword}
+	core, err := node.BlockTemplate(ctx)
+	if err != nil {
+		slog.Error("error fetching the node's block template", "err", err)
+		return false
+	}
+	if config.PrevBlockHash, err = merkle.ParseHash(core.PreviousBlockHash); err != nil {
+		slog.Error("invalid previousblockhash in the node's block template", "err", err)
+		return false
+	}
+	config.Height = core.Height
+
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return false
+	}
+	ours, err := miner.New(config.minerOptions()).BuildTemplate(ctx, transactions)
+	if err != nil && !errors.Is(err, miner.ErrNoValidTransactions) {
+		if !logInterrupted("selection", err) {
+			slog.Error("error selecting transactions", "err", err)
+		}
+		return false
+	}
+
+	maxWeight := config.MaxWeight
+	if maxWeight == 0 {
+		maxWeight = config.Params.MaxWeight
+	}
+	view := newGBTCompareView(ours, core, maxWeight)
+	if *jsonOutput {
+		printJSON(view)
+		return true
+	}
+	printGBTCompare(view)
+	return true
+}
+
+// newGBTCompareView compares our template to the node's, both filling blocks
+// of at most maxWeight
+func newGBTCompareView(ours miner.Result, core txpool.NodeTemplate, maxWeight int) gbtCompareView {
+	view := gbtCompareView{
+		Ours:     templateTotalsView{Transactions: len(ours.Block.Transactions) - 1, Fees: ours.Fees, Weight: ours.Weight},
+		Core:     templateTotalsView{Transactions: len(core.Transactions)},
+		OnlyOurs: []diffTxView{},
+		OnlyCore: []diffTxView{},
+	}
+	inCore := make(map[string]bool, len(core.Transactions))
+	for _, transaction := range core.Transactions {
+		inCore[transaction.Txid] = true
+		view.Core.Fees += transaction.Fee
+		view.Core.Weight += transaction.Weight
+	}
+	inOurs := make(map[string]bool, len(ours.Block.Transactions))
+	for _, transaction := range ours.Block.Transactions[1:] {
+		txid, _ := tx.Txid(transaction)
+		inOurs[txid] = true
+		if inCore[txid] {
+			view.Common++
+			continue
+		}
+		weight, _ := tx.Weight(transaction)
+		view.OnlyOurs = append(view.OnlyOurs, diffTxView{Txid: txid, Known: true, Fee: tx.Fee(transaction), Weight: weight})
+	}
+	for _, transaction := range core.Transactions {
+		if !inOurs[transaction.Txid] {
+			view.OnlyCore = append(view.OnlyCore, diffTxView{Txid: transaction.Txid, Known: true, Fee: transaction.Fee, Weight: transaction.Weight})
+		}
+	}
+	if maxWeight > 0 {
+		view.Ours.Utilization = 100 * float64(view.Ours.Weight) / float64(maxWeight)
+		view.Core.Utilization = 100 * float64(view.Core.Weight) / float64(maxWeight)
+	}
+	return view
+}
+
+// printGBTCompare prints our template next to the node's
+func printGBTCompare(view gbtCompareView) {
+	fmt.Printf("%-22s %16s %16s %16s\n", "", "this miner", "Bitcoin Core", "difference")
+	row := func(name string, ours, core int64) {
+		fmt.Printf("%-22s %16d %16d %+16d\n", name, ours, core, ours-core)
+	}
+	row("transactions", int64(view.Ours.Transactions), int64(view.Core.Transactions))
+	row("fees", view.Ours.Fees, view.Core.Fees)
+	row("weight", int64(view.Ours.Weight), int64(view.Core.Weight))
+	fmt.Printf("%-22s %15.2f%% %15.2f%% %+15.2f%%\n", "weight utilization",
+		view.Ours.Utilization, view.Core.Utilization, view.Ours.Utilization-view.Core.Utilization)
+	fmt.Println()
+	fmt.Println(stdoutColors.bold(fmt.Sprintf("In both templates: %d", view.Common)))
+
+	only := func(name string, txs []diffTxView, paint func(string) string) {
+		var fees int64
+		for _, view := range txs {
+			fees += view.Fee
+		}
+		fmt.Println()
+		fmt.Println(stdoutColors.bold(fmt.Sprintf("Only in %s: %d, %d sats of fees", name, len(txs), fees)))
+		for _, view := range txs {
+			fmt.Println(paint(fmt.Sprintf("%-64s %10d %8d", view.Txid, view.Fee, view.Weight)))
+		}
+	}
+	only("this miner's template", view.OnlyOurs, stdoutColors.green)
+	only("Bitcoin Core's template", view.OnlyCore, stdoutColors.red)
+}
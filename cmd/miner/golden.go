@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// runGolden runs the pipeline on the fixture mempool in goldenDir and compares the
+// produced output with the checked-in golden file, or rewrites it when update is set.
+// It returns false when the output differs or the pipeline fails.
+func runGolden(ctx context.Context, goldenDir string, update bool) bool {
+	view := checkGolden(ctx, goldenDir, update)
+	if *jsonOutput {
+		printJSON(view)
+	}
+	return view.Match || view.Updated
+}
+
+// checkGolden does the work of runGolden, logging the outcome and describing
+// it for --json
+func checkGolden(ctx context.Context, goldenDir string, update bool) goldenView {
+	goldenPath := goldenDir + "/output.txt"
+	view := goldenView{File: goldenPath}
+	outputFile, err := os.CreateTemp("", "golden-output-*.txt")
+	if err != nil {
+		slog.Error("error creating temporary output file", "err", err)
+		view.Error = err.Error()
+		return view
+	}
+	outputFile.Close()
+	defer os.Remove(outputFile.Name())
+
+	config := PipelineConfig{
+		Source:        txpool.FolderSource{Path: goldenDir + "/mempool"},
+		OutputPath:    outputFile.Name(),
+		Timestamp:     deterministicTimestamp,
+		Workers:       1,
+		Deterministic: true,
+		Quiet:         true,
+	}
+	if _, err := runPipeline(ctx, config); err != nil {
+		view.Error = err.Error()
+		return view
+	}
+
+	got, err := os.ReadFile(outputFile.Name())
+	if err != nil {
+		slog.Error("error reading produced output", "err", err)
+		view.Error = err.Error()
+		return view
+	}
+	if update {
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			slog.Error("error updating golden file", "file", goldenPath, "err", err)
+			view.Error = err.Error()
+			return view
+		}
+		slog.Info("golden file updated", "file", goldenPath)
+		view.Updated = true
+		return view
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		slog.Error("error reading golden file", "file", goldenPath, "err", err)
+		view.Error = err.Error()
+		return view
+	}
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine {
+			slog.Error("output differs from golden file", "file", goldenPath, "line", i+1, "got", gotLine, "want", wantLine)
+			view.Line, view.Got, view.Want = i+1, gotLine, wantLine
+			return view
+		}
+	}
+	slog.Info("output matches golden file", "file", goldenPath)
+	view.Match = true
+	return view
+}
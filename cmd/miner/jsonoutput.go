@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+)
+
+// printJSON writes the --json result of a command to stdout
+func printJSON(value any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		slog.Error("error writing JSON output", "err", err)
+	}
+}
+
+// errorString returns the message of err, empty if it is nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// rejectionView is a candidate transaction dropped by validation, with the
+// file it was read from and the failing input when known
+type rejectionView struct {
+	Txid   string `json:"txid"`
+	File   string `json:"file,omitempty"`
+	Input  *int   `json:"input,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// rejections collects the candidates dropped while building blocks, keeping
+// the first reason given for each txid. It is safe for concurrent use.
+type rejections struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	list []rejectionView
+}
+
+// add records a dropped candidate, as a PipelineConfig.Reject callback
+func (r *rejections) add(transaction tx.Transaction, reason error) {
+	txid, _ := tx.Txid(transaction)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[txid] {
+		return
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+	r.seen[txid] = true
+	view := rejectionView{Txid: txid, File: transaction.File, Reason: reason.Error()}
+	if context, ok := txerror.Context(reason); ok {
+		// The txid and file have fields of their own
+		view.Reason = context.Err.Error()
+		if context.Input >= 0 {
+			view.Input = &context.Input
+		}
+	}
+	r.list = append(r.list, view)
+}
+
+// views returns the dropped candidates in the order they were recorded
+func (r *rejections) views() []rejectionView {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]rejectionView{}, r.list...)
+}
+
+// mineView is the --json output of the mine and stratum commands
+type mineView struct {
+	Blocks   []blockView     `json:"blocks"`
+	Rejected []rejectionView `json:"rejected"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// newMineView describes the blocks mined by a command, the candidates it
+// dropped and the error that stopped it, if any
+func newMineView(results []miner.Result, rejected *rejections, err error) mineView {
+	view := mineView{Blocks: []blockView{}, Rejected: rejected.views(), Error: errorString(err)}
+	for _, result := range results {
+		view.Blocks = append(view.Blocks, newBlockView(result, true))
+	}
+	return view
+}
+
+// txView is a selected transaction with what it contributes to the block
+type txView struct {
+	Txid    string  `json:"txid"`
+	Fee     int64   `json:"fee"`
+	Weight  int     `json:"weight"`
+	FeeRate float64 `json:"fee_rate"`
+}
+
+// newTxView describes a selected transaction
+func newTxView(transaction tx.Transaction) txView {
+	txid, _ := tx.Txid(transaction)
+	weight, _ := tx.Weight(transaction)
+	return txView{Txid: txid, Fee: tx.Fee(transaction), Weight: weight, FeeRate: tx.FeeRate(transaction)}
+}
+
+// weightBudgetView is how a template spent its weight limit, with the fee
+// rate of the transaction the block would have taken next
+type weightBudgetView struct {
+	Limit           int     `json:"limit"`
+	Coinbase        int     `json:"coinbase"`
+	Selected        int     `json:"selected"`
+	Slack           int     `json:"slack"`
+	MarginalTxid    string  `json:"marginal_txid,omitempty"`
+	MarginalFeeRate float64 `json:"marginal_fee_rate,omitempty"`
+}
+
+// newWeightBudgetView describes the weight budget of a miner result
+func newWeightBudgetView(result miner.Result) weightBudgetView {
+	return weightBudgetView{
+		Limit:           result.WeightLimit,
+		Coinbase:        result.CoinbaseWeight,
+		Selected:        result.Weight,
+		Slack:           result.WeightLimit - result.CoinbaseWeight - result.Weight,
+		MarginalTxid:    result.MarginalTxid,
+		MarginalFeeRate: result.MarginalRate,
+	}
+}
+
+// dryRunView is the --json output of mine --dry-run
+type dryRunView struct {
+	Template blockView       `json:"template"`
+	Selected []txView        `json:"selected"`
+	Rejected []rejectionView `json:"rejected"`
+}
+
+// feeRateBucketView is a fee rate range of the stats histogram
+type feeRateBucketView struct {
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max,omitempty"` // omitted for the unbounded last bucket
+	Mempool int     `json:"mempool"`
+	Block   int     `json:"block"`
+}
+
+// scriptTypeView counts the inputs and outputs of a script type in the
+// mempool and in the block
+type scriptTypeView struct {
+	Type           string `json:"type"`
+	MempoolInputs  int    `json:"mempool_inputs"`
+	BlockInputs    int    `json:"block_inputs"`
+	MempoolOutputs int    `json:"mempool_outputs"`
+	BlockOutputs   int    `json:"block_outputs"`
+}
+
+// paidAddressView is an address paid by the selected transactions
+type paidAddressView struct {
+	Address string `json:"address"`
+	Value   int64  `json:"value"`
+}
+
+// mempoolStatsView is the --json output of the stats command
+type mempoolStatsView struct {
+	MempoolTransactions  int                 `json:"mempool_transactions"`
+	SelectedTransactions int                 `json:"selected_transactions"`
+	FeeRates             []feeRateBucketView `json:"fee_rates"`
+	FeeEstimate          feeEstimateView     `json:"fee_estimate"`
+	ScriptTypes          []scriptTypeView    `json:"script_types"`
+	AddressesPaid        int                 `json:"addresses_paid"`
+	TopAddresses         []paidAddressView   `json:"top_addresses"`
+}
+
+// goldenView is the --json output of the golden command, with the first
+// differing line when the output does not match
+type goldenView struct {
+	File    string `json:"file"`
+	Match   bool   `json:"match"`
+	Updated bool   `json:"updated,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Got     string `json:"got,omitempty"`
+	Want    string `json:"want,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// lookupView is the location of a txid in the --json output of the lookup
+// command
+type lookupView struct {
+	Txid  string `json:"txid"`
+	Found bool   `json:"found"`
+	*chain.TxLocation
+}
+
+// diffView is the output of the diff command
+type diffView struct {
+	A      diffBlockView    `json:"a"`
+	B      diffBlockView    `json:"b"`
+	Header []headerDiffView `json:"header"` // fields that differ
+	OnlyA  []diffTxView     `json:"only_a"`
+	OnlyB  []diffTxView     `json:"only_b"`
+}
+
+// diffBlockView is the totals of one of the blocks compared. Fees and weight
+// add up the transactions found in the mempool, Known of them.
+type diffBlockView struct {
+	Path          string `json:"path"`
+	Hash          string `json:"hash"`
+	Transactions  int    `json:"transactions"`
+	CoinbaseValue int64  `json:"coinbase_value"`
+	Fees          int64  `json:"fees"`
+	Weight        int    `json:"weight"`
+	Known         int    `json:"known"`
+}
+
+// headerDiffView is a header field that differs between the blocks compared
+type headerDiffView struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// diffTxView is a transaction only one of the blocks compared includes, with
+// its fee and weight if it was found in the mempool
+type diffTxView struct {
+	Txid   string `json:"txid"`
+	Known  bool   `json:"known"`
+	Fee    int64  `json:"fee,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// newDiffView compares block a to block b
+func newDiffView(a, b diffBlock) diffView {
+	view := diffView{A: newDiffBlockView(a), B: newDiffBlockView(b), Header: []headerDiffView{}}
+	for _, field := range headerDifferences(a, b) {
+		view.Header = append(view.Header, headerDiffView{Field: field[0], A: field[1], B: field[2]})
+	}
+	view.OnlyA, view.OnlyB = newDiffTxViews(a, onlyIn(a, b)), newDiffTxViews(b, onlyIn(b, a))
+	return view
+}
+
+func newDiffBlockView(b diffBlock) diffBlockView {
+	fees, weight, known := b.total()
+	return diffBlockView{
+		Path: b.Path, Hash: b.Hash, Transactions: len(b.Txids), CoinbaseValue: b.coinbaseValue(),
+		Fees: fees, Weight: weight, Known: known,
+	}
+}
+
+func newDiffTxViews(b diffBlock, txids []string) []diffTxView {
+	views := make([]diffTxView, 0, len(txids))
+	for _, txid := range txids {
+		view := diffTxView{Txid: txid}
+		if transaction, ok := b.Known[txid]; ok {
+			view.Known, view.Fee = true, tx.Fee(transaction)
+			view.Weight, _ = tx.Weight(transaction)
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// gbtCompareView is the output of the compare-gbt command
+type gbtCompareView struct {
+	Ours     templateTotalsView `json:"ours"`
+	Core     templateTotalsView `json:"core"`
+	Common   int                `json:"common"` // transactions in both templates
+	OnlyOurs []diffTxView       `json:"only_ours"`
+	OnlyCore []diffTxView       `json:"only_core"`
+}
+
+// templateTotalsView is what a block template collects, coinbase aside
+type templateTotalsView struct {
+	Transactions int     `json:"transactions"`
+	Fees         int64   `json:"fees"`
+	Weight       int     `json:"weight"`
+	Utilization  float64 `json:"weight_utilization"` // percent of the weight limit
+}
+
+// scoreView is the output of the score command
+type scoreView struct {
+	File          string           `json:"file"`
+	Checks        []scoreCheckView `json:"checks"`
+	Fees          int64            `json:"fees"`
+	ReferenceFees int64            `json:"reference_fees,omitempty"` // set when every check passed
+	Weight        int              `json:"weight"`
+	MaxWeight     int              `json:"max_weight"`
+	Score         float64          `json:"score"`
+}
+
+// scoreCheckView is one check of a scored block
+type scoreCheckView struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+)
+
+// runLookup prints where the mined blocks recorded in the transaction index
+// at indexPath included each of txids. It returns false when the index cannot
+// be opened or a txid is in no indexed block.
+func runLookup(indexPath string, txids []string) bool {
+	if indexPath == "" {
+		slog.Error("lookup needs --tx-index")
+		return false
+	}
+	if len(txids) == 0 {
+		slog.Error("lookup needs at least one txid")
+		return false
+	}
+	index, err := chain.OpenTxIndex(indexPath)
+	if err != nil {
+		slog.Error("error opening transaction index", "err", err)
+		return false
+	}
+	defer index.Close()
+
+	found := true
+	views := make([]lookupView, 0, len(txids))
+	for _, txid := range txids {
+		location, ok := index.Lookup(txid)
+		found = found && ok
+		if *jsonOutput {
+			view := lookupView{Txid: txid, Found: ok}
+			if ok {
+				view.TxLocation = &location
+			}
+			views = append(views, view)
+			continue
+		}
+		if !ok {
+			fmt.Println(stdoutColors.red(txid + " not found"))
+			continue
+		}
+		fmt.Println(stdoutColors.green(fmt.Sprintf("%s block %s height %d position %d", txid, location.BlockHash, location.Height, location.Position)))
+	}
+	if *jsonOutput {
+		printJSON(views)
+	}
+	return found
+}
@@ -0,0 +1,273 @@
+// Command miner assembles a block from the mempool snapshot, mines it and writes
+// the result to output.txt. Subcommands report statistics and check its
+// output against known-good data.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Exit statuses of the mine command, one per failure class so scripts can
+// branch on why a run failed
+const (
+	exitFailure            = 1   // any failure not listed below
+	exitUsage              = 2   // invalid flags or command
+	exitLimitReached       = 3   // --max-time or --max-hashes ended the proof of work before a block was found
+	exitMempoolLoad        = 4   // the mempool could not be loaded
+	exitNoValidTransaction = 5   // every candidate transaction was rejected
+	exitTargetNotMet       = 6   // the nonce range was searched without finding a block
+	exitOutputWrite        = 7   // the block was mined but could not be written out
+	exitInterrupted        = 130 // stopped by SIGINT or SIGTERM
+)
+
+// exitStatus returns the exit status of a command that failed with err
+func exitStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, errInvalidFlags):
+		return exitUsage
+	case errors.Is(err, miner.ErrLimitReached):
+		return exitLimitReached
+	case errors.Is(err, miner.ErrMempoolLoad):
+		return exitMempoolLoad
+	case errors.Is(err, miner.ErrNoValidTransactions):
+		return exitNoValidTransaction
+	case errors.Is(err, miner.ErrTargetNotMet):
+		return exitTargetNotMet
+	case errors.Is(err, miner.ErrOutputWrite):
+		return exitOutputWrite
+	default:
+		return exitFailure
+	}
+}
+
+// Command line flags
+var (
+	logLevel         = flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat        = flag.String("log-format", "text", "log format: text or json")
+	networkName      = flag.String("network", "mainnet", "network parameters to mine with: mainnet, testnet, signet or regtest")
+	mempoolPath      = flag.String("mempool", txpool.DefaultPath, "folder of mempool JSON files to read transactions from")
+	candidateWeight  = flag.Int("candidate-weight", 0, "index the --mempool folder first and load only the best transactions up to this total weight, for snapshots too large to load whole; 0 loads every file")
+	rpcURL           = flag.String("rpc-url", "", "read the mempool from a Bitcoin Core node at this JSON-RPC URL instead of a folder")
+	rpcUser          = flag.String("rpc-user", "", "JSON-RPC user name")
+	rpcPassword      = flag.String("rpc-password", "", "JSON-RPC password")
+	payoutAddr       = flag.String("payout-address", "", "address the coinbase output pays to (segwit, P2PKH or P2SH)")
+	proofsDir        = flag.String("proofs-dir", "", "write a JSON merkle inclusion proof per block transaction into this directory")
+	compactBlockPath = flag.String("compact-block", "", "write the mined block as a hex BIP152 compact block to this file")
+	csvPath          = flag.String("csv", "", "write the txid, wtxid, fee, weight, fee rate, script types and position of each selected transaction to this CSV file")
+	submitTo         = flag.String("submit-to", "", "send the mined block to the node at this host:port over the P2P protocol (e.g. a local regtest node)")
+	nonceStart       = flag.Uint64("nonce-start", 0, "first header nonce the cpu solver tries")
+	nonceEnd         = flag.Uint64("nonce-end", 0xFFFFFFFF, "last header nonce the cpu solver tries")
+	shard            = flag.String("shard", "", "search only shard i of n equal nonce ranges, given as i/n (e.g. 0/4), instead of --nonce-start/--nonce-end")
+	stopListen       = flag.String("stop-listen", "", "stop mining when another instance reports a found block on this address")
+	stopPeers        = flag.String("stop-peers", "", "comma-separated --stop-listen addresses of other instances to tell when a block is found")
+	checkpointPath   = flag.String("checkpoint", "", "periodically save the proof-of-work progress to this file so an interrupted run can be resumed")
+	resume           = flag.Bool("resume", false, "continue the proof of work from the --checkpoint file of an interrupted run over the same mempool")
+	maxTime          = flag.Duration("max-time", 0, "give up the proof of work after this long, reporting the best hash seen (0 means no limit)")
+	maxHashes        = flag.Uint64("max-hashes", 0, "give up the proof of work after about this many hashes, reporting the best hash seen (0 means no limit)")
+	jsonOutput       = flag.Bool("json", false, "print the results of the command as JSON on stdout instead of text (serve always answers in JSON)")
+	dryRun           = flag.Bool("dry-run", false, "load, validate and select transactions, print the txids, fees and weights of the block that would be mined and exit without hashing or writing anything")
+	deterministic    = flag.Bool("deterministic", false, "make runs over the same mempool produce byte-identical output: fixed timestamp, one worker, transactions sorted by txid")
+	minFeeRate       = flag.Float64("min-feerate", 0, "drop transactions paying less than this many sat/vB before validating them")
+	includeTxids     = flag.String("include-txids", "", "file of txids, one per line, to put first in the block whatever their fee rate, if they are valid")
+	excludeTxids     = flag.String("exclude-txids", "", "file of txids, one per line, never to put in the block")
+	onlyTypes        = flag.String("only-types", "", "comma-separated script types (e.g. p2wpkh,p2tr) that every input and output of a selected transaction must have")
+	excludeTypes     = flag.String("exclude-types", "", "comma-separated script types (e.g. p2sh) that no input or output of a selected transaction may have")
+	prevHash         = flag.String("prevhash", "", "hash of the block the new block extends, as block explorers show it (default: all zeros)")
+	tipName          = flag.String("tip", "static", "chain tip to extend: static takes --prevhash, --height and --mtp; rpc asks the --rpc-url node for its best block")
+	tipPoll          = flag.Duration("tip-poll", 10*time.Second, "how often serve and stratum ask a --tip rpc node for its best block, rebuilding the template without the newly confirmed transactions and restarting mining when it changes (0 disables)")
+	utxoPath         = flag.String("utxo-db", "", "journal of the outputs created and spent by mined blocks, kept across runs; spends of outputs it knows are checked against it instead of trusting the prevout JSON")
+	txIndexPath      = flag.String("tx-index", "", "journal mapping the txids of mined blocks to the block and position that included them, kept across runs and queried by the lookup command")
+	chainLength      = flag.Int("blocks", 1, "mine this many consecutive blocks, each extending the last and leaving out the transactions already mined; block i is written to output-i.txt")
+	blockHeight      = flag.Uint("height", 0, "height of the block being mined, which sets the coinbase subsidy and is pushed first in the coinbase scriptsig (BIP34)")
+	blockVersion     = flag.String("block-version", "", "hex header version, checked against the --network minimum (default: BIP9 top bits plus --version-bits)")
+	versionBits      = flag.String("version-bits", "", "comma-separated BIP9 deployment bits (0-28) the header version signals")
+	medianTimePast   = flag.Uint("mtp", 0, "median time past of the chain tip; the header timestamp is set after it if the clock is behind")
+	prevTimestamps   = flag.String("prev-timestamps", "", "comma-separated timestamps of the blocks before this one, oldest first, whose last 11 give the median time past instead of --mtp")
+	maxWeight        = flag.Int("max-weight", 4000000, "weight limit of the block's coinbase and selected transactions, in weight units")
+	coinbaseTag      = flag.String("coinbase-tag", "", "miner tag pushed into the coinbase scriptsig after the extranonce")
+	witnessReserved  = flag.String("witness-reserved-value", "", "hex 32-byte witness reserved value of the coinbase, all zeros if empty")
+	minimalData      = flag.String("minimal-data", "strict", "strict rejects data pushes and script numbers not in their shortest encoding, as relay policy does; permissive accepts them, as consensus does")
+	quiet            = flag.Bool("quiet", false, "do not print mining progress")
+	tuiMode          = flag.Bool("tui", false, "show a live dashboard of the mine command on the terminal: mempool and selection counts, best hash against the target, a hash rate graph and the log tail")
+	noColor          = flag.Bool("no-color", false, "never color the console output (by default it is colored when printed to a terminal and NO_COLOR is not set)")
+	selectorName     = flag.String("selector", "ancestor", "transaction selection strategy: greedy, ancestor, or bnb to search for the most fees within the weight limit by branch and bound")
+	bnbTime          = flag.Duration("bnb-time", miner.DefaultBranchAndBoundBudget, "how long --selector=bnb searches before settling for the best selection found")
+	solverName       = flag.String("solver", "cpu", "proof-of-work backend: cpu, or simulated to skip hashing")
+	workers          = flag.Int("workers", 0, "proof-of-work goroutines (0 means one per CPU)")
+	referenceFees    = flag.Int64("reference-fees", 0, "fees in sats that earn the score command its full fee points (default: the fees of this miner's own template of the mempool)")
+	goldenDir        = flag.String("golden-dir", "testdata/golden", "directory with the fixture mempool and golden output used by the golden command")
+	updateGold       = flag.Bool("update-golden", false, "rewrite the golden output instead of comparing against it")
+	listenAddr       = flag.String("listen", "127.0.0.1:8080", "address the serve command listens on")
+	stratumAddr      = flag.String("stratum-listen", "127.0.0.1:3333", "address the stratum command accepts miners on")
+	shareTarget      = flag.String("share-target", "", "big-endian hex target of shares accepted by the stratum command (default: the block target)")
+	metricsAddr      = flag.String("metrics-addr", "", "serve Prometheus metrics and pprof on this address (e.g. :9100) until interrupted")
+	cpuProfile       = flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile       = flag.String("memprofile", "", "write a heap profile to this file when the command finishes")
+)
+
+// setupLogger installs the default slog logger according to the log flags,
+// writing to w
+func setupLogger(level string, format string, w io.Writer) error {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	options := &slog.HandlerOptions{Level: slogLevel}
+
+	var handler slog.Handler
+	switch format {
+	case "text":
+		if w == os.Stderr && stderrColors {
+			handler = newColorHandler(w, options)
+		} else {
+			handler = slog.NewTextHandler(w, options)
+		}
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// startCPUProfile starts CPU profiling into path and returns the function that stops it
+func startCPUProfile(path string) (func(), error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtimepprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		runtimepprof.StopCPUProfile()
+		file.Close()
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile of the live objects to path
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	runtime.GC() // get up-to-date allocation statistics
+	return runtimepprof.WriteHeapProfile(file)
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs the command and returns the exit status of the process. Failing
+// commands return here too, so the profiles are complete whatever the status.
+func run() int {
+	// An optional subcommand comes first, followed by its flags
+	command := "mine"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	setupColors(*noColor)
+	// The dashboard shows the log itself, below its panels
+	logOutput := io.Writer(os.Stderr)
+	if *tuiMode {
+		logOutput = tuiLog
+	}
+	if err := setupLogger(*logLevel, *logFormat, logOutput); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	// Stop cleanly on SIGINT/SIGTERM instead of dying mid-stage
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *metricsAddr != "" {
+		serveMetrics(*metricsAddr)
+	}
+
+	stopCPUProfile := func() {}
+	if *cpuProfile != "" {
+		var err error
+		if stopCPUProfile, err = startCPUProfile(*cpuProfile); err != nil {
+			slog.Error("error starting CPU profile", "err", err)
+			return exitFailure
+		}
+	}
+
+	var status int
+	switch command {
+	case "mine":
+		status = exitStatus(runMine(ctx))
+	case "stats":
+		status = exitStatus(runStats(ctx))
+	case "serve":
+		status = okStatus(runServe(ctx, *listenAddr))
+	case "stratum":
+		status = okStatus(runStratum(ctx, *stratumAddr, *shareTarget))
+	case "golden":
+		status = okStatus(runGolden(ctx, *goldenDir, *updateGold))
+	case "diff":
+		status = okStatus(runDiff(ctx, flag.Args()))
+	case "compare-gbt":
+		status = okStatus(runCompareGBT(ctx))
+	case "score":
+		path := "output.txt"
+		if flag.NArg() > 0 {
+			path = flag.Arg(0)
+		}
+		status = okStatus(runScore(ctx, path, *referenceFees))
+	case "lookup":
+		status = okStatus(runLookup(*txIndexPath, flag.Args()))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		status = exitUsage
+	}
+
+	stopCPUProfile()
+	if *memProfile != "" {
+		if err := writeHeapProfile(*memProfile); err != nil {
+			slog.Error("error writing heap profile", "err", err)
+		}
+	}
+
+	// Keep the final metrics of a successful run available for scraping
+	// until the process is stopped
+	if *metricsAddr != "" && status == 0 {
+		<-ctx.Done()
+	}
+	return status
+}
+
+// okStatus is the exit status of a command reporting whether it succeeded
+func okStatus(ok bool) int {
+	if ok {
+		return 0
+	}
+	return exitFailure
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metrics holds the values exported in the Prometheus text format on --metrics-addr
+type Metrics struct {
+	mu                   sync.Mutex
+	TransactionsLoaded   int
+	TransactionsValid    int
+	TransactionsRejected int
+	BlocksMined          int
+	Hashes               uint64
+	HashRate             float64
+	BlockFees            int64
+	SelectionDuration    time.Duration
+}
+
+// metrics is the process-wide metrics instance updated by the pipeline
+var metrics = &Metrics{}
+
+// Update applies a change to the metrics while holding the lock
+func (m *Metrics) Update(change func(m *Metrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	change(m)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	writeMetric("miner_transactions_loaded_total", "counter", "Transactions loaded from the mempool.", float64(m.TransactionsLoaded))
+	writeMetric("miner_transactions_valid_total", "counter", "Transactions that passed validation.", float64(m.TransactionsValid))
+	writeMetric("miner_transactions_rejected_total", "counter", "Transactions that failed validation.", float64(m.TransactionsRejected))
+	writeMetric("miner_blocks_mined_total", "counter", "Blocks whose proof of work was found.", float64(m.BlocksMined))
+	writeMetric("miner_hashes_total", "counter", "Block header hashes computed.", float64(m.Hashes))
+	writeMetric("miner_hash_rate", "gauge", "Current proof-of-work hash rate in hashes per second.", m.HashRate)
+	writeMetric("miner_block_fees_sats", "gauge", "Total fees of the last assembled block in satoshis.", float64(m.BlockFees))
+	writeMetric("miner_selection_duration_seconds", "gauge", "Duration of the last transaction selection.", m.SelectionDuration.Seconds())
+}
+
+// serveMetrics starts the metrics HTTP server in the background
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("metrics server stopped", "addr", addr, "err", err)
+		}
+	}()
+	slog.Info("serving metrics", "addr", addr)
+}
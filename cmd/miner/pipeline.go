@@ -0,0 +1,906 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/address"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/compactblock"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/p2p"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/secp256k1"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// printMiningProgress renders mining progress on a single, refreshing line
+func printMiningProgress(p miner.MiningProgress) {
+	eta := "?"
+	if p.ETA >= 0 {
+		eta = p.ETA.Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "\rnonces %d-%d  %.0f H/s  elapsed %s  eta %s   ",
+		p.FirstNonce, p.LastNonce, p.HashRate, p.Elapsed.Round(time.Second), eta)
+}
+
+// printFeeHistogram prints the fee histogram as a table
+func printFeeHistogram(buckets []txpool.FeeRateBucket) {
+	fmt.Printf("%-14s %10s %10s %8s\n", "sat/vB", "mempool", "block", "incl%")
+	for _, bucket := range buckets {
+		label := fmt.Sprintf("%g-%g", bucket.Min, bucket.Max)
+		if bucket.Max == 0 {
+			label = fmt.Sprintf("%g+", bucket.Min)
+		}
+		included := 0.0
+		if bucket.MempoolCount > 0 {
+			included = 100 * float64(bucket.BlockCount) / float64(bucket.MempoolCount)
+		}
+		fmt.Printf("%-14s %10d %10d %7.1f%%\n", label, bucket.MempoolCount, bucket.BlockCount, included)
+	}
+}
+
+// printFeeEstimate prints the fee rate needed for next-block inclusion
+func printFeeEstimate(estimate txpool.FeeEstimate) {
+	if !estimate.Full {
+		fmt.Println("Next-block fee estimate: any fee rate, the block is not full")
+		return
+	}
+	fmt.Printf("Next-block fee estimate: %.2f sat/vB (cut-off %s)\n", estimate.FeeRate, estimate.CutOff)
+}
+
+// printScriptTypes prints how many inputs and outputs of each script type the
+// block includes out of the mempool's. A type the mempool has but the block
+// lacks entirely, as when validation drops all of it, is printed in red.
+func printScriptTypes(counts []txpool.ScriptTypeCount) {
+	fmt.Printf("%-14s %11s %10s %8s %11s %10s %8s\n", "script type", "mempool in", "block in", "incl%", "mempool out", "block out", "incl%")
+	percent := func(block, mempool int) float64 {
+		if mempool == 0 {
+			return 0
+		}
+		return 100 * float64(block) / float64(mempool)
+	}
+	for _, count := range counts {
+		line := fmt.Sprintf("%-14s %11d %10d %7.1f%% %11d %10d %7.1f%%", count.Type,
+			count.MempoolInputs, count.BlockInputs, percent(count.BlockInputs, count.MempoolInputs),
+			count.MempoolOutputs, count.BlockOutputs, percent(count.BlockOutputs, count.MempoolOutputs))
+		if count.MempoolInputs > 0 && count.BlockInputs == 0 || count.MempoolOutputs > 0 && count.BlockOutputs == 0 {
+			line = stdoutColors.red(line)
+		}
+		fmt.Println(line)
+	}
+}
+
+// topAddresses is the number of most paid addresses the stats command lists
+const topAddresses = 5
+
+// paidAddresses returns the addresses the transactions pay, most paid first,
+// and the value each receives
+func paidAddresses(params *chaincfg.Params, transactions []tx.Transaction) ([]string, map[string]int64) {
+	received := map[string]int64{}
+	for _, transaction := range transactions {
+		for _, vout := range transaction.Vout {
+			received[address.FromScript(params, vout.ScriptPubKey)] += vout.Value
+		}
+	}
+	addresses := make([]string, 0, len(received))
+	for paid := range received {
+		addresses = append(addresses, paid)
+	}
+	slices.SortFunc(addresses, func(a, b string) int {
+		return cmp.Or(cmp.Compare(received[b], received[a]), cmp.Compare(a, b))
+	})
+	return addresses, received
+}
+
+// logStage logs the completion of a pipeline stage together with its duration
+func logStage(stage string, start time.Time, attrs ...any) {
+	attrs = append([]any{"stage", stage, "duration", time.Since(start)}, attrs...)
+	slog.Info("stage completed", attrs...)
+}
+
+// logWeightBudget logs how a template spent its weight limit and the fee
+// rate of the best transaction left out, to judge how tightly it packed
+func logWeightBudget(template miner.Result) {
+	budget := newWeightBudgetView(template)
+	slog.Info("weight budget", "limit", budget.Limit, "coinbase", budget.Coinbase, "selected", budget.Selected,
+		"slack", budget.Slack, "marginal_txid", budget.MarginalTxid, "marginal_fee_rate", budget.MarginalFeeRate)
+}
+
+// logInterrupted logs the partial statistics of a stage stopped by a signal.
+// It returns false when err is not a cancellation, so the caller can report it as a failure.
+func logInterrupted(stage string, err error, attrs ...any) bool {
+	if !errors.Is(err, context.Canceled) {
+		return false
+	}
+	attrs = append([]any{"stage", stage}, attrs...)
+	slog.Warn("interrupted, stopping", attrs...)
+	return true
+}
+
+// txSource returns the transaction source selected by the command line flags
+func txSource() txpool.TxSource {
+	if *rpcURL != "" {
+		return txpool.RPCSource{URL: *rpcURL, User: *rpcUser, Password: *rpcPassword}
+	}
+	if *candidateWeight > 0 {
+		return txpool.IndexedFolderSource{Path: *mempoolPath, MaxWeight: *candidateWeight}
+	}
+	return txpool.FolderSource{Path: *mempoolPath}
+}
+
+// runStats prints the fee-rate distribution of the mempool and of the selected
+// transactions. Errors are logged before being returned.
+func runStats(ctx context.Context) error {
+	start := time.Now()
+	transactions, err := txSource().Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+	logStage("load", start, "transactions", len(transactions))
+
+	selector, err := flagSelector()
+	if err != nil {
+		slog.Error("invalid --selector", "err", err)
+		return fmt.Errorf("%w: %w", errInvalidFlags, err)
+	}
+	params, err := chaincfg.ParamsByName(*networkName)
+	if err != nil {
+		slog.Error("invalid --network", "err", err)
+		return fmt.Errorf("%w: %w", errInvalidFlags, err)
+	}
+	template, err := miner.New(miner.Options{Params: params, Selector: selector}).BuildTemplate(ctx, transactions)
+	if err != nil && !errors.Is(err, miner.ErrNoValidTransactions) {
+		if !logInterrupted("selection", err) {
+			slog.Error("error selecting transactions", "err", err)
+		}
+		return err
+	}
+	selectedTransactions := template.Block.Transactions[1:]
+	slog.Info("stage completed", "stage", "selection", "duration", template.SelectionTime, "selected", len(selectedTransactions))
+
+	histogram := txpool.BuildFeeHistogram(transactions, selectedTransactions)
+	estimate := txpool.EstimateNextBlockFee(template.Block.Transactions, params.MaxWeight)
+	scriptTypes := txpool.CountScriptTypes(transactions, selectedTransactions)
+	addresses, received := paidAddresses(params, selectedTransactions)
+	if *jsonOutput {
+		view := mempoolStatsView{
+			MempoolTransactions:  len(transactions),
+			SelectedTransactions: len(selectedTransactions),
+			FeeEstimate:          feeEstimateView{FeeRate: estimate.FeeRate, Full: estimate.Full, CutOff: estimate.CutOff},
+			AddressesPaid:        len(addresses),
+			TopAddresses:         []paidAddressView{},
+		}
+		for _, bucket := range histogram {
+			view.FeeRates = append(view.FeeRates, feeRateBucketView{Min: bucket.Min, Max: bucket.Max, Mempool: bucket.MempoolCount, Block: bucket.BlockCount})
+		}
+		for _, count := range scriptTypes {
+			view.ScriptTypes = append(view.ScriptTypes, scriptTypeView{
+				Type: count.Type.String(), MempoolInputs: count.MempoolInputs, BlockInputs: count.BlockInputs,
+				MempoolOutputs: count.MempoolOutputs, BlockOutputs: count.BlockOutputs,
+			})
+		}
+		for _, paid := range addresses[:min(len(addresses), topAddresses)] {
+			view.TopAddresses = append(view.TopAddresses, paidAddressView{Address: paid, Value: received[paid]})
+		}
+		printJSON(view)
+		return nil
+	}
+
+	fmt.Println("Number of transactions in mempool:", len(transactions))
+	fmt.Println("Number of selected transactions:", len(selectedTransactions))
+	printFeeHistogram(histogram)
+	printFeeEstimate(estimate)
+	printScriptTypes(scriptTypes)
+	fmt.Println("Number of addresses paid:", len(addresses))
+	for _, paid := range addresses[:min(len(addresses), topAddresses)] {
+		fmt.Printf("%-64s %16d sats\n", paid, received[paid])
+	}
+	return nil
+}
+
+// readTxidFile reads a file of txids, one per line. Blank lines and lines
+// starting with # are skipped.
+func readTxidFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var txids []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := merkle.ParseHash(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid txid %q", path, i+1, line)
+		}
+		txids = append(txids, strings.ToLower(line))
+	}
+	return txids, nil
+}
+
+// parseScriptTypes parses a comma-separated list of script type names
+func parseScriptTypes(list string) ([]script.ScriptType, error) {
+	var types []script.ScriptType
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		scriptType, err := script.ParseScriptType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, scriptType)
+	}
+	return types, nil
+}
+
+// parseMedianTimePast returns the median time past given by --prev-timestamps,
+// or else by --mtp
+func parseMedianTimePast() (uint32, error) {
+	if *prevTimestamps == "" {
+		if *medianTimePast > math.MaxUint32 {
+			return 0, fmt.Errorf("%d does not fit in 32 bits", *medianTimePast)
+		}
+		return uint32(*medianTimePast), nil
+	}
+	var timestamps []uint32
+	for _, field := range strings.Split(*prevTimestamps, ",") {
+		timestamp, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		timestamps = append(timestamps, uint32(timestamp))
+	}
+	return block.MedianTimePast(timestamps), nil
+}
+
+// parseBlockVersion returns the header version given by --block-version, or
+// the BIP9 version signaling --version-bits, checked against params
+func parseBlockVersion(params *chaincfg.Params) (uint32, error) {
+	if *blockVersion != "" && *versionBits != "" {
+		return 0, errors.New("--block-version and --version-bits are exclusive")
+	}
+	var version uint32
+	if *blockVersion != "" {
+		parsed, err := strconv.ParseUint(strings.TrimPrefix(*blockVersion, "0x"), 16, 32)
+		if err != nil {
+			return 0, err
+		}
+		version = uint32(parsed)
+	} else {
+		var bits []uint
+		for _, field := range strings.Split(*versionBits, ",") {
+			if strings.TrimSpace(field) == "" {
+				continue
+			}
+			bit, err := strconv.ParseUint(strings.TrimSpace(field), 10, 8)
+			if err != nil {
+				return 0, err
+			}
+			bits = append(bits, uint(bit))
+		}
+		var err error
+		if version, err = chaincfg.SignalingVersion(bits); err != nil {
+			return 0, err
+		}
+	}
+	return version, params.CheckBlockVersion(version)
+}
+
+// PipelineConfig configures one run of the load, select, mine and write pipeline
+type PipelineConfig struct {
+	Params           *chaincfg.Params // nil means mainnet
+	Source           txpool.TxSource
+	OutputPath       string
+	PayoutScript     []byte          // scriptPubKey of the coinbase output
+	CoinbaseTag      []byte          // miner tag of the coinbase scriptSig, none if empty
+	Height           uint32          // height of the block, for its subsidy and BIP34 coinbase
+	WitnessReserved  [32]byte        // witness reserved value of the coinbase
+	ProofsDir        string          // directory for per-transaction inclusion proofs, none if empty
+	CompactBlockPath string          // file for the hex BIP152 compact block, none if empty
+	CSVPath          string          // file listing the selected transactions as CSV, none if empty
+	SubmitTo         string          // host:port of a node to send the mined block to over P2P
+	Timestamp        uint32          // header timestamp, 0 means the current time
+	Version          uint32          // header version, the BIP9 top bits if zero
+	PrevBlockHash    [32]byte        // hash of the block extended, in internal byte order
+	Tip              chain.TipSource // if set, overrides PrevBlockHash, Height and MedianTimePast
+	TipPoll          time.Duration   // how often serve and stratum poll Tip for a new one, never if zero
+	Coins            *chain.UTXOSet  // outputs of mined blocks, checked against and updated with each block; none if nil
+	TxIndex          *chain.TxIndex  // locations of the transactions of mined blocks, updated with each block; none if nil
+	MedianTimePast   uint32          // the header timestamp is kept later than this
+	Workers          int             // proof-of-work goroutines, 0 means one per CPU
+	Selector         miner.Selector
+	Solver           miner.PowSolver                                // nil means a CPUSolver with Workers goroutines
+	Nonces           miner.NonceRange                               // nonces searched by the CPU solver, all if zero
+	CheckpointPath   string                                         // file the CPU solver saves its progress to, none if empty
+	Resume           bool                                           // continue from the checkpoint of an earlier run
+	MaxHashes        uint64                                         // stop the CPU solver after about this many hashes, no limit if zero
+	MaxTime          time.Duration                                  // stop the CPU solver after this long, no limit if zero
+	MaxWeight        int                                            // weight limit of the coinbase and selected transactions, Params.MaxWeight if zero
+	MinFeeRate       float64                                        // sat/vB below which transactions are dropped, 0 keeps all
+	ScriptFlags      script.Flags                                   // script verification rules, script.StandardFlags if zero
+	Include          []string                                       // txids put first in the block
+	Exclude          []string                                       // txids never put in the block
+	OnlyTypes        []script.ScriptType                            // script types every input and output must have, any if empty
+	ExcludeTypes     []script.ScriptType                            // script types no input or output may have
+	Quiet            bool                                           // suppress the mining progress line
+	Reject           func(transaction tx.Transaction, reason error) // called for every candidate dropped by validation, if set
+	Deterministic    bool                                           // sort the transactions by txid and derive the compact block nonce from the block
+}
+
+// deterministicTimestamp is the header timestamp of --deterministic and golden
+// runs, so their output is reproducible
+const deterministicTimestamp = 1713744000
+
+// flagSelector returns the --selector strategy, searching for --bnb-time if
+// it is bnb
+func flagSelector() (miner.Selector, error) {
+	selector, err := miner.SelectorByName(*selectorName)
+	if bnb, ok := selector.(miner.BranchAndBoundSelector); ok {
+		bnb.Budget = *bnbTime
+		return bnb, nil
+	}
+	return selector, err
+}
+
+// pipelineConfig builds the pipeline configuration selected by the command
+// line flags. Invalid flags are logged and reported as false.
+func pipelineConfig() (PipelineConfig, bool) {
+	params, err := chaincfg.ParamsByName(*networkName)
+	if err != nil {
+		slog.Error("invalid --network", "err", err)
+		return PipelineConfig{}, false
+	}
+	selector, err := flagSelector()
+	if err != nil {
+		slog.Error("invalid --selector", "err", err)
+		return PipelineConfig{}, false
+	}
+	var payoutScript []byte
+	if *payoutAddr != "" {
+		if payoutScript, err = address.ToScript(params, *payoutAddr); err != nil {
+			slog.Error("invalid --payout-address", "err", err)
+			return PipelineConfig{}, false
+		}
+	}
+	nonces, err := nonceRange()
+	if err != nil {
+		slog.Error("invalid nonce range", "err", err)
+		return PipelineConfig{}, false
+	}
+	scriptFlags := script.StandardFlags
+	switch *minimalData {
+	case "strict":
+	case "permissive":
+		scriptFlags &^= script.VerifyMinimalData
+	default:
+		slog.Error("invalid --minimal-data, want strict or permissive", "value", *minimalData)
+		return PipelineConfig{}, false
+	}
+	var include, exclude []string
+	if *includeTxids != "" {
+		if include, err = readTxidFile(*includeTxids); err != nil {
+			slog.Error("invalid --include-txids", "err", err)
+			return PipelineConfig{}, false
+		}
+	}
+	if *excludeTxids != "" {
+		if exclude, err = readTxidFile(*excludeTxids); err != nil {
+			slog.Error("invalid --exclude-txids", "err", err)
+			return PipelineConfig{}, false
+		}
+	}
+	if *blockHeight > math.MaxUint32 {
+		slog.Error("invalid --height, must fit in 32 bits", "height", *blockHeight)
+		return PipelineConfig{}, false
+	}
+	if err := miner.CheckCoinbaseScript(miner.CoinbaseScriptSig(uint32(*blockHeight), 0, []byte(*coinbaseTag))); err != nil {
+		slog.Error("invalid --coinbase-tag", "err", err)
+		return PipelineConfig{}, false
+	}
+	var witnessReservedValue [32]byte
+	if *witnessReserved != "" {
+		decoded, err := hex.DecodeString(*witnessReserved)
+		if err != nil || len(decoded) != len(witnessReservedValue) {
+			slog.Error("invalid --witness-reserved-value, want 32 hex bytes", "value", *witnessReserved)
+			return PipelineConfig{}, false
+		}
+		copy(witnessReservedValue[:], decoded)
+	}
+	if *maxWeight <= 0 {
+		slog.Error("invalid --max-weight", "max_weight", *maxWeight)
+		return PipelineConfig{}, false
+	}
+	only, err := parseScriptTypes(*onlyTypes)
+	if err != nil {
+		slog.Error("invalid --only-types", "err", err)
+		return PipelineConfig{}, false
+	}
+	excludedTypes, err := parseScriptTypes(*excludeTypes)
+	if err != nil {
+		slog.Error("invalid --exclude-types", "err", err)
+		return PipelineConfig{}, false
+	}
+	var prevBlockHash [32]byte
+	if *prevHash != "" {
+		hash, err := merkle.ParseHash(*prevHash)
+		if err != nil {
+			slog.Error("invalid --prevhash", "err", err)
+			return PipelineConfig{}, false
+		}
+		prevBlockHash = hash
+	}
+	var tip chain.TipSource
+	switch *tipName {
+	case "static":
+	case "rpc":
+		if *rpcURL == "" {
+			slog.Error("--tip rpc needs the --rpc-url of a node")
+			return PipelineConfig{}, false
+		}
+		tip = chain.RPCTipSource{Node: txpool.RPCSource{URL: *rpcURL, User: *rpcUser, Password: *rpcPassword}}
+	default:
+		slog.Error("invalid --tip, want static or rpc", "tip", *tipName)
+		return PipelineConfig{}, false
+	}
+	version, err := parseBlockVersion(params)
+	if err != nil {
+		slog.Error("invalid header version", "err", err)
+		return PipelineConfig{}, false
+	}
+	mtp, err := parseMedianTimePast()
+	if err != nil {
+		slog.Error("invalid median time past", "err", err)
+		return PipelineConfig{}, false
+	}
+	if *chainLength < 1 {
+		slog.Error("invalid --blocks, must be at least 1", "blocks", *chainLength)
+		return PipelineConfig{}, false
+	}
+	if *chainLength > 1 && *resume {
+		slog.Error("--resume cannot continue a chain of --blocks")
+		return PipelineConfig{}, false
+	}
+	if *resume && *checkpointPath == "" {
+		slog.Error("--resume needs the --checkpoint file to resume from")
+		return PipelineConfig{}, false
+	}
+	var solver miner.PowSolver
+	switch *solverName {
+	case "cpu":
+	case "simulated":
+		solver = miner.SimulatedSolver{}
+	default:
+		slog.Error("invalid --solver", "solver", *solverName)
+		return PipelineConfig{}, false
+	}
+	config := PipelineConfig{
+		Params:           params,
+		Source:           txSource(),
+		OutputPath:       "output.txt",
+		PayoutScript:     payoutScript,
+		CoinbaseTag:      []byte(*coinbaseTag),
+		Height:           uint32(*blockHeight),
+		Version:          version,
+		PrevBlockHash:    prevBlockHash,
+		Tip:              tip,
+		TipPoll:          *tipPoll,
+		MedianTimePast:   mtp,
+		WitnessReserved:  witnessReservedValue,
+		ProofsDir:        *proofsDir,
+		CompactBlockPath: *compactBlockPath,
+		CSVPath:          *csvPath,
+		SubmitTo:         *submitTo,
+		Workers:          *workers,
+		Selector:         selector,
+		Solver:           solver,
+		Nonces:           nonces,
+		CheckpointPath:   *checkpointPath,
+		Resume:           *resume,
+		MaxHashes:        *maxHashes,
+		MaxTime:          *maxTime,
+		MaxWeight:        *maxWeight,
+		MinFeeRate:       *minFeeRate,
+		ScriptFlags:      scriptFlags,
+		Include:          include,
+		Exclude:          exclude,
+		OnlyTypes:        only,
+		ExcludeTypes:     excludedTypes,
+		Quiet:            *quiet,
+	}
+	if *deterministic {
+		// A single worker finds the lowest valid nonce; several may find any
+		config.Timestamp = deterministicTimestamp
+		config.Workers = 1
+		config.Deterministic = true
+	}
+	return config, true
+}
+
+// runMine assembles a block from the mempool and writes it to the output file.
+// The error, already logged, is returned so the exit status can reflect it.
+func runMine(ctx context.Context) error {
+	config, ok := pipelineConfig()
+	if !ok {
+		return errInvalidFlags
+	}
+	if *stopListen != "" {
+		var stop context.CancelFunc
+		var err error
+		if ctx, stop, err = listenForStop(ctx, *stopListen); err != nil {
+			slog.Error("error listening for stop messages", "addr", *stopListen, "err", err)
+			return err
+		}
+		defer stop()
+	}
+	if *utxoPath != "" {
+		coins, err := chain.OpenUTXOSet(*utxoPath)
+		if err != nil {
+			slog.Error("error opening UTXO set", "err", err)
+			return err
+		}
+		defer coins.Close()
+		config.Coins = coins
+	}
+	if *tuiMode {
+		if *jsonOutput || *dryRun {
+			slog.Error("--tui cannot be combined with --json or --dry-run")
+			return errInvalidFlags
+		}
+		// The dashboard replaces the progress line
+		config.Quiet = true
+		maxWeight := config.MaxWeight
+		if maxWeight == 0 {
+			maxWeight = config.Params.MaxWeight
+		}
+		defer startDashboard(ctx, maxWeight)()
+	}
+	// The dry run lists the rejected candidates in text as well
+	var rejected rejections
+	if *jsonOutput || *dryRun {
+		config.Reject = rejected.add
+	}
+	if *dryRun {
+		if *chainLength > 1 {
+			slog.Error("--dry-run cannot preview a chain of --blocks")
+			return errInvalidFlags
+		}
+		return runDryRun(ctx, config, &rejected)
+	}
+	if *txIndexPath != "" {
+		index, err := chain.OpenTxIndex(*txIndexPath)
+		if err != nil {
+			slog.Error("error opening transaction index", "err", err)
+			return err
+		}
+		defer index.Close()
+		config.TxIndex = index
+	}
+	var results []miner.Result
+	var err error
+	if *chainLength > 1 {
+		results, err = runChain(ctx, config, *chainLength)
+	} else {
+		var result miner.Result
+		if result, err = runPipeline(ctx, config); err == nil {
+			results = append(results, result)
+			if *stopPeers != "" {
+				notifyPeers(*stopPeers, result.Hash)
+			}
+		}
+	}
+	if *jsonOutput {
+		printJSON(newMineView(results, &rejected, err))
+	}
+	return err
+}
+
+// withTip returns the configuration extending the tip of config.Tip, or
+// config itself if it has no tip source
+func (config PipelineConfig) withTip(ctx context.Context) (PipelineConfig, error) {
+	if config.Tip == nil {
+		return config, nil
+	}
+	tip, err := config.Tip.Tip(ctx)
+	if err != nil {
+		return config, fmt.Errorf("reading the chain tip: %w", err)
+	}
+	slog.Info("extending chain tip", "prevhash", block.HashToString(tip.PrevBlockHash), "height", tip.Height, "mtp", tip.MedianTimePast)
+	config.PrevBlockHash, config.Height, config.MedianTimePast = tip.PrevBlockHash, tip.Height, tip.MedianTimePast
+	return config, nil
+}
+
+// minerOptions returns the miner options of a pipeline configuration, without
+// a progress callback
+func (config PipelineConfig) minerOptions() miner.Options {
+	options := miner.Options{
+		Params:               config.Params,
+		PayoutScript:         config.PayoutScript,
+		CoinbaseTag:          config.CoinbaseTag,
+		Height:               config.Height,
+		Version:              config.Version,
+		PrevBlockHash:        config.PrevBlockHash,
+		MedianTimePast:       config.MedianTimePast,
+		WitnessReservedValue: config.WitnessReserved,
+		MaxWeight:            config.MaxWeight,
+		MinFeeRate:           config.MinFeeRate,
+		ScriptFlags:          config.ScriptFlags,
+		Include:              config.Include,
+		Exclude:              config.Exclude,
+		OnlyTypes:            config.OnlyTypes,
+		ExcludeTypes:         config.ExcludeTypes,
+		Workers:              config.Workers,
+		Selector:             config.Selector,
+		Solver:               config.Solver,
+		Reject:               config.Reject,
+	}
+	if config.Coins != nil {
+		options.Coins = config.Coins
+	}
+	if options.Solver == nil {
+		cpu := miner.CPUSolver{Workers: config.Workers, Range: config.Nonces, MaxHashes: config.MaxHashes, MaxTime: config.MaxTime}
+		if cpu.Workers == 0 {
+			cpu.Workers = runtime.NumCPU()
+		}
+		options.Solver = cpu
+		if config.CheckpointPath != "" {
+			options.Solver = checkpointSolver{path: config.CheckpointPath, solver: cpu}
+		}
+	}
+	if config.Timestamp != 0 {
+		options.Now = func() time.Time { return time.Unix(int64(config.Timestamp), 0) }
+	}
+	return options
+}
+
+// writeCompactBlock writes the hex-encoded BIP152 compact block of a mined
+// block, keyed with a random nonce, or with one taken from the block hash
+// when deterministic is set
+func writeCompactBlock(path string, minedBlock block.Block, hash [32]byte, deterministic bool) error {
+	nonce := hash[:8]
+	if !deterministic {
+		nonce = make([]byte, 8)
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+	}
+	compact, err := compactblock.New(minedBlock, binary.LittleEndian.Uint64(nonce))
+	if err != nil {
+		return err
+	}
+	data, err := compact.Serialize()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(hex.EncodeToString(data)+"\n"), 0o644)
+}
+
+// sortByTxid orders transactions by txid, so the result does not depend on the
+// order a source returns them in. Transactions without a txid stay in front.
+func sortByTxid(transactions []tx.Transaction) {
+	type keyed struct {
+		txid        string
+		transaction tx.Transaction
+	}
+	sorted := make([]keyed, len(transactions))
+	for i, transaction := range transactions {
+		txid, _ := tx.Txid(transaction)
+		sorted[i] = keyed{txid, transaction}
+	}
+	slices.SortStableFunc(sorted, func(a, b keyed) int { return strings.Compare(a.txid, b.txid) })
+	for i := range sorted {
+		transactions[i] = sorted[i].transaction
+	}
+}
+
+// errInvalidFlags is returned when the command line flags were rejected
+var errInvalidFlags = errors.New("invalid flags")
+
+// runPipeline runs the whole mining pipeline. Errors are logged before being returned.
+func runPipeline(ctx context.Context, config PipelineConfig) (miner.Result, error) {
+	// Load the candidate transactions
+	start := time.Now()
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return miner.Result{}, fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+	logStage("load", start, "transactions", len(transactions))
+	if config.Deterministic {
+		sortByTxid(transactions)
+	}
+	metrics.Update(func(m *Metrics) { m.TransactionsLoaded += len(transactions) })
+	events.Publish(eventTransactionsLoaded, map[string]any{"transactions": len(transactions)})
+
+	if config, err = config.withTip(ctx); err != nil {
+		slog.Error("error reading chain tip", "err", err)
+		return miner.Result{}, err
+	}
+	options := config.minerOptions()
+	if config.Resume {
+		saved, err := readCheckpoint(config.CheckpointPath)
+		if err != nil {
+			slog.Error("error reading checkpoint", "checkpoint", config.CheckpointPath, "err", err)
+			return miner.Result{}, err
+		}
+		if saved == nil {
+			slog.Warn("no checkpoint to resume from, starting over", "checkpoint", config.CheckpointPath)
+		} else if config.Timestamp == 0 {
+			// Reuse the timestamp of the checkpoint, so the same template is rebuilt
+			options.Now = func() time.Time { return time.Unix(int64(saved.Timestamp), 0) }
+		}
+		if solver, ok := options.Solver.(checkpointSolver); ok {
+			solver.resume = saved
+			options.Solver = solver
+		}
+	}
+	options.Reject = func(transaction tx.Transaction, reason error) {
+		if config.Reject != nil {
+			config.Reject(transaction, reason)
+		}
+		if !events.Active() {
+			return
+		}
+		txid, _ := tx.Txid(transaction)
+		events.Publish(eventTransactionRejected, map[string]any{"txid": txid, "reason": reason.Error()})
+	}
+	options.Assembled = func(template miner.Result) {
+		target, _ := block.CompactToTarget(template.Block.Header.Bits)
+		events.Publish(eventSelectionDone, map[string]any{
+			"selected": len(template.Block.Transactions) - 1, "rejected": template.Rejected,
+			"weight": template.Weight, "fees": template.Fees, "duration_seconds": template.SelectionTime.Seconds(),
+			"target": hex.EncodeToString(target[:]),
+		})
+	}
+	hashesBefore := metrics.Hashes
+	var milestones uint64
+	options.Progress = func(p miner.MiningProgress) {
+		metrics.Update(func(m *Metrics) {
+			m.Hashes = hashesBefore + p.Hashes
+			m.HashRate = p.HashRate
+		})
+		if p.Hashes/nonceMilestone > milestones {
+			milestones = p.Hashes / nonceMilestone
+			events.Publish(eventNonceMilestone, map[string]any{"nonce": p.LastNonce, "hashes": p.Hashes, "hash_rate": p.HashRate})
+		}
+		events.Publish(eventMiningProgress, map[string]any{
+			"nonce": p.LastNonce, "hashes": p.Hashes, "hash_rate": p.HashRate, "best_hash": block.HashToString(p.BestHash),
+		})
+		if !config.Quiet {
+			printMiningProgress(p)
+		}
+	}
+
+	result, err := miner.New(options).BuildAndMine(ctx, transactions)
+	if err != nil && result.MiningTime == 0 {
+		// BuildAndMine stopped during selection, before mining started
+		if !logInterrupted("selection", err) {
+			slog.Error("error selecting transactions", "err", err)
+		}
+		return result, err
+	}
+	if !config.Quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+	metrics.Update(func(m *Metrics) {
+		m.TransactionsValid += len(transactions) - result.Rejected
+		m.TransactionsRejected += result.Rejected
+		m.BlockFees = result.Fees
+		m.SelectionDuration = result.SelectionTime
+	})
+	if errors.Is(err, miner.ErrLimitReached) {
+		slog.Warn("search limit reached without a block", "hashes", result.Hashes, "elapsed", result.MiningTime,
+			"best_hash", block.HashToString(result.BestHash), "best_nonce", result.BestNonce)
+		return result, err
+	}
+	if err != nil {
+		if !logInterrupted("mining", err, "last_nonce", result.Block.Header.Nonce, "hashes", result.Hashes, "elapsed", result.MiningTime) {
+			slog.Error("error mining block", "err", err)
+		}
+		return result, err
+	}
+	slog.Info("stage completed", "stage", "selection", "duration", result.SelectionTime,
+		"selected", result.Block.TransactionCount-1, "invalid", result.Rejected, "weight", result.Weight, "fees", result.Fees, "sigops", result.SigOps,
+		"secp256k1", secp256k1.Backend)
+	logWeightBudget(result)
+	slog.Info("stage completed", "stage", "mining", "duration", result.MiningTime,
+		"nonce", result.Block.Header.Nonce, "hashes", result.Hashes, "hash", block.HashToString(result.Hash))
+	metrics.Update(func(m *Metrics) { m.BlocksMined++ })
+	events.Publish(eventBlockFound, map[string]any{
+		"hash": block.HashToString(result.Hash), "nonce": result.Block.Header.Nonce, "hashes": result.Hashes,
+		"mining_seconds": result.MiningTime.Seconds(),
+	})
+
+	if err := writeBlock(ctx, config, result); err != nil {
+		return result, err
+	}
+	if config.Coins != nil {
+		if err := config.Coins.ConnectBlock(result.Block, config.Height); err != nil {
+			slog.Error("error recording the block in the UTXO set", "err", err)
+			return result, err
+		}
+		slog.Info("block connected to UTXO set", "height", config.Height, "unspent", config.Coins.Len())
+	}
+	if config.TxIndex != nil {
+		if err := config.TxIndex.AddBlock(result.Block, result.Hash, config.Height); err != nil {
+			slog.Error("error recording the block in the transaction index", "err", err)
+			return result, err
+		}
+		slog.Info("block added to transaction index", "height", config.Height, "transactions", config.TxIndex.Len())
+	}
+	return result, nil
+}
+
+// writeBlock runs the stages after mining: the output file, then the proofs,
+// compact block and submission configured. Errors are logged before being returned.
+func writeBlock(ctx context.Context, config PipelineConfig, result miner.Result) error {
+	// Write the block data to the output file
+	start := time.Now()
+	if err := block.WriteOutputFile(config.OutputPath, result.Block); err != nil {
+		slog.Error("error writing block to output file", "err", err)
+		return fmt.Errorf("%w: %w", miner.ErrOutputWrite, err)
+	}
+	logStage("write", start, "file", config.OutputPath)
+
+	if config.ProofsDir != "" {
+		start = time.Now()
+		if err := writeProofs(config.ProofsDir, result.Block, result.Hash); err != nil {
+			slog.Error("error writing inclusion proofs", "err", err)
+			return fmt.Errorf("%w: %w", miner.ErrOutputWrite, err)
+		}
+		logStage("proofs", start, "dir", config.ProofsDir, "proofs", len(result.Block.Transactions))
+	}
+	if config.CompactBlockPath != "" {
+		start = time.Now()
+		if err := writeCompactBlock(config.CompactBlockPath, result.Block, result.Hash, config.Deterministic); err != nil {
+			slog.Error("error writing compact block", "err", err)
+			return fmt.Errorf("%w: %w", miner.ErrOutputWrite, err)
+		}
+		logStage("compact-block", start, "file", config.CompactBlockPath)
+	}
+	if config.CSVPath != "" {
+		start = time.Now()
+		if err := writeCSV(config.CSVPath, result.Block); err != nil {
+			slog.Error("error writing CSV export", "err", err)
+			return fmt.Errorf("%w: %w", miner.ErrOutputWrite, err)
+		}
+		logStage("csv", start, "file", config.CSVPath, "rows", len(result.Block.Transactions)-1)
+	}
+	if config.SubmitTo != "" {
+		start = time.Now()
+		params := config.Params
+		if params == nil {
+			params = &chaincfg.MainNetParams
+		}
+		if err := p2p.SubmitBlock(ctx, config.SubmitTo, params, result.Block); err != nil {
+			slog.Error("error submitting block", "peer", config.SubmitTo, "err", err)
+			return err
+		}
+		logStage("submit", start, "peer", config.SubmitTo)
+	}
+	return nil
+}
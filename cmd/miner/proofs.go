@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// inclusionProof is the SPV proof written for each transaction of a mined block.
+// Hashes are in the displayed, byte-reversed hex form.
+type inclusionProof struct {
+	Txid       string   `json:"txid"`
+	BlockHash  string   `json:"block_hash"`
+	MerkleRoot string   `json:"merkle_root"`
+	Position   uint32   `json:"position"`
+	Branch     []string `json:"branch"`
+}
+
+// writeProofs writes one <txid>.json inclusion proof per transaction of a
+// mined block into dir, creating it if needed
+func writeProofs(dir string, minedBlock block.Block, hash [32]byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	txids := make([]merkle.Hash, len(minedBlock.Transactions))
+	for i, transaction := range minedBlock.Transactions {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if txids[i], err = merkle.ParseHash(txid); err != nil {
+			return err
+		}
+	}
+
+	tree := merkle.NewTree(txids)
+	for position, txid := range txids {
+		branch := tree.Proof(uint32(position))
+		proof := inclusionProof{
+			Txid:       txid.String(),
+			BlockHash:  block.HashToString(hash),
+			MerkleRoot: tree.Root().String(),
+			Position:   uint32(position),
+			Branch:     make([]string, len(branch)),
+		}
+		for i, node := range branch {
+			proof.Branch[i] = node.String()
+		}
+		data, err := json.MarshalIndent(proof, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, proof.Txid+".json"), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
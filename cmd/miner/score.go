@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Points of a block passing every check. The README scores the fees
+// collected and the block space used without saying how much each counts;
+// here they count for half each.
+const (
+	feePoints    = 50 // for collecting the reference fees
+	weightPoints = 50 // for filling the weight limit
+)
+
+// scoredBlock is an output file as the grader reads it
+type scoredBlock struct {
+	Header       block.Header
+	Transactions []tx.Transaction // the coinbase, then the mempool transactions of the txids
+	Txids        []string         // every txid listed, the coinbase's first
+}
+
+// parseScoredBlock parses an output file strictly: the hex header, the hex
+// coinbase and the txids with the coinbase's first. The listed transactions
+// are looked up in the mempool, as known.
+func parseScoredBlock(data []byte, known map[string]tx.Transaction) (scoredBlock, error) {
+	var b scoredBlock
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) < 3 {
+		return b, fmt.Errorf("%d lines, want the header, the coinbase and at least its txid", len(lines))
+	}
+	rawHeader, err := hex.DecodeString(lines[0])
+	if err != nil {
+		return b, fmt.Errorf("line 1: %w", err)
+	}
+	if b.Header, err = block.ParseHeader(rawHeader); err != nil {
+		return b, fmt.Errorf("line 1: %w", err)
+	}
+	rawCoinbase, err := hex.DecodeString(lines[1])
+	if err != nil {
+		return b, fmt.Errorf("line 2: %w", err)
+	}
+	coinbase, err := tx.Parse(rawCoinbase)
+	if err != nil {
+		return b, fmt.Errorf("line 2: %w", err)
+	}
+	b.Transactions = append(b.Transactions, coinbase)
+	b.Txids = lines[2:]
+	if coinbaseTxid, _ := tx.Txid(coinbase); b.Txids[0] != coinbaseTxid {
+		return b, fmt.Errorf("line 3: txid %s is not the coinbase's, %s", b.Txids[0], coinbaseTxid)
+	}
+
+	seen := make(map[string]bool, len(b.Txids))
+	for i, txid := range b.Txids[1:] {
+		if seen[txid] {
+			return b, fmt.Errorf("line %d: txid %s listed twice", i+4, txid)
+		}
+		seen[txid] = true
+		transaction, ok := known[txid]
+		if !ok {
+			return b, fmt.Errorf("line %d: txid %s is not in the mempool", i+4, txid)
+		}
+		b.Transactions = append(b.Transactions, transaction)
+	}
+	return b, nil
+}
+
+// scoreCheck is one of the checks a block must pass to score
+type scoreCheck struct {
+	Name string
+	Err  error
+}
+
+// blockWeight returns the weight of a whole block: header, transaction count
+// and transactions
+func blockWeight(transactions []tx.Transaction) (int, error) {
+	weight := 4 * (block.HeaderSize + tx.VarIntSize(uint64(len(transactions))))
+	for i, transaction := range transactions {
+		w, err := tx.Weight(transaction)
+		if err != nil {
+			return 0, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		weight += w
+	}
+	return weight, nil
+}
+
+// checkScoredBlock runs the checks of the grader on a parsed block: its proof
+// of work, merkle root, coinbase, transactions and weight. It returns them
+// with the fees of the transactions and the block weight.
+func checkScoredBlock(ctx context.Context, config PipelineConfig, b scoredBlock, known map[string]tx.Transaction) ([]scoreCheck, int64, int) {
+	params := config.Params
+	var checks []scoreCheck
+	check := func(name string, err error) {
+		checks = append(checks, scoreCheck{name, err})
+	}
+
+	hash := block.HashHeader(block.SerializeHeader(b.Header))
+	var err error
+	if !miner.HashMeetsTarget(hash, params.DefaultTarget) {
+		err = fmt.Errorf("hash %s is not below the target %x", block.HashToString(hash), params.DefaultTarget)
+	}
+	check("target", err)
+
+	txids := make([]merkle.Hash, len(b.Txids))
+	wtxids := make([]merkle.Hash, len(b.Transactions))
+	for i, transaction := range b.Transactions {
+		txids[i], _ = merkle.ParseHash(b.Txids[i])
+		wtxid, _ := tx.Wtxid(transaction)
+		wtxids[i], _ = merkle.ParseHash(wtxid)
+	}
+	err = nil
+	if root := merkle.Root(txids); root != b.Header.MerkleRoot {
+		err = fmt.Errorf("header commits to %s, the txids to %s", block.HashToString(b.Header.MerkleRoot), block.HashToString(root))
+	}
+	check("merkle root", err)
+
+	coinbase := b.Transactions[0]
+	err = nil
+	if len(coinbase.Vin) != 1 || !coinbase.Vin[0].IsCoinbase {
+		err = errors.New("the first transaction is not a coinbase")
+	} else {
+		err = miner.CheckCoinbaseScript(coinbase.Vin[0].ScriptSig)
+	}
+	check("coinbase", err)
+	check("witness commitment", miner.CheckWitnessCommitment(coinbase, wtxids))
+
+	// Each transaction is valid, spends no output another one spends and
+	// comes after any mempool transaction it spends
+	var reasons []string
+	position := make(map[string]int, len(b.Txids))
+	for i, txid := range b.Txids {
+		position[txid] = i
+	}
+	for i, transaction := range b.Transactions[1:] {
+		for _, vin := range transaction.Vin {
+			parent, inBlock := position[vin.Txid]
+			if _, unconfirmed := known[vin.Txid]; unconfirmed && !inBlock {
+				reasons = append(reasons, fmt.Sprintf("tx %s: spends %s, which the block does not include", b.Txids[i+1], vin.Txid))
+			} else if inBlock && parent > i {
+				reasons = append(reasons, fmt.Sprintf("tx %s: spends %s, which comes after it", b.Txids[i+1], vin.Txid))
+			}
+		}
+	}
+	options := miner.Options{
+		Params: params, Height: config.Height, ScriptFlags: config.ScriptFlags, MaxWeight: math.MaxInt32,
+		Reject: func(transaction tx.Transaction, reason error) {
+			reasons = append(reasons, reason.Error())
+		},
+	}
+	template, err := miner.New(options).BuildTemplate(ctx, b.Transactions[1:])
+	if err != nil && !errors.Is(err, miner.ErrNoValidTransactions) {
+		reasons = append(reasons, err.Error())
+	}
+	err = nil
+	if len(reasons) > 0 {
+		err = fmt.Errorf("%d problems, the first: %s", len(reasons), reasons[0])
+	}
+	check("transactions", err)
+
+	weight, err := blockWeight(b.Transactions)
+	if err == nil && weight > params.MaxWeight {
+		err = fmt.Errorf("weight %d exceeds %d", weight, params.MaxWeight)
+	}
+	check("weight", err)
+
+	var claimed int64
+	for _, output := range coinbase.Vout {
+		claimed += output.Value
+	}
+	err = nil
+	if allowed := chaincfg.BlockSubsidy(config.Height, params) + template.Fees; claimed > allowed {
+		err = fmt.Errorf("the coinbase claims %d sats, the subsidy and fees are %d", claimed, allowed)
+	}
+	check("coinbase value", err)
+	return checks, template.Fees, weight
+}
+
+// runScore scores the output file at path as the challenge grader would:
+// zero unless the block passes every check, otherwise points for the fees
+// collected against referenceFees, or the fees of this miner's own template
+// of the mempool if zero, and for the weight limit used. It reports false if
+// the block fails a check.
+func runScore(ctx context.Context, path string, referenceFees int64) bool {
+	config, ok := pipelineConfig()
+	if !ok {
+		return false
+	}
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return false
+	}
+	known := make(map[string]tx.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		if txid, err := tx.Txid(transaction); err == nil {
+			known[txid] = transaction
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("error reading output file", "err", err)
+		return false
+	}
+
+	view := scoreView{File: path, Checks: []scoreCheckView{}, MaxWeight: config.Params.MaxWeight}
+	b, err := parseScoredBlock(data, known)
+	checks := []scoreCheck{{"format", err}}
+	if err == nil {
+		more, fees, weight := checkScoredBlock(ctx, config, b, known)
+		checks = append(checks, more...)
+		view.Fees, view.Weight = fees, weight
+	}
+	passed := true
+	for _, check := range checks {
+		view.Checks = append(view.Checks, scoreCheckView{Name: check.Name, OK: check.Err == nil, Error: errorString(check.Err)})
+		passed = passed && check.Err == nil
+	}
+
+	if passed {
+		if referenceFees == 0 {
+			options := config.minerOptions()
+			options.Reject = nil
+			reference, err := miner.New(options).BuildTemplate(ctx, transactions)
+			if err != nil && !errors.Is(err, miner.ErrNoValidTransactions) {
+				if !logInterrupted("selection", err) {
+					slog.Error("error building the reference template", "err", err)
+				}
+				return false
+			}
+			referenceFees = reference.Fees
+		}
+		view.ReferenceFees = referenceFees
+		view.Score = weightPoints * float64(view.Weight) / float64(view.MaxWeight)
+		if referenceFees > 0 {
+			view.Score += feePoints * min(1, float64(view.Fees)/float64(referenceFees))
+		}
+	}
+	if *jsonOutput {
+		printJSON(view)
+		return passed
+	}
+	printScore(view)
+	return passed
+}
+
+// printScore prints the checks of a scored block and its score
+func printScore(view scoreView) {
+	for _, check := range view.Checks {
+		if check.OK {
+			fmt.Println(stdoutColors.green("ok   " + check.Name))
+		} else {
+			fmt.Println(stdoutColors.red(fmt.Sprintf("FAIL %s: %s", check.Name, check.Error)))
+		}
+	}
+	fmt.Println()
+	fmt.Println("Fees collected:", view.Fees, "sats")
+	if view.ReferenceFees > 0 {
+		fmt.Printf("Reference fees: %d sats (%.2f%%)\n", view.ReferenceFees, 100*float64(view.Fees)/float64(view.ReferenceFees))
+	}
+	fmt.Printf("Block weight: %d of %d (%.2f%%)\n", view.Weight, view.MaxWeight, 100*float64(view.Weight)/float64(view.MaxWeight))
+	fmt.Println(stdoutColors.bold(fmt.Sprintf("Score: %.2f/%d", view.Score, feePoints+weightPoints)))
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// headerView is the JSON form of a block header
+type headerView struct {
+	Version           uint32 `json:"version"`
+	PreviousBlockHash string `json:"previous_block_hash"`
+	MerkleRoot        string `json:"merkle_root"`
+	Timestamp         uint32 `json:"timestamp"`
+	Bits              string `json:"bits"`
+	Nonce             uint32 `json:"nonce"`
+}
+
+// blockView is the JSON form of an assembled or mined block
+type blockView struct {
+	Hash          string           `json:"hash,omitempty"`
+	Header        headerView       `json:"header"`
+	Transactions  int              `json:"transactions"`
+	Fees          int64            `json:"fees"`
+	Weight        int              `json:"weight"`
+	Rejected      int              `json:"rejected"`
+	WeightBudget  weightBudgetView `json:"weight_budget"`
+	Hashes        uint64           `json:"hashes,omitempty"`
+	SelectionTime float64          `json:"selection_seconds"`
+	MiningTime    float64          `json:"mining_seconds,omitempty"`
+	Txids         []string         `json:"txids,omitempty"`
+}
+
+// newBlockView describes a miner result. mined selects whether the hash and
+// proof-of-work statistics are meaningful.
+func newBlockView(result miner.Result, mined bool) blockView {
+	header := result.Block.Header
+	view := blockView{
+		Header: headerView{
+			Version:           header.Version,
+			PreviousBlockHash: merkle.Hash(header.PreviousBlockHash).String(),
+			MerkleRoot:        merkle.Hash(header.MerkleRoot).String(),
+			Timestamp:         header.Timestamp,
+			Bits:              fmt.Sprintf("%08x", header.Bits),
+			Nonce:             header.Nonce,
+		},
+		Transactions:  len(result.Block.Transactions),
+		Fees:          result.Fees,
+		Weight:        result.Weight,
+		Rejected:      result.Rejected,
+		WeightBudget:  newWeightBudgetView(result),
+		SelectionTime: result.SelectionTime.Seconds(),
+	}
+	if mined {
+		view.Hash = block.HashToString(result.Hash)
+		view.Hashes = result.Hashes
+		view.MiningTime = result.MiningTime.Seconds()
+	}
+	for _, transaction := range result.Block.Transactions {
+		if txid, err := tx.Txid(transaction); err == nil {
+			view.Txids = append(view.Txids, txid)
+		}
+	}
+	return view
+}
+
+// apiServer serves block templates and mining results over HTTP
+type apiServer struct {
+	config PipelineConfig
+
+	mu            sync.Mutex // guards template, lastBlock and restartMining
+	template      *miner.Result
+	lastBlock     *miner.Result
+	restartMining context.CancelCauseFunc // cancels the run of POST /mine, nil when none
+
+	mining sync.Mutex // held while POST /mine runs the pipeline
+
+	done <-chan struct{} // closed when the server shuts down, ending event streams
+}
+
+// writeJSON writes value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// buildTemplate loads the mempool and assembles a fresh block template
+func (s *apiServer) buildTemplate(ctx context.Context) (miner.Result, error) {
+	transactions, err := s.config.Source.Transactions(ctx)
+	if err != nil {
+		return miner.Result{}, fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+	config, err := s.config.withTip(ctx)
+	if err != nil {
+		return miner.Result{}, err
+	}
+	// A mempool of only invalid transactions still gets an empty template
+	template, err := miner.New(config.minerOptions()).BuildTemplate(ctx, transactions)
+	if err != nil && !errors.Is(err, miner.ErrNoValidTransactions) {
+		return miner.Result{}, err
+	}
+	s.mu.Lock()
+	s.template = &template
+	s.mu.Unlock()
+	return template, nil
+}
+
+// currentTemplate returns the current block template, rebuilt when none
+// exists yet or when refresh is set
+func (s *apiServer) currentTemplate(ctx context.Context, refresh bool) (miner.Result, error) {
+	s.mu.Lock()
+	template := s.template
+	s.mu.Unlock()
+	if template == nil || refresh {
+		return s.buildTemplate(ctx)
+	}
+	return *template, nil
+}
+
+// handleTemplate serves GET /template: the current block template, rebuilt
+// when none exists yet or when ?refresh=1 is given
+func (s *apiServer) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := s.currentTemplate(r.Context(), r.URL.Query().Get("refresh") == "1")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newBlockView(template, false))
+}
+
+// feeEstimateView is the JSON body of GET /fee-estimate
+type feeEstimateView struct {
+	FeeRate float64 `json:"fee_rate"`
+	Full    bool    `json:"full"`
+	CutOff  string  `json:"cut_off_txid,omitempty"`
+}
+
+// handleFeeEstimate serves GET /fee-estimate: the fee rate needed for
+// inclusion in the current block template, rebuilt as for GET /template
+func (s *apiServer) handleFeeEstimate(w http.ResponseWriter, r *http.Request) {
+	template, err := s.currentTemplate(r.Context(), r.URL.Query().Get("refresh") == "1")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	maxWeight := cmp.Or(s.config.MaxWeight, s.config.Params.MaxWeight)
+	estimate := txpool.EstimateNextBlockFee(template.Block.Transactions, maxWeight)
+	writeJSON(w, http.StatusOK, feeEstimateView{FeeRate: estimate.FeeRate, Full: estimate.Full, CutOff: estimate.CutOff})
+}
+
+// statsView is the JSON body of GET /stats
+type statsView struct {
+	TransactionsLoaded   int        `json:"transactions_loaded"`
+	TransactionsValid    int        `json:"transactions_valid"`
+	TransactionsRejected int        `json:"transactions_rejected"`
+	BlocksMined          int        `json:"blocks_mined"`
+	Hashes               uint64     `json:"hashes"`
+	HashRate             float64    `json:"hash_rate"`
+	LastBlock            *blockView `json:"last_block,omitempty"`
+}
+
+// handleStats serves GET /stats: the process metrics and the last mined block
+func (s *apiServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	var stats statsView
+	metrics.Update(func(m *Metrics) {
+		stats = statsView{
+			TransactionsLoaded:   m.TransactionsLoaded,
+			TransactionsValid:    m.TransactionsValid,
+			TransactionsRejected: m.TransactionsRejected,
+			BlocksMined:          m.BlocksMined,
+			Hashes:               m.Hashes,
+			HashRate:             m.HashRate,
+		}
+	})
+	s.mu.Lock()
+	if s.lastBlock != nil {
+		view := newBlockView(*s.lastBlock, true)
+		view.Txids = nil
+		stats.LastBlock = &view
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleMine serves POST /mine: runs the whole pipeline, including the output
+// file and any proofs or submission configured by flags, and returns the block
+func (s *apiServer) handleMine(w http.ResponseWriter, r *http.Request) {
+	if !s.mining.TryLock() {
+		writeError(w, http.StatusConflict, errors.New("a block is already being mined"))
+		return
+	}
+	defer s.mining.Unlock()
+
+	// A new chain tip cancels the run with errTipChanged, and mining starts
+	// over on top of it
+	var result miner.Result
+	var err error
+	for {
+		ctx, cancel := context.WithCancelCause(r.Context())
+		s.mu.Lock()
+		s.restartMining = cancel
+		s.mu.Unlock()
+		result, err = runPipeline(ctx, s.config)
+		s.mu.Lock()
+		s.restartMining = nil
+		s.mu.Unlock()
+		restart := err != nil && errors.Is(context.Cause(ctx), errTipChanged)
+		cancel(nil)
+		if !restart {
+			break
+		}
+		slog.Info("restarting mining on the new chain tip")
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.mu.Lock()
+	s.lastBlock = &result
+	s.template = &result
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, newBlockView(result, true))
+}
+
+// tipChanged rebuilds the template on top of a new chain tip and restarts
+// a run of POST /mine in progress
+func (s *apiServer) tipChanged(ctx context.Context, tip chain.Tip) {
+	s.mu.Lock()
+	s.template = nil
+	if s.restartMining != nil {
+		s.restartMining(errTipChanged)
+	}
+	s.mu.Unlock()
+	events.Publish(eventTipChanged, map[string]any{"prevhash": block.HashToString(tip.PrevBlockHash), "height": tip.Height})
+	if _, err := s.buildTemplate(ctx); err != nil {
+		slog.Warn("error rebuilding the template on the new chain tip", "err", err)
+	}
+}
+
+// runServe serves the HTTP API on addr until ctx is cancelled
+func runServe(ctx context.Context, addr string) bool {
+	config, ok := pipelineConfig()
+	if !ok {
+		return false
+	}
+	config.Quiet = true // no terminal to draw progress on
+	s := &apiServer{config: config, done: ctx.Done()}
+	if config.Tip != nil && config.TipPoll > 0 {
+		var current [32]byte
+		if tip, err := config.Tip.Tip(ctx); err != nil {
+			slog.Warn("error reading chain tip", "err", err)
+		} else {
+			current = tip.PrevBlockHash
+		}
+		go watchTip(ctx, config, current, func(tip chain.Tip) { s.tipChanged(ctx, tip) })
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /template", s.handleTemplate)
+	mux.HandleFunc("GET /stats", s.handleStats)
+	mux.HandleFunc("GET /fee-estimate", s.handleFeeEstimate)
+	mux.HandleFunc("POST /mine", s.handleMine)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("serving API", "addr", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("API server stopped", "addr", addr, "err", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+)
+
+// errFoundByPeer is the cancellation cause when another instance found the block
+var errFoundByPeer = errors.New("block found by another instance")
+
+// peerTimeout bounds connecting to a peer and exchanging the stop message
+const peerTimeout = 5 * time.Second
+
+// nonceRange returns the nonce range selected by --shard or --nonce-start and
+// --nonce-end, the zero range when the whole nonce space is searched
+func nonceRange() (miner.NonceRange, error) {
+	if *shard != "" {
+		if *nonceStart != 0 || *nonceEnd != 0xFFFFFFFF {
+			return miner.NonceRange{}, errors.New("--shard cannot be combined with --nonce-start or --nonce-end")
+		}
+		indexText, countText, ok := strings.Cut(*shard, "/")
+		index, indexErr := strconv.Atoi(indexText)
+		count, countErr := strconv.Atoi(countText)
+		if !ok || indexErr != nil || countErr != nil {
+			return miner.NonceRange{}, fmt.Errorf("--shard %q is not of the form i/n", *shard)
+		}
+		return miner.ShardRange(index, count)
+	}
+	if *nonceEnd > 0xFFFFFFFF || *nonceStart > *nonceEnd {
+		return miner.NonceRange{}, fmt.Errorf("invalid nonce range %d-%d", *nonceStart, *nonceEnd)
+	}
+	if *nonceStart == 0 && *nonceEnd == 0xFFFFFFFF {
+		return miner.NonceRange{}, nil
+	}
+	return miner.NonceRange{First: uint32(*nonceStart), Last: uint32(*nonceEnd)}, nil
+}
+
+// listenForStop returns a context cancelled with errFoundByPeer as soon as
+// another instance reports a found block on addr
+func listenForStop(ctx context.Context, addr string) (context.Context, context.CancelFunc, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(peerTimeout))
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+			if hash, ok := strings.CutPrefix(strings.TrimSpace(line), "found "); ok {
+				slog.Info("another instance found the block", "peer", conn.RemoteAddr(), "hash", hash)
+				cancel(errFoundByPeer)
+				return
+			}
+		}
+	}()
+	slog.Info("listening for stop messages", "addr", addr)
+	return ctx, func() { cancel(context.Canceled) }, nil
+}
+
+// notifyPeers tells the other instances that a block was found so they stop.
+// Unreachable peers are logged and skipped.
+func notifyPeers(peers string, hash [32]byte) {
+	for _, peer := range strings.Split(peers, ",") {
+		peer = strings.TrimSpace(peer)
+		if peer == "" {
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", peer, peerTimeout)
+		if err != nil {
+			slog.Warn("could not tell peer to stop", "peer", peer, "err", err)
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(peerTimeout))
+		if _, err := fmt.Fprintf(conn, "found %s\n", block.HashToString(hash)); err != nil {
+			slog.Warn("could not tell peer to stop", "peer", peer, "err", err)
+		}
+		conn.Close()
+	}
+}
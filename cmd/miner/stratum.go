@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/stratum"
+)
+
+// runStratum assembles a block template and hands it to external miners as a
+// Stratum job on addr. A new chain tip replaces the job with one built on top
+// of it. The first share meeting the block target is written like a block
+// mined locally, which ends the command.
+func runStratum(ctx context.Context, addr string, shareTargetHex string) bool {
+	config, ok := pipelineConfig()
+	if !ok {
+		return false
+	}
+	var shareTarget [32]byte
+	if shareTargetHex != "" {
+		var err error
+		if shareTarget, err = miner.ParseTarget(shareTargetHex); err != nil {
+			slog.Error("invalid --share-target", "err", err)
+			return false
+		}
+	}
+
+	var rejected rejections
+	if *jsonOutput {
+		config.Reject = rejected.add
+	}
+	config, err := config.withTip(ctx)
+	if err != nil {
+		slog.Error("error reading chain tip", "err", err)
+		return false
+	}
+	template, job, err := stratumJob(ctx, config, 1)
+	if err != nil {
+		return false
+	}
+	if shareTarget == ([32]byte{}) {
+		shareTarget, _ = block.CompactToTarget(template.Block.Header.Bits)
+	}
+
+	serveCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	var start time.Time // when miners were first accepted
+	var mu sync.Mutex   // guards config and template, replaced on a new tip
+	found := make(chan miner.Result, 1)
+	server := stratum.NewServer(stratum.Options{
+		ShareTarget: shareTarget,
+		OnShare: func(worker string, hash [32]byte) {
+			slog.Info("share accepted", "worker", worker, "hash", block.HashToString(hash))
+		},
+		OnBlock: func(solved block.Block, hash [32]byte) {
+			mu.Lock()
+			result := template
+			mu.Unlock()
+			result.Block, result.Hash = solved, hash
+			result.MiningTime = time.Since(start)
+			select {
+			case found <- result:
+				stop()
+			default:
+			}
+		},
+	})
+	server.SetJob(job)
+
+	jobs := 1
+	go watchTip(serveCtx, config, config.PrevBlockHash, func(tip chain.Tip) {
+		mu.Lock()
+		next := config
+		mu.Unlock()
+		next.PrevBlockHash, next.Height, next.MedianTimePast = tip.PrevBlockHash, tip.Height, tip.MedianTimePast
+		jobs++
+		nextTemplate, nextJob, err := stratumJob(serveCtx, next, jobs)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		config, template = next, nextTemplate
+		mu.Unlock()
+		server.SetJob(nextJob)
+		slog.Info("stratum job replaced", "job", nextJob.ID, "height", tip.Height)
+	})
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		slog.Error("error listening for stratum miners", "addr", addr, "err", err)
+		return false
+	}
+	slog.Info("serving stratum jobs", "addr", addr, "difficulty", stratum.Difficulty(shareTarget))
+	start = time.Now()
+	if err := server.Serve(serveCtx, listener); !errors.Is(err, context.Canceled) {
+		slog.Error("stratum server stopped", "addr", addr, "err", err)
+		return false
+	}
+
+	select {
+	case result := <-found:
+		slog.Info("stage completed", "stage", "mining", "duration", result.MiningTime,
+			"nonce", result.Block.Header.Nonce, "hash", block.HashToString(result.Hash))
+		metrics.Update(func(m *Metrics) { m.BlocksMined++ })
+		mu.Lock()
+		defer mu.Unlock()
+		err := writeBlock(ctx, config, result)
+		if *jsonOutput {
+			printJSON(newMineView([]miner.Result{result}, &rejected, err))
+		}
+		return err == nil
+	default:
+		// The server only stops without a block when the command is interrupted
+		if err := ctx.Err(); !logInterrupted("mining", err, "elapsed", time.Since(start)) {
+			slog.Error("stratum server stopped without a block", "addr", addr, "err", err)
+			return false
+		}
+		if *jsonOutput {
+			printJSON(newMineView(nil, &rejected, nil))
+		}
+		return true
+	}
+}
+
+// stratumJob loads the mempool and assembles the template of config as the
+// Stratum job numbered id. Errors are logged before being returned.
+func stratumJob(ctx context.Context, config PipelineConfig, id int) (miner.Result, *stratum.Job, error) {
+	start := time.Now()
+	transactions, err := config.Source.Transactions(ctx)
+	if err != nil {
+		if !logInterrupted("load", err, "transactions", len(transactions)) {
+			slog.Error("error loading transactions", "err", err)
+		}
+		return miner.Result{}, nil, fmt.Errorf("%w: %w", miner.ErrMempoolLoad, err)
+	}
+	logStage("load", start, "transactions", len(transactions))
+	// Miners are kept busy on an empty block if no transaction is valid
+	template, err := miner.New(config.minerOptions()).BuildTemplate(ctx, transactions)
+	if errors.Is(err, miner.ErrNoValidTransactions) {
+		slog.Warn("mining an empty block", "err", err)
+	} else if err != nil {
+		if !logInterrupted("selection", err) {
+			slog.Error("error selecting transactions", "err", err)
+		}
+		return miner.Result{}, nil, err
+	}
+	slog.Info("stage completed", "stage", "selection", "duration", template.SelectionTime,
+		"selected", template.Block.TransactionCount-1, "invalid", template.Rejected, "weight", template.Weight, "fees", template.Fees)
+	job, err := stratum.NewJob(strconv.Itoa(id), template.Block)
+	if err != nil {
+		slog.Error("error creating stratum job", "err", err)
+		return miner.Result{}, nil, err
+	}
+	return template, job, nil
+}
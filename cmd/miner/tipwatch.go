@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+)
+
+// errTipChanged is the cause of cancelling mining on a chain tip that is no
+// longer the best one
+var errTipChanged = errors.New("chain tip changed")
+
+// watchTip polls config.Tip every config.TipPoll until ctx is done and calls
+// onChange with each tip whose previous block hash differs from current. A
+// tip source is a node whose mempool is also the transaction source, so a
+// template rebuilt from there no longer has the transactions the new tip
+// confirmed. It returns at once if there is no tip source to poll.
+func watchTip(ctx context.Context, config PipelineConfig, current [32]byte, onChange func(chain.Tip)) {
+	if config.Tip == nil || config.TipPoll <= 0 {
+		return
+	}
+	onError := func(err error) { slog.Warn("error polling chain tip", "err", err) }
+	for tip := range chain.WatchTip(ctx, config.Tip, current, config.TipPoll, onError) {
+		slog.Info("chain tip changed", "prevhash", block.HashToString(tip.PrevBlockHash), "height", tip.Height)
+		onChange(tip)
+	}
+}
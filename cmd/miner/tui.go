@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// dashboardWidth is the width of the dashboard in columns, unless
+	// COLUMNS says otherwise
+	dashboardWidth = 80
+	// dashboardRefresh is how often the dashboard is redrawn
+	dashboardRefresh = 500 * time.Millisecond
+	// logTailLines is the number of most recent log lines the dashboard shows
+	logTailLines = 8
+	// hashRateSamples is the number of hash rate samples in the graph
+	hashRateSamples = 60
+)
+
+// ANSI escape sequences drawing the dashboard in place
+const (
+	ansiHome        = "\x1b[H"
+	ansiClearLine   = "\x1b[K"
+	ansiClearBelow  = "\x1b[J"
+	ansiClearScreen = "\x1b[2J"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+)
+
+// graphLevels are the bars of the hash rate graph, lowest first
+var graphLevels = []rune("▁▂▃▄▅▆▇█")
+
+// logTail keeps the last lines written to it, the log panel of the
+// dashboard. While no dashboard shows them, the lines are passed on to
+// stderr. It is safe for concurrent use.
+type logTail struct {
+	mu    sync.Mutex
+	lines []string
+	shown bool // whether a dashboard is drawing the lines
+}
+
+// Write records the lines of p, the handler writing one record per call
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.shown {
+		return os.Stderr.Write(p)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		t.lines = append(t.lines, line)
+	}
+	if len(t.lines) > logTailLines {
+		t.lines = append([]string{}, t.lines[len(t.lines)-logTailLines:]...)
+	}
+	return len(p), nil
+}
+
+// show starts or stops recording the lines for a dashboard
+func (t *logTail) show(shown bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shown = shown
+}
+
+// tail returns the recorded lines, oldest first
+func (t *logTail) tail() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.lines...)
+}
+
+// tuiLog receives the log records of a --tui run
+var tuiLog = &logTail{}
+
+// dashboard is the state drawn by --tui, updated from the pipeline events
+type dashboard struct {
+	mu         sync.Mutex
+	start      time.Time
+	stage      string
+	loaded     int
+	rejected   int
+	selected   int
+	weight     int
+	fees       int64
+	selection  time.Duration
+	target     string
+	hashes     uint64
+	hashRate   float64
+	nonce      uint32
+	bestHash   string
+	hashRates  []float64
+	blocks     int
+	lastBlock  string
+	maxWeight  int
+	tipChanges int
+}
+
+// startDashboard draws the pipeline events published while ctx is live on
+// stdout until the returned function is called, which draws the final state
+// and gives the terminal back
+func startDashboard(ctx context.Context, maxWeight int) func() {
+	d := &dashboard{start: time.Now(), stage: "loading", maxWeight: maxWeight}
+	subscription, unsubscribe := events.Subscribe()
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(dashboardRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case message := <-subscription:
+				d.apply(message)
+			case <-ticker.C:
+				d.draw()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	tuiLog.show(true)
+	fmt.Print(ansiHideCursor + ansiClearScreen)
+	d.draw()
+	return func() {
+		cancel()
+		wg.Wait()
+		unsubscribe()
+		for drained := false; !drained; {
+			select {
+			case message := <-subscription:
+				d.apply(message)
+			default:
+				drained = true
+			}
+		}
+		d.draw()
+		fmt.Print(ansiShowCursor)
+		tuiLog.show(false)
+	}
+}
+
+// apply updates the dashboard with one encoded event
+func (d *dashboard) apply(message []byte) {
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Transactions int     `json:"transactions"`
+			Selected     int     `json:"selected"`
+			Rejected     int     `json:"rejected"`
+			Weight       int     `json:"weight"`
+			Fees         int64   `json:"fees"`
+			Duration     float64 `json:"duration_seconds"`
+			Target       string  `json:"target"`
+			Nonce        uint32  `json:"nonce"`
+			Hashes       uint64  `json:"hashes"`
+			HashRate     float64 `json:"hash_rate"`
+			BestHash     string  `json:"best_hash"`
+			Hash         string  `json:"hash"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return
+	}
+	data := event.Data
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch event.Type {
+	case eventTransactionsLoaded:
+		d.stage, d.loaded, d.rejected = "selecting", data.Transactions, 0
+	case eventTransactionRejected:
+		d.rejected++
+	case eventSelectionDone:
+		d.stage, d.selected, d.rejected = "mining", data.Selected, data.Rejected
+		d.weight, d.fees, d.target = data.Weight, data.Fees, data.Target
+		d.selection = time.Duration(data.Duration * float64(time.Second))
+		d.hashes, d.hashRate, d.bestHash = 0, 0, ""
+	case eventMiningProgress:
+		d.hashes, d.hashRate, d.nonce, d.bestHash = data.Hashes, data.HashRate, data.Nonce, data.BestHash
+		d.hashRates = append(d.hashRates, data.HashRate)
+		if len(d.hashRates) > hashRateSamples {
+			d.hashRates = d.hashRates[len(d.hashRates)-hashRateSamples:]
+		}
+	case eventBlockFound:
+		d.stage, d.lastBlock = "found", data.Hash
+		d.blocks++
+	case eventTipChanged:
+		d.tipChanges++
+	}
+}
+
+// draw redraws the whole dashboard from the top left corner of the terminal
+func (d *dashboard) draw() {
+	width := dashboardWidth
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+	}
+	var screen bytes.Buffer
+	screen.WriteString(ansiHome)
+	line := func(text string) {
+		screen.WriteString(truncate(text, width) + ansiClearLine + "\n")
+	}
+	label := func(name string) string { return stdoutColors.bold(fmt.Sprintf("%-11s", name)) }
+
+	d.mu.Lock()
+	line(stdoutColors.bold(fmt.Sprintf("miner  stage %s  blocks %d  elapsed %s", d.stage, d.blocks, time.Since(d.start).Round(time.Second))))
+	line("")
+	line(label("Mempool") + fmt.Sprintf("loaded %d  tip changes %d", d.loaded, d.tipChanges))
+	selection := fmt.Sprintf("selected %d  ", d.selected) + stdoutColors.red(fmt.Sprintf("rejected %d", d.rejected))
+	if d.selection > 0 {
+		selection += fmt.Sprintf("  in %s", d.selection.Round(time.Millisecond))
+	}
+	line(label("Selection") + selection)
+	fill := ""
+	if d.maxWeight > 0 {
+		fill = fmt.Sprintf(" (%.1f%%)", 100*float64(d.weight)/float64(d.maxWeight))
+	}
+	line(label("Block") + fmt.Sprintf("weight %d%s  fees %d sats", d.weight, fill, d.fees))
+	line("")
+	line(label("Mining") + fmt.Sprintf("hashes %d  %s  nonce %d", d.hashes, formatHashRate(d.hashRate), d.nonce))
+	line(label("Best hash") + d.bestHash)
+	line(label("Target") + d.target)
+	if zeros, ok := leadingZeroBits(d.bestHash); ok && d.target != "" {
+		needed, _ := leadingZeroBits(d.target)
+		line(label("") + fmt.Sprintf("best hash has %d leading zero bits, target %d", zeros, needed))
+	} else {
+		line("")
+	}
+	line(label("Hash rate") + stdoutColors.green(hashRateGraph(d.hashRates)))
+	if d.lastBlock != "" {
+		line(label("Found") + stdoutColors.green(d.lastBlock))
+	} else {
+		line("")
+	}
+	d.mu.Unlock()
+
+	line("")
+	line(label("Log"))
+	for _, logLine := range tuiLog.tail() {
+		line(logLine)
+	}
+	screen.WriteString(ansiClearBelow)
+	os.Stdout.Write(screen.Bytes())
+}
+
+// truncate cuts text to at most width visible runes, ANSI escapes aside
+func truncate(text string, width int) string {
+	var truncated strings.Builder
+	visible := 0
+	for i := 0; i < len(text); {
+		if text[i] == '\x1b' {
+			end := strings.IndexByte(text[i:], 'm')
+			if end < 0 {
+				break
+			}
+			truncated.WriteString(text[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if visible < width {
+			truncated.WriteRune(r)
+		}
+		visible++
+		i += size
+	}
+	return truncated.String()
+}
+
+// formatHashRate formats a hash rate with a metric prefix
+func formatHashRate(rate float64) string {
+	for _, unit := range []string{"H/s", "kH/s", "MH/s", "GH/s"} {
+		if rate < 1000 {
+			return fmt.Sprintf("%.1f %s", rate, unit)
+		}
+		rate /= 1000
+	}
+	return fmt.Sprintf("%.1f TH/s", rate)
+}
+
+// hashRateGraph draws samples as bars scaled to the highest one
+func hashRateGraph(samples []float64) string {
+	peak := 0.0
+	for _, sample := range samples {
+		peak = max(peak, sample)
+	}
+	var graph strings.Builder
+	for _, sample := range samples {
+		level := 0
+		if peak > 0 {
+			level = int(sample / peak * float64(len(graphLevels)-1))
+		}
+		graph.WriteRune(graphLevels[level])
+	}
+	if peak > 0 {
+		graph.WriteString("  peak " + formatHashRate(peak))
+	}
+	return graph.String()
+}
+
+// leadingZeroBits counts the leading zero bits of a big-endian hex number,
+// reporting false if it is not one
+func leadingZeroBits(hexNumber string) (int, bool) {
+	if hexNumber == "" {
+		return 0, false
+	}
+	zeros := 0
+	for _, digit := range hexNumber {
+		value, err := strconv.ParseUint(string(digit), 16, 8)
+		if err != nil {
+			return 0, false
+		}
+		if value != 0 {
+			return zeros + bits.LeadingZeros8(uint8(value)) - 4, true
+		}
+		zeros += 4
+	}
+	return zeros, true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the client's key to compute Sec-WebSocket-Accept (RFC 6455 section 4.2.2)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// maxClientFrame bounds the payload of frames read from a client, which only
+// sends control frames to the event stream
+const maxClientFrame = 1 << 16
+
+// websocketConn is the server side of an upgraded WebSocket connection.
+// Writes may come from several goroutines.
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	mu sync.Mutex // serialises frame writes
+}
+
+// headerContains reports whether a comma-separated request header contains token, ignoring case
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket performs the opening handshake and takes over the
+// connection. On failure an HTTP error has already been written.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*websocketConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket upgrade request", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be upgraded", http.StatusInternalServerError)
+		return nil, errors.New("response writer does not support hijacking")
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocketConn{conn: conn, reader: buffered.Reader}, nil
+}
+
+// WriteFrame writes a single unmasked, unfragmented frame
+func (c *websocketConn) WriteFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrame reads one frame sent by the client, unmasking its payload.
+// Fragmented messages are returned frame by frame.
+func (c *websocketConn) ReadFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0f
+	if header[1]&0x80 == 0 {
+		return 0, nil, errors.New("client frame is not masked")
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxClientFrame {
+		return 0, nil, fmt.Errorf("client frame of %d bytes exceeds %d", length, maxClientFrame)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// Close sends a normal closure frame and closes the connection
+func (c *websocketConn) Close() error {
+	c.WriteFrame(opClose, []byte{0x03, 0xe8}) // status 1000
+	return c.conn.Close()
+}
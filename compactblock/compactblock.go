@@ -0,0 +1,92 @@
+// Package compactblock encodes blocks in the BIP152 compact block format
+// (version 2, keyed by wtxid): the header, a nonce, 6-byte short transaction
+// ids and the prefilled coinbase.
+package compactblock
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// ShortIDSize is the size of a serialized short transaction id in bytes
+const ShortIDSize = 6
+
+// PrefilledTx is a transaction sent in full, at its index in the block
+type PrefilledTx struct {
+	Index uint32
+	Tx    tx.Transaction
+}
+
+// CompactBlock is the cmpctblock message body of a block
+type CompactBlock struct {
+	Header    block.Header
+	Nonce     uint64
+	ShortIDs  []uint64 // 48-bit short ids of the transactions not prefilled
+	Prefilled []PrefilledTx
+}
+
+// ShortIDKeys derives the SipHash keys from the header and nonce: the first
+// two little-endian words of SHA256(header || nonce)
+func ShortIDKeys(header block.Header, nonce uint64) (k0, k1 uint64) {
+	data := binary.LittleEndian.AppendUint64(block.SerializeHeader(header), nonce)
+	hash := sha256.Sum256(data)
+	return binary.LittleEndian.Uint64(hash[0:]), binary.LittleEndian.Uint64(hash[8:])
+}
+
+// ShortID computes the short id of a transaction from its wtxid, in internal
+// byte order
+func ShortID(k0, k1 uint64, wtxid [32]byte) uint64 {
+	return SipHash24(k0, k1, wtxid[:]) & 0xffffffffffff
+}
+
+// New builds the compact block of b, prefilling the coinbase. The nonce should
+// be random per peer so short id collisions cannot be forced.
+func New(b block.Block, nonce uint64) (CompactBlock, error) {
+	compact := CompactBlock{Header: b.Header, Nonce: nonce}
+	if len(b.Transactions) == 0 {
+		return compact, fmt.Errorf("block has no coinbase transaction")
+	}
+	compact.Prefilled = []PrefilledTx{{Index: 0, Tx: b.Transactions[0]}}
+	k0, k1 := ShortIDKeys(b.Header, nonce)
+	for i, transaction := range b.Transactions[1:] {
+		serializedTx, err := tx.Serialize(transaction, true)
+		if err != nil {
+			return compact, fmt.Errorf("transaction %d: %w", i+1, err)
+		}
+		compact.ShortIDs = append(compact.ShortIDs, ShortID(k0, k1, hashutil.Hash256(serializedTx)))
+	}
+	return compact, nil
+}
+
+// Serialize encodes the compact block. Prefilled indexes are written as the
+// difference to the previous prefilled index plus one, as BIP152 requires.
+func (c CompactBlock) Serialize() ([]byte, error) {
+	data := block.SerializeHeader(c.Header)
+	data = binary.LittleEndian.AppendUint64(data, c.Nonce)
+	data = tx.AppendVarInt(data, uint64(len(c.ShortIDs)))
+	for _, id := range c.ShortIDs {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], id)
+		data = append(data, buf[:ShortIDSize]...)
+	}
+	data = tx.AppendVarInt(data, uint64(len(c.Prefilled)))
+	next := uint32(0)
+	for _, prefilled := range c.Prefilled {
+		if prefilled.Index < next {
+			return nil, fmt.Errorf("prefilled transaction index %d out of order", prefilled.Index)
+		}
+		data = tx.AppendVarInt(data, uint64(prefilled.Index-next))
+		next = prefilled.Index + 1
+		serializedTx, err := tx.Serialize(prefilled.Tx, true)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, serializedTx...)
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package compactblock
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+func TestSerialize(t *testing.T) {
+	header := block.Header{Version: 0x20000000, Timestamp: 1700000000, Bits: 0x1f00ffff, Nonce: 7}
+	coinbase := block.CreateCoinbaseTransaction()
+	serializedCoinbase, err := tx.Serialize(coinbase, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := hex.EncodeToString(block.SerializeHeader(header)) + "0807060504030201"
+	coinbaseHex := hex.EncodeToString(serializedCoinbase)
+
+	tests := []struct {
+		name      string
+		shortIDs  []uint64
+		prefilled []uint32
+		want      string // after the header and nonce
+	}{
+		{"nothing", nil, nil, "00" + "00"},
+		// Short ids are their low 6 bytes, little-endian
+		{"short ids", []uint64{0xaabbccddeeff, 0xffff000000000001}, nil, "02" + "ffeeddccbbaa" + "010000000000" + "00"},
+		// Each index is written as the difference to the previous one plus one
+		{"coinbase", nil, []uint32{0}, "00" + "01" + "00" + coinbaseHex},
+		{"differential indexes", []uint64{1}, []uint32{0, 2, 3, 300}, "01" + "010000000000" + "04" +
+			"00" + coinbaseHex + "01" + coinbaseHex + "00" + coinbaseHex + "fd2801" + coinbaseHex},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compact := CompactBlock{Header: header, Nonce: 0x0102030405060708, ShortIDs: test.shortIDs}
+			for _, index := range test.prefilled {
+				compact.Prefilled = append(compact.Prefilled, PrefilledTx{Index: index, Tx: coinbase})
+			}
+			data, err := compact.Serialize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hex.EncodeToString(data), prefix+test.want; got != want {
+				t.Errorf("serialized\n%s, want\n%s", strings.TrimPrefix(got, prefix), test.want)
+			}
+		})
+	}
+
+	for _, indexes := range [][]uint32{{1, 1}, {2, 1}} {
+		compact := CompactBlock{Header: header, Prefilled: []PrefilledTx{{Index: indexes[0], Tx: coinbase}, {Index: indexes[1], Tx: coinbase}}}
+		if _, err := compact.Serialize(); err == nil {
+			t.Errorf("prefilled indexes %v serialized", indexes)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	coinbase := block.CreateCoinbaseTransaction()
+	spend := tx.Transaction{
+		Version: 2,
+		Vin:     []tx.TxInput{{Txid: strings.Repeat("11", 32), Witness: []tx.HexBytes{{0x01}}}},
+		Vout:    []tx.TxOutput{{ScriptPubKey: []byte{0x51}, Value: 1000}},
+	}
+	b := block.Block{Header: block.Header{Version: 0x20000000, Timestamp: 1700000000}, Transactions: []tx.Transaction{coinbase, spend}}
+	compact, err := New(b, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compact.Prefilled) != 1 || compact.Prefilled[0].Index != 0 {
+		t.Fatalf("prefilled %+v, want the coinbase alone", compact.Prefilled)
+	}
+	// The short id is keyed by the wtxid, not the txid
+	serialized, err := tx.Serialize(spend, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k0, k1 := ShortIDKeys(b.Header, 42)
+	want := ShortID(k0, k1, hashutil.Hash256(serialized))
+	if len(compact.ShortIDs) != 1 || compact.ShortIDs[0] != want || want>>48 != 0 {
+		t.Errorf("short ids %x, want [%x]", compact.ShortIDs, want)
+	}
+	stripped, err := tx.Serialize(spend, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(stripped, serialized) || ShortID(k0, k1, hashutil.Hash256(stripped)) == want {
+		t.Error("the witness does not change the short id")
+	}
+
+	if _, err := New(block.Block{}, 42); err == nil {
+		t.Error("compact block of a block without a coinbase built")
+	}
+}
@@ -0,0 +1,25 @@
+package compactblock
+
+import "testing"
+
+func TestSipHash24(t *testing.T) {
+	// Vectors of the SipHash paper: key 00..0f, message 00..(n-1)
+	k0, k1 := uint64(0x0706050403020100), uint64(0x0f0e0d0c0b0a0908)
+	tests := []struct {
+		length int
+		want   uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+	}
+	for _, test := range tests {
+		message := make([]byte, test.length)
+		for i := range message {
+			message[i] = byte(i)
+		}
+		if got := SipHash24(k0, k1, message); got != test.want {
+			t.Errorf("%d-byte message: got %#016x, want %#016x", test.length, got, test.want)
+		}
+	}
+}
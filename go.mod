@@ -0,0 +1,3 @@
+module github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133
+
+go 1.22
@@ -0,0 +1,85 @@
+// Package hashutil provides the composite hashes used throughout Bitcoin:
+// HASH256 (double SHA256) for txids, block hashes and checksums, and HASH160
+// (RIPEMD160 of SHA256) for public key and script hashes.
+package hashutil
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"hash"
+)
+
+// Hash256 computes SHA256(SHA256(data))
+func Hash256(data []byte) [32]byte {
+	first := sha256.Sum256(data)
+	return sha256.Sum256(first[:])
+}
+
+// Midstate computes Hash256 of messages sharing a prefix, hashing the prefix
+// only once. The nonce search uses it on the first 64 bytes of the block
+// header, saving one of the three SHA256 compressions of every attempt.
+// crypto/sha256 itself picks SHA-NI, AVX2 or ARMv8 instructions at run time
+// and falls back to portable code, so no assembly of our own is needed.
+type Midstate struct {
+	h     hash.Hash
+	state []byte
+	sum   [32]byte
+}
+
+// NewMidstate hashes prefix, whose length must be a multiple of the 64-byte
+// SHA256 block so that no part of it is left buffered
+func NewMidstate(prefix []byte) *Midstate {
+	if len(prefix)%sha256.BlockSize != 0 {
+		panic("hashutil: midstate prefix is not a whole number of blocks")
+	}
+	h := sha256.New()
+	h.Write(prefix)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	return &Midstate{h: h, state: state}
+}
+
+// Hash256 computes Hash256 of the prefix followed by suffix. A Midstate is
+// not safe for concurrent use.
+func (m *Midstate) Hash256(suffix []byte) [32]byte {
+	m.h.(encoding.BinaryUnmarshaler).UnmarshalBinary(m.state)
+	m.h.Write(suffix)
+	m.h.Sum(m.sum[:0])
+	return sha256.Sum256(m.sum[:])
+}
+
+// Hash160 computes RIPEMD160(SHA256(data))
+func Hash160(data []byte) [20]byte {
+	first := sha256.Sum256(data)
+	h := NewRIPEMD160()
+	h.Write(first[:])
+	var sum [20]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// composite applies outer to the digest of inner when summed
+type composite struct {
+	inner hash.Hash
+	outer func() hash.Hash
+}
+
+func (c composite) Write(p []byte) (int, error) { return c.inner.Write(p) }
+func (c composite) Reset()                      { c.inner.Reset() }
+func (c composite) BlockSize() int              { return c.inner.BlockSize() }
+func (c composite) Size() int                   { return c.outer().Size() }
+
+func (c composite) Sum(in []byte) []byte {
+	outer := c.outer()
+	outer.Write(c.inner.Sum(nil))
+	return outer.Sum(in)
+}
+
+// NewHash256 returns a streaming hash.Hash computing Hash256
+func NewHash256() hash.Hash {
+	return composite{inner: sha256.New(), outer: sha256.New}
+}
+
+// NewHash160 returns a streaming hash.Hash computing Hash160
+func NewHash160() hash.Hash {
+	return composite{inner: sha256.New(), outer: NewRIPEMD160}
+}
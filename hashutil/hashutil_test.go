@@ -0,0 +1,36 @@
+package hashutil
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestHash160 checks the hash of the genesis coinbase public key, at once and
+// streamed in two writes
+func TestHash160(t *testing.T) {
+	const want = "62e907b15cbf27d5425399ebf6f0fb50ebb88f18"
+	pubKey, _ := hex.DecodeString("04678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5f")
+	if hash := Hash160(pubKey); hex.EncodeToString(hash[:]) != want {
+		t.Errorf("Hash160: got %x, want %s", hash, want)
+	}
+	h := NewHash160()
+	h.Write(pubKey[:10])
+	h.Write(pubKey[10:])
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("NewHash160: got %s, want %s", got, want)
+	}
+}
+
+// TestHash256 checks the streaming double SHA256 against hashing at once
+func TestHash256(t *testing.T) {
+	const want = "9595c9df90075148eb06860365df33584b75bff782a510c6cd4883a419833d50"
+	if hash := Hash256([]byte("hello")); hex.EncodeToString(hash[:]) != want {
+		t.Errorf("Hash256: got %x, want %s", hash, want)
+	}
+	h := NewHash256()
+	h.Write([]byte("hel"))
+	h.Write([]byte("lo"))
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("NewHash256: got %s, want %s", got, want)
+	}
+}
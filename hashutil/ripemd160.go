@@ -0,0 +1,150 @@
+package hashutil
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// RIPEMD160Size is the size of a RIPEMD-160 digest in bytes
+const RIPEMD160Size = 20
+
+const ripemd160BlockSize = 64
+
+// ripemd160 is a streaming RIPEMD-160 digest, which the standard library lacks
+type ripemd160 struct {
+	s   [5]uint32
+	x   [ripemd160BlockSize]byte
+	nx  int
+	len uint64
+}
+
+// NewRIPEMD160 returns a hash.Hash computing RIPEMD-160
+func NewRIPEMD160() hash.Hash {
+	d := new(ripemd160)
+	d.Reset()
+	return d
+}
+
+func (d *ripemd160) Reset() {
+	d.s = [5]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *ripemd160) Size() int      { return RIPEMD160Size }
+func (d *ripemd160) BlockSize() int { return ripemd160BlockSize }
+
+func (d *ripemd160) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		copied := copy(d.x[d.nx:], p)
+		d.nx += copied
+		p = p[copied:]
+		if d.nx < ripemd160BlockSize {
+			return n, nil
+		}
+		d.block(d.x[:])
+		d.nx = 0
+	}
+	for len(p) >= ripemd160BlockSize {
+		d.block(p[:ripemd160BlockSize])
+		p = p[ripemd160BlockSize:]
+	}
+	d.nx = copy(d.x[:], p)
+	return n, nil
+}
+
+func (d *ripemd160) Sum(in []byte) []byte {
+	// Pad a copy so the caller can keep writing
+	c := *d
+	length := c.len
+	var padding [ripemd160BlockSize + 8]byte
+	padding[0] = 0x80
+	padLength := 56 - int(length%64)
+	if padLength <= 0 {
+		padLength += 64
+	}
+	binary.LittleEndian.PutUint64(padding[padLength:], length<<3)
+	c.Write(padding[:padLength+8])
+
+	var digest [RIPEMD160Size]byte
+	for i, s := range c.s {
+		binary.LittleEndian.PutUint32(digest[i*4:], s)
+	}
+	return append(in, digest[:]...)
+}
+
+// Message word selection, rotation amounts and constants of the left and
+// right lines of the compression function
+var (
+	ripemdRL = [80]uint8{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		7, 4, 13, 1, 10, 6, 15, 3, 12, 0, 9, 5, 2, 14, 11, 8,
+		3, 10, 14, 4, 9, 15, 8, 1, 2, 7, 0, 6, 13, 11, 5, 12,
+		1, 9, 11, 10, 0, 8, 12, 4, 13, 3, 7, 15, 14, 5, 6, 2,
+		4, 0, 5, 9, 7, 12, 2, 10, 14, 1, 3, 8, 11, 6, 15, 13,
+	}
+	ripemdRR = [80]uint8{
+		5, 14, 7, 0, 9, 2, 11, 4, 13, 6, 15, 8, 1, 10, 3, 12,
+		6, 11, 3, 7, 0, 13, 5, 10, 14, 15, 8, 12, 4, 9, 1, 2,
+		15, 5, 1, 3, 7, 14, 6, 9, 11, 8, 12, 2, 10, 0, 4, 13,
+		8, 6, 4, 1, 3, 11, 15, 0, 5, 12, 2, 13, 9, 7, 10, 14,
+		12, 15, 10, 4, 1, 5, 8, 7, 6, 2, 13, 14, 0, 3, 9, 11,
+	}
+	ripemdSL = [80]uint8{
+		11, 14, 15, 12, 5, 8, 7, 9, 11, 13, 14, 15, 6, 7, 9, 8,
+		7, 6, 8, 13, 11, 9, 7, 15, 7, 12, 15, 9, 11, 7, 13, 12,
+		11, 13, 6, 7, 14, 9, 13, 15, 14, 8, 13, 6, 5, 12, 7, 5,
+		11, 12, 14, 15, 14, 15, 9, 8, 9, 14, 5, 6, 8, 6, 5, 12,
+		9, 15, 5, 11, 6, 8, 13, 12, 5, 12, 13, 14, 11, 8, 5, 6,
+	}
+	ripemdSR = [80]uint8{
+		8, 9, 9, 11, 13, 15, 15, 5, 7, 7, 8, 11, 14, 14, 12, 6,
+		9, 13, 15, 7, 12, 8, 9, 11, 7, 7, 12, 7, 6, 15, 13, 11,
+		9, 7, 15, 11, 8, 6, 6, 14, 12, 13, 5, 14, 13, 13, 7, 5,
+		15, 5, 8, 11, 14, 14, 6, 14, 6, 9, 12, 9, 12, 5, 15, 8,
+		8, 5, 12, 9, 12, 5, 14, 6, 8, 13, 6, 5, 15, 13, 11, 11,
+	}
+	ripemdKL = [5]uint32{0x00000000, 0x5a827999, 0x6ed9eba1, 0x8f1bbcdc, 0xa953fd4e}
+	ripemdKR = [5]uint32{0x50a28be6, 0x5c4dd124, 0x6d703ef3, 0x7a6d76e9, 0x00000000}
+)
+
+// ripemdF is the boolean function of round j (0 to 4)
+func ripemdF(j int, x, y, z uint32) uint32 {
+	switch j {
+	case 0:
+		return x ^ y ^ z
+	case 1:
+		return (x & y) | (^x & z)
+	case 2:
+		return (x | ^y) ^ z
+	case 3:
+		return (x & z) | (y & ^z)
+	}
+	return x ^ (y | ^z)
+}
+
+// block runs the compression function over one 64-byte block
+func (d *ripemd160) block(p []byte) {
+	var x [16]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(p[i*4:])
+	}
+	al, bl, cl, dl, el := d.s[0], d.s[1], d.s[2], d.s[3], d.s[4]
+	ar, br, cr, dr, er := al, bl, cl, dl, el
+	for i := 0; i < 80; i++ {
+		round := i / 16
+		t := bits.RotateLeft32(al+ripemdF(round, bl, cl, dl)+x[ripemdRL[i]]+ripemdKL[round], int(ripemdSL[i])) + el
+		al, el, dl, cl, bl = el, dl, bits.RotateLeft32(cl, 10), bl, t
+		t = bits.RotateLeft32(ar+ripemdF(4-round, br, cr, dr)+x[ripemdRR[i]]+ripemdKR[round], int(ripemdSR[i])) + er
+		ar, er, dr, cr, br = er, dr, bits.RotateLeft32(cr, 10), br, t
+	}
+	t := d.s[1] + cl + dr
+	d.s[1] = d.s[2] + dl + er
+	d.s[2] = d.s[3] + el + ar
+	d.s[3] = d.s[4] + al + br
+	d.s[4] = d.s[0] + bl + cr
+	d.s[0] = t
+}
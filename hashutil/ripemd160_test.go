@@ -0,0 +1,28 @@
+package hashutil
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestRIPEMD160 checks the test vectors of the RIPEMD-160 reference
+func TestRIPEMD160(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"", "9c1185a5c5e9fc54612808977ee8f548b2258d31"},
+		{"abc", "8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"},
+		{"message digest", "5d0689ef49d2fae572b881b123a85ffa21595f36"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "12a053384a9c0c88e405a06c27dcf49ada62eb2b"},
+		{strings.Repeat("1234567890", 8), "9b752e45573d4b39f4dbd3323cab82bf63326bfb"},
+		{strings.Repeat("a", 1000000), "52783243c1697bdbe16d37f97f68f08325dc1528"},
+	}
+	for _, test := range tests {
+		h := NewRIPEMD160()
+		h.Write([]byte(test.input))
+		if got := hex.EncodeToString(h.Sum(nil)); got != test.want {
+			t.Errorf("%.20q: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
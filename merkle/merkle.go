@@ -0,0 +1,208 @@
+// Package merkle computes transaction merkle roots and the inclusion proofs
+// (merkle branches) that let light clients check a transaction is in a block.
+package merkle
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+)
+
+// Hash is a txid or merkle node in internal byte order, the reverse of how
+// txids are displayed
+type Hash [32]byte
+
+// ParseHash decodes a displayed, byte-reversed hex txid
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(decoded) != len(h) {
+		return h, fmt.Errorf("hash of %d bytes, want %d", len(decoded), len(h))
+	}
+	for i, b := range decoded {
+		h[len(h)-1-i] = b
+	}
+	return h, nil
+}
+
+// String returns the displayed, byte-reversed hex form of the hash
+func (h Hash) String() string {
+	for i := 0; i < len(h)/2; i++ {
+		h[i], h[len(h)-1-i] = h[len(h)-1-i], h[i]
+	}
+	return hex.EncodeToString(h[:])
+}
+
+// hashPair hashes two sibling nodes into their parent
+func hashPair(left, right Hash) Hash {
+	var pair [64]byte
+	copy(pair[:32], left[:])
+	copy(pair[32:], right[:])
+	return hashutil.Hash256(pair[:])
+}
+
+// parallelThreshold is the number of parents from which a level is hashed
+// by several goroutines; below it the goroutines cost more than they save
+const parallelThreshold = 1024
+
+// nextLevel hashes one tree level into the level above it. An odd last node
+// is paired with itself. Wide levels are split into contiguous chunks hashed
+// in parallel, one per available CPU.
+func nextLevel(level []Hash) []Hash {
+	parents := make([]Hash, (len(level)+1)/2)
+	workers := min(runtime.GOMAXPROCS(0), len(parents)/(parallelThreshold/2))
+	if workers <= 1 {
+		hashParents(parents, level, 0, len(parents))
+		return parents
+	}
+	chunk := (len(parents) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for first := 0; first < len(parents); first += chunk {
+		wg.Add(1)
+		go func(first, last int) {
+			defer wg.Done()
+			hashParents(parents, level, first, last)
+		}(first, min(first+chunk, len(parents)))
+	}
+	wg.Wait()
+	return parents
+}
+
+// hashParents sets parents[first:last] from their children in level
+func hashParents(parents, level []Hash, first, last int) {
+	for i := first; i < last; i++ {
+		left, right := level[2*i], level[2*i]
+		if 2*i+1 < len(level) {
+			right = level[2*i+1]
+		}
+		parents[i] = hashPair(left, right)
+	}
+}
+
+// Root computes the merkle root of txids, which must start with the coinbase.
+// The root of an empty list is the zero hash.
+func Root(txids []Hash) Hash {
+	if len(txids) == 0 {
+		return Hash{}
+	}
+	level := txids
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+// Tree holds every level of a merkle tree, so proofs for many transactions of
+// the same block are computed without rehashing
+type Tree struct {
+	levels [][]Hash // levels[0] are the txids, the last level is the root
+}
+
+// NewTree builds the merkle tree of txids
+func NewTree(txids []Hash) *Tree {
+	t := &Tree{levels: [][]Hash{txids}}
+	for level := txids; len(level) > 1; {
+		level = nextLevel(level)
+		t.levels = append(t.levels, level)
+	}
+	return t
+}
+
+// Root returns the merkle root, the zero hash for an empty tree
+func (t *Tree) Root() Hash {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return Hash{}
+	}
+	return top[0]
+}
+
+// Proof returns the merkle branch of the transaction at position, from its
+// sibling up to the child of the root
+func (t *Tree) Proof(position uint32) []Hash {
+	var branch []Hash
+	index := int(position)
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index
+		}
+		branch = append(branch, level[sibling])
+		index /= 2
+	}
+	return branch
+}
+
+// CoinbaseBranch returns the merkle branch of the coinbase, the first of
+// txids. It does not depend on the coinbase txid, so when only the coinbase
+// changes ProofRoot(txid, branch, 0) gives the new root in one hash per level.
+func CoinbaseBranch(txids []Hash) []Hash {
+	var branch []Hash
+	for level := txids; len(level) > 1; level = nextLevel(level) {
+		branch = append(branch, level[1])
+	}
+	return branch
+}
+
+// ErrNotInBlock is returned by MerkleProof for a txid missing from txids
+var ErrNotInBlock = errors.New("transaction not in block")
+
+// MerkleProof returns the merkle branch of txid, from its sibling up to the
+// child of the root, together with its position in txids
+func MerkleProof(txid Hash, txids []Hash) ([]Hash, uint32, error) {
+	for i, candidate := range txids {
+		if candidate == txid {
+			return NewTree(txids).Proof(uint32(i)), uint32(i), nil
+		}
+	}
+	return nil, 0, ErrNotInBlock
+}
+
+// ProofRoot returns the root that a merkle branch and position link txid to
+func ProofRoot(txid Hash, branch []Hash, position uint32) Hash {
+	node := txid
+	for _, sibling := range branch {
+		if position&1 == 1 {
+			node = hashPair(sibling, node)
+		} else {
+			node = hashPair(node, sibling)
+		}
+		position >>= 1
+	}
+	return node
+}
+
+// VerifyProof reports whether a merkle branch and position produced by
+// MerkleProof link txid to root
+func VerifyProof(txid Hash, branch []Hash, position uint32, root Hash) bool {
+	if len(branch) < 32 && position>>len(branch) != 0 {
+		return false
+	}
+	return ProofRoot(txid, branch, position) == root
+}
+
+// WitnessRoot computes the witness merkle root of a block from its wtxids
+// (BIP141). The coinbase wtxid is replaced by the zero hash, whatever it is.
+func WitnessRoot(wtxids []Hash) Hash {
+	if len(wtxids) == 0 {
+		return Hash{}
+	}
+	leaves := append([]Hash{{}}, wtxids[1:]...)
+	return Root(leaves)
+}
+
+// WitnessCommitment is the hash committed to in the coinbase: the double
+// SHA256 of the witness root followed by the witness reserved value
+func WitnessCommitment(witnessRoot Hash, reservedValue [32]byte) Hash {
+	var data [64]byte
+	copy(data[:32], witnessRoot[:])
+	copy(data[32:], reservedValue[:])
+	return hashutil.Hash256(data[:])
+}
@@ -0,0 +1,135 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// block100000 are the txids of mainnet block 100000
+var block100000 = []string{
+	"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+	"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+	"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+	"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+}
+
+func parseHashes(t *testing.T, hexes []string) []Hash {
+	t.Helper()
+	hashes := make([]Hash, len(hexes))
+	for i, s := range hexes {
+		hash, err := ParseHash(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = hash
+	}
+	return hashes
+}
+
+func TestRootBlock100000(t *testing.T) {
+	txids := parseHashes(t, block100000)
+	const want = "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+	if root := Root(txids); root.String() != want {
+		t.Errorf("Root = %s, want %s", root, want)
+	}
+	if root := NewTree(txids).Root(); root.String() != want {
+		t.Errorf("NewTree(txids).Root() = %s, want %s", root, want)
+	}
+	if root := Root(nil); root != (Hash{}) {
+		t.Errorf("root of no transactions %s", root)
+	}
+}
+
+func TestMerkleProof(t *testing.T) {
+	txids := parseHashes(t, block100000)
+	// Every transaction of an even and an odd-sized block proves, no other one does
+	for _, block := range [][]Hash{txids, txids[:3], txids[:1]} {
+		root := Root(block)
+		for i, txid := range block {
+			t.Run(fmt.Sprintf("%d of %d", i, len(block)), func(t *testing.T) {
+				branch, position, err := MerkleProof(txid, block)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if position != uint32(i) || !VerifyProof(txid, branch, position, root) {
+					t.Fatalf("proof at position %d does not verify", position)
+				}
+				if len(block) == 1 {
+					return
+				}
+				// A last odd transaction is its own sibling, so only even
+				// blocks pin down the position bits
+				if len(block)%2 == 0 && VerifyProof(txid, branch, position^1, root) {
+					t.Error("proof verifies at the sibling's position")
+				}
+				if VerifyProof(block[(i+1)%len(block)], branch, position, root) {
+					t.Error("proof verifies for another transaction")
+				}
+			})
+		}
+	}
+	if _, _, err := MerkleProof(Hash{}, txids); !errors.Is(err, ErrNotInBlock) {
+		t.Errorf("proof of a missing transaction: got %v, want %v", err, ErrNotInBlock)
+	}
+}
+
+func TestCoinbaseBranch(t *testing.T) {
+	txids := parseHashes(t, block100000)
+	// Replacing the coinbase through its branch gives the root of the whole new tree
+	for n := 1; n <= len(txids); n++ {
+		replaced := append([]Hash{{0x01}}, txids[1:n]...)
+		if ProofRoot(replaced[0], CoinbaseBranch(txids[:n]), 0) != Root(replaced) {
+			t.Errorf("coinbase branch of %d transactions gives the wrong root", n)
+		}
+	}
+}
+
+// serialRoot is the merkle root computed one pair at a time
+func serialRoot(level []Hash) Hash {
+	for len(level) > 1 {
+		var parents []Hash
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			parents = append(parents, hashPair(level[i], right))
+		}
+		level = parents
+	}
+	return level[0]
+}
+
+// Levels wide enough to be split between goroutines hash to the same tree as
+// hashing them serially
+func TestRootParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	for _, n := range []int{2 * parallelThreshold, 4*parallelThreshold + 1, 6*parallelThreshold - 3} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			txids := make([]Hash, n)
+			for i := range txids {
+				txids[i] = sha256.Sum256(binary.LittleEndian.AppendUint32(nil, uint32(i)))
+			}
+			if workers := min(runtime.GOMAXPROCS(0), (n+1)/2/(parallelThreshold/2)); workers < 2 {
+				t.Fatalf("%d transactions are hashed by %d goroutines", n, workers)
+			}
+			want := serialRoot(txids)
+			if root := Root(txids); root != want {
+				t.Errorf("Root = %s, want %s", root, want)
+			}
+			tree := NewTree(txids)
+			if root := tree.Root(); root != want {
+				t.Errorf("NewTree(txids).Root() = %s, want %s", root, want)
+			}
+			for _, position := range []uint32{0, 1, uint32(n / 2), uint32(n - 1)} {
+				if !VerifyProof(txids[position], tree.Proof(position), position, want) {
+					t.Errorf("proof of transaction %d does not verify", position)
+				}
+			}
+		})
+	}
+}
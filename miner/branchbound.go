@@ -0,0 +1,218 @@
+package miner
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// DefaultBranchAndBoundBudget is how long BranchAndBoundSelector searches
+// when its Budget is zero
+const DefaultBranchAndBoundBudget = time.Second
+
+// BranchAndBoundSelector searches for the transactions paying the most fees
+// within the weight limit, a knapsack where a transaction can only be taken
+// with its parents. Starting from AncestorSelector's choice, it branches on
+// the candidates by fee rate, highest first: taken with all their ancestors,
+// or left out with all their descendants. A branch is pruned when it could not
+// beat the best choice so far even if the undecided candidates could be split
+// to fill the block exactly. The result is optimal if the search finishes
+// within Budget, and the best choice found otherwise.
+type BranchAndBoundSelector struct {
+	Budget time.Duration // search time, DefaultBranchAndBoundBudget if zero
+}
+
+// bnbCheckInterval is how many branches the search explores between looks at
+// the clock
+const bnbCheckInterval = 1024
+
+// Select implements Selector
+func (s BranchAndBoundSelector) Select(candidates []Candidate, maxWeight int) []int {
+	budget := s.Budget
+	if budget == 0 {
+		budget = DefaultBranchAndBoundBudget
+	}
+	ancestors := ancestorSets(candidates)
+	descendants := make([][]int, len(candidates))
+	totalWeight := 0
+	for i := range candidates {
+		totalWeight += candidates[i].Weight
+		for ancestor := range ancestors[i] {
+			descendants[ancestor] = append(descendants[ancestor], i)
+		}
+	}
+	all := make([]int, len(candidates))
+	for i := range all {
+		all[i] = i
+	}
+	if totalWeight <= maxWeight {
+		return blockOrder(all, ancestors)
+	}
+
+	search := &bnbSearch{
+		candidates:  candidates,
+		ancestors:   ancestors,
+		descendants: descendants,
+		state:       make([]int8, len(candidates)),
+		free:        maxWeight,
+		deadline:    time.Now().Add(budget),
+	}
+	search.best = AncestorSelector{}.Select(candidates, maxWeight)
+	for _, i := range search.best {
+		search.bestFee += candidates[i].Fee
+	}
+	initialFee := search.bestFee
+
+	// Branch on the candidates by fee rate, so the first dives follow the
+	// greedy choice and the fractional bound is a prefix of the order
+	search.order = all
+	sort.SliceStable(search.order, func(a, b int) bool {
+		return candidates[search.order[a]].FeeRate() > candidates[search.order[b]].FeeRate()
+	})
+	search.branch(0)
+	slog.Debug("branch and bound selection", "branches", search.branches, "exhaustive", !search.stopped,
+		"fees", search.bestFee, "gain", search.bestFee-initialFee)
+	return blockOrder(search.best, ancestors)
+}
+
+// Decisions of a bnbSearch on a candidate
+const (
+	bnbUndecided int8 = iota
+	bnbTaken
+	bnbLeftOut
+)
+
+// bnbSearch is the state of a BranchAndBoundSelector search: the decision on
+// every candidate, undone on backtracking through trail
+type bnbSearch struct {
+	candidates  []Candidate
+	ancestors   []map[int]bool
+	descendants [][]int
+	order       []int // candidate indexes by fee rate, highest first
+
+	state []int8
+	trail []int // candidates decided on the current branch, in decision order
+	free  int   // weight left
+	fee   int64 // fees of the taken candidates
+
+	best     []int
+	bestFee  int64
+	branches int
+	deadline time.Time
+	stopped  bool // the budget ran out
+}
+
+// bound returns an upper bound of the fees of any completion of the current
+// branch: the undecided candidates from position on, filling the weight left
+// by fee rate with the last one split
+func (s *bnbSearch) bound(position int) float64 {
+	bound, free := float64(s.fee), s.free
+	for _, i := range s.order[position:] {
+		if s.state[i] != bnbUndecided {
+			continue
+		}
+		c := s.candidates[i]
+		if c.Weight >= free {
+			return bound + c.FeeRate()*float64(free)
+		}
+		bound += float64(c.Fee)
+		free -= c.Weight
+	}
+	return bound
+}
+
+// decide records the decision on candidate i
+func (s *bnbSearch) decide(i int, decision int8) {
+	s.state[i] = decision
+	s.trail = append(s.trail, i)
+	if decision == bnbTaken {
+		s.free -= s.candidates[i].Weight
+		s.fee += s.candidates[i].Fee
+	}
+}
+
+// undo reverts the decisions made after the trail was mark long
+func (s *bnbSearch) undo(mark int) {
+	for _, i := range s.trail[mark:] {
+		if s.state[i] == bnbTaken {
+			s.free += s.candidates[i].Weight
+			s.fee -= s.candidates[i].Fee
+		}
+		s.state[i] = bnbUndecided
+	}
+	s.trail = s.trail[:mark]
+}
+
+// branch keeps the current branch if it beats the best choice, then explores
+// its completions, deciding the undecided candidates from position on in order
+func (s *bnbSearch) branch(position int) {
+	// The taken candidates always include their ancestors, so every branch
+	// is a valid choice
+	if s.fee > s.bestFee {
+		s.bestFee = s.fee
+		s.best = s.best[:0]
+		for i, state := range s.state {
+			if state == bnbTaken {
+				s.best = append(s.best, i)
+			}
+		}
+	}
+	for position < len(s.order) && s.state[s.order[position]] != bnbUndecided {
+		position++
+	}
+	if position == len(s.order) || s.stopped {
+		return
+	}
+	if s.branches++; s.branches%bnbCheckInterval == 0 && time.Now().After(s.deadline) {
+		s.stopped = true
+		return
+	}
+	// Fees are whole sats, so a bound below the best fee plus one cannot improve on it
+	if s.bound(position) < float64(s.bestFee+1) {
+		return
+	}
+
+	i := s.order[position]
+	mark := len(s.trail)
+	weight := s.candidates[i].Weight
+	for ancestor := range s.ancestors[i] {
+		if s.state[ancestor] == bnbUndecided {
+			weight += s.candidates[ancestor].Weight
+		}
+	}
+	// An ancestor left out leaves its descendants out, so every ancestor is
+	// taken or undecided here
+	if weight <= s.free {
+		for ancestor := range s.ancestors[i] {
+			if s.state[ancestor] == bnbUndecided {
+				s.decide(ancestor, bnbTaken)
+			}
+		}
+		s.decide(i, bnbTaken)
+		s.branch(position + 1)
+		s.undo(mark)
+	}
+
+	s.decide(i, bnbLeftOut)
+	for _, descendant := range s.descendants[i] {
+		if s.state[descendant] == bnbUndecided {
+			s.decide(descendant, bnbLeftOut)
+		}
+	}
+	s.branch(position + 1)
+	s.undo(mark)
+}
+
+// blockOrder sorts selected candidates so every one comes after its ancestors:
+// by number of ancestors, which is larger for a child than for any of its
+// ancestors, then by index
+func blockOrder(selected []int, ancestors []map[int]bool) []int {
+	sorted := append([]int(nil), selected...)
+	sort.Slice(sorted, func(a, b int) bool {
+		if len(ancestors[sorted[a]]) != len(ancestors[sorted[b]]) {
+			return len(ancestors[sorted[a]]) < len(ancestors[sorted[b]])
+		}
+		return sorted[a] < sorted[b]
+	})
+	return sorted
+}
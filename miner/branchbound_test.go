@@ -0,0 +1,146 @@
+package miner
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// checkSelection fails t unless selected is a valid block of candidates:
+// each at most once, after its parents, within maxWeight. It returns the fees.
+func checkSelection(t *testing.T, candidates []Candidate, selected []int, maxWeight int) int64 {
+	t.Helper()
+	position := make(map[int]int, len(selected))
+	var fee int64
+	weight := 0
+	for i, candidate := range selected {
+		if _, seen := position[candidate]; seen {
+			t.Fatalf("candidate %d selected twice in %v", candidate, selected)
+		}
+		position[candidate] = i
+		fee += candidates[candidate].Fee
+		weight += candidates[candidate].Weight
+	}
+	for _, candidate := range selected {
+		for _, parent := range candidates[candidate].Parents {
+			if p, ok := position[parent]; !ok || p > position[candidate] {
+				t.Fatalf("candidate %d selected without its parent %d before it: %v", candidate, parent, selected)
+			}
+		}
+	}
+	if weight > maxWeight {
+		t.Fatalf("selected weight %d above the limit of %d", weight, maxWeight)
+	}
+	return fee
+}
+
+// randomCandidates returns n candidates of varied fee rates, about a third of
+// them spending one or two earlier ones, listed in a shuffled order
+func randomCandidates(n int, seed int64) []Candidate {
+	random := rand.New(rand.NewSource(seed))
+	candidates := make([]Candidate, n)
+	for i := range candidates {
+		candidates[i] = Candidate{Txid: fmt.Sprint(i), Fee: int64(100 + random.Intn(5000)), Weight: 400 + random.Intn(2000)}
+		if i > 0 && random.Intn(3) == 0 {
+			candidates[i].Parents = append(candidates[i].Parents, random.Intn(i))
+			if parent := random.Intn(i); i > 1 && random.Intn(2) == 0 && !slices.Contains(candidates[i].Parents, parent) {
+				candidates[i].Parents = append(candidates[i].Parents, parent)
+			}
+		}
+	}
+	// Children listed before their parents must still follow them in the block
+	order := random.Perm(n)
+	position := make([]int, n)
+	for to, from := range order {
+		position[from] = to
+	}
+	shuffled := make([]Candidate, n)
+	for from, candidate := range candidates {
+		for j, parent := range candidate.Parents {
+			candidate.Parents[j] = position[parent]
+		}
+		shuffled[position[from]] = candidate
+	}
+	return shuffled
+}
+
+func TestBranchAndBoundSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		maxWeight  int
+		want       []int // nil to only compare with AncestorSelector
+		better     bool  // AncestorSelector is suboptimal
+	}{
+		{
+			// The best fee rate fills the block alone for 700 sats; a parent
+			// and child at a lower rate fill it for 1000, the child listed first
+			name: "parent and child beat the best fee rate",
+			candidates: []Candidate{
+				{Txid: "child", Fee: 500, Weight: 500, Parents: []int{1}},
+				{Txid: "parent", Fee: 500, Weight: 500},
+				{Txid: "best-rate", Fee: 700, Weight: 600},
+			},
+			maxWeight: 1000,
+			want:      []int{1, 0},
+			better:    true,
+		},
+		{
+			// Greedy takes the best rate and wastes the rest of the block
+			name: "two lower rates fill the block exactly",
+			candidates: []Candidate{
+				{Txid: "best-rate", Fee: 620, Weight: 600},
+				{Txid: "half-1", Fee: 500, Weight: 500},
+				{Txid: "half-2", Fee: 500, Weight: 500},
+			},
+			maxWeight: 1000,
+			want:      []int{1, 2},
+			better:    true,
+		},
+		{
+			name: "everything fits, chain listed backwards",
+			candidates: []Candidate{
+				{Txid: "grandchild", Fee: 100, Weight: 100, Parents: []int{1}},
+				{Txid: "child", Fee: 100, Weight: 100, Parents: []int{2}},
+				{Txid: "parent", Fee: 100, Weight: 100},
+			},
+			maxWeight: 1000,
+			want:      []int{2, 1, 0},
+		},
+		{name: "random mempool", candidates: randomCandidates(40, 1), maxWeight: 20000},
+		{name: "random mempool, tight block", candidates: randomCandidates(40, 2), maxWeight: 6000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			greedy := AncestorSelector{}.Select(test.candidates, test.maxWeight)
+			greedyFee := checkSelection(t, test.candidates, greedy, test.maxWeight)
+			selected := BranchAndBoundSelector{}.Select(test.candidates, test.maxWeight)
+			fee := checkSelection(t, test.candidates, selected, test.maxWeight)
+			if fee < greedyFee || test.better && fee == greedyFee {
+				t.Errorf("fees %d, AncestorSelector's %d", fee, greedyFee)
+			}
+			if test.want != nil && !slices.Equal(selected, test.want) {
+				t.Errorf("selected %v, want %v", selected, test.want)
+			}
+		})
+	}
+}
+
+// A search cut short by its budget still returns a valid block, at least as
+// good as AncestorSelector's it started from
+func TestBranchAndBoundSelectorBudget(t *testing.T) {
+	candidates := randomCandidates(300, 3)
+	const maxWeight = 100000
+	greedyFee := checkSelection(t, candidates, AncestorSelector{}.Select(candidates, maxWeight), maxWeight)
+
+	start := time.Now()
+	selected := BranchAndBoundSelector{Budget: time.Nanosecond}.Select(candidates, maxWeight)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("search of a 1ns budget took %v", elapsed)
+	}
+	if fee := checkSelection(t, candidates, selected, maxWeight); fee < greedyFee {
+		t.Errorf("fees %d below the %d of AncestorSelector", fee, greedyFee)
+	}
+}
@@ -0,0 +1,92 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Size bounds of the coinbase scriptSig enforced by consensus (bad-cb-length)
+const (
+	MinCoinbaseScriptSize = 2
+	MaxCoinbaseScriptSize = 100
+)
+
+// ErrCoinbaseScriptSize is returned for a coinbase scriptSig outside the consensus bounds
+var ErrCoinbaseScriptSize = errors.New("coinbase scriptsig size out of bounds")
+
+// CoinbaseScriptSig builds a coinbase scriptSig: the block height as BIP34
+// requires it, pushed first as a minimally encoded script number, a push of
+// the extranonce as 8 little-endian bytes, and a push of the miner tag unless
+// it is empty
+func CoinbaseScriptSig(height uint32, extranonce uint64, tag []byte) []byte {
+	var builder script.Builder
+	builder.AddInt64(int64(height))
+	builder.AddData(binary.LittleEndian.AppendUint64(nil, extranonce))
+	if len(tag) > 0 {
+		builder.AddData(tag)
+	}
+	return builder.Script()
+}
+
+// CheckCoinbaseScript verifies that a coinbase scriptSig is between
+// MinCoinbaseScriptSize and MaxCoinbaseScriptSize bytes long
+func CheckCoinbaseScript(scriptSig []byte) error {
+	if len(scriptSig) < MinCoinbaseScriptSize || len(scriptSig) > MaxCoinbaseScriptSize {
+		return fmt.Errorf("%w: %d bytes, must be %d to %d", ErrCoinbaseScriptSize, len(scriptSig), MinCoinbaseScriptSize, MaxCoinbaseScriptSize)
+	}
+	return nil
+}
+
+// witnessCommitmentHeader starts the scriptPubKey of the coinbase output
+// committing to the witness root: OP_RETURN, a 36-byte push and 0xaa21a9ed
+var witnessCommitmentHeader = []byte{script.OpReturn, 0x24, 0xaa, 0x21, 0xa9, 0xed}
+
+// witnessCommitmentScript returns the scriptPubKey committing to a witness commitment
+func witnessCommitmentScript(commitment merkle.Hash) []byte {
+	return append(append([]byte(nil), witnessCommitmentHeader...), commitment[:]...)
+}
+
+// commitmentOutput returns the zero-value coinbase output carrying a witness commitment
+func commitmentOutput(commitment merkle.Hash) tx.TxOutput {
+	scriptPubKey := witnessCommitmentScript(commitment)
+	asm, _ := script.Disasm(scriptPubKey)
+	return tx.TxOutput{
+		ScriptPubKey:     scriptPubKey,
+		ScriptPubKeyASM:  asm,
+		ScriptPubKeyType: script.ClassifyScript(scriptPubKey).String(),
+	}
+}
+
+// ErrWitnessCommitment is returned for a coinbase that does not commit to the
+// witnesses of its block
+var ErrWitnessCommitment = errors.New("bad witness commitment")
+
+// CheckWitnessCommitment verifies that the last coinbase output committing to
+// a witness root (BIP141) commits to wtxids, the wtxids of the block in order
+// with the coinbase first, and to the witness reserved value of the coinbase
+func CheckWitnessCommitment(coinbase tx.Transaction, wtxids []merkle.Hash) error {
+	committed := -1
+	for i, output := range coinbase.Vout {
+		if len(output.ScriptPubKey) >= len(witnessCommitmentHeader)+len(merkle.Hash{}) && bytes.HasPrefix(output.ScriptPubKey, witnessCommitmentHeader) {
+			committed = i
+		}
+	}
+	if committed < 0 {
+		return fmt.Errorf("%w: no commitment output", ErrWitnessCommitment)
+	}
+	if len(coinbase.Vin) != 1 || len(coinbase.Vin[0].Witness) != 1 || len(coinbase.Vin[0].Witness[0]) != 32 {
+		return fmt.Errorf("%w: the coinbase witness is not a 32-byte reserved value", ErrWitnessCommitment)
+	}
+	want := merkle.WitnessCommitment(merkle.WitnessRoot(wtxids), [32]byte(coinbase.Vin[0].Witness[0]))
+	got := []byte(coinbase.Vout[committed].ScriptPubKey[len(witnessCommitmentHeader) : len(witnessCommitmentHeader)+len(want)])
+	if !bytes.Equal(got, want[:]) {
+		return fmt.Errorf("%w: output %d commits to %x, want %x", ErrWitnessCommitment, committed, got, want[:])
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TestCheckCoinbaseScript checks the 2-100 byte consensus bounds at both ends
+func TestCheckCoinbaseScript(t *testing.T) {
+	tests := []struct {
+		size  int
+		valid bool
+	}{
+		{0, false},
+		{1, false},
+		{2, true},
+		{100, true},
+		{101, false},
+	}
+	for _, test := range tests {
+		err := CheckCoinbaseScript(make([]byte, test.size))
+		if (err == nil) != test.valid || (err != nil && !errors.Is(err, ErrCoinbaseScriptSize)) {
+			t.Errorf("%d-byte scriptsig: got %v", test.size, err)
+		}
+	}
+}
+
+// TestCoinbaseScriptSigTag checks the longest tag that fits the scriptSig. At
+// height 840000 the height push is 4 bytes and the extranonce push 9; an
+// 85-byte tag needs OP_PUSHDATA1, which fills the scriptSig exactly, and one
+// more byte is too many.
+func TestCoinbaseScriptSigTag(t *testing.T) {
+	tests := []struct {
+		tagSize int
+		want    error
+	}{
+		{84, nil},
+		{85, nil},
+		{86, ErrCoinbaseScriptSize},
+	}
+	for _, test := range tests {
+		scriptSig := CoinbaseScriptSig(840000, 0, make([]byte, test.tagSize))
+		if err := CheckCoinbaseScript(scriptSig); !errors.Is(err, test.want) {
+			t.Errorf("%d-byte tag: %d-byte scriptsig, got %v, want %v", test.tagSize, len(scriptSig), err, test.want)
+		}
+	}
+	if scriptSig := CoinbaseScriptSig(840000, 0, make([]byte, 85)); len(scriptSig) != 100 {
+		t.Errorf("85-byte tag: got a %d-byte scriptsig, want 100", len(scriptSig))
+	}
+}
+
+// TestCoinbaseScriptSigHeight checks BIP34 heights are minimal script numbers:
+// small ones are opcodes, and 128 needs a sign byte
+func TestCoinbaseScriptSigHeight(t *testing.T) {
+	tests := []struct {
+		height uint32
+		want   string
+	}{
+		{0, "00"},
+		{16, "60"},
+		{17, "0111"},
+		{127, "017f"},
+		{128, "028000"},
+		{840000, "0340d10c"},
+	}
+	for _, test := range tests {
+		scriptSig := CoinbaseScriptSig(test.height, 1, []byte("/sob/"))
+		if got, want := hex.EncodeToString(scriptSig), test.want+"080100000000000000052f736f622f"; got != want {
+			t.Errorf("height %d: got %s, want %s", test.height, got, want)
+		}
+	}
+}
+
+// TestRollExtranonce checks rolling the extranonce changes the coinbase and
+// the merkle root committing to it
+func TestRollExtranonce(t *testing.T) {
+	m := New(Options{Height: 840000, CoinbaseTag: []byte("/sob/")})
+	template, err := m.BuildTemplate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for extranonce := uint64(1); extranonce <= 2; extranonce++ {
+		if err := m.RollExtranonce(&template, extranonce); err != nil {
+			t.Fatal(err)
+		}
+		coinbase := template.Block.Transactions[0]
+		want := fmt.Sprintf("0340d10c080%d00000000000000052f736f622f", extranonce)
+		if got := hex.EncodeToString(coinbase.Vin[0].ScriptSig); got != want {
+			t.Errorf("extranonce %d: got scriptsig %s, want %s", extranonce, got, want)
+		}
+		txid, err := tx.Txid(coinbase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root := merkle.Hash(template.Block.Header.MerkleRoot); root.String() != txid {
+			t.Errorf("extranonce %d: merkle root %s, coinbase txid %s", extranonce, root, txid)
+		}
+	}
+}
+
+// TestCheckWitnessCommitment checks a block of only the coinbase commits to
+// the zero witness root and, by default, the zero reserved value, like every
+// empty regtest block
+func TestCheckWitnessCommitment(t *testing.T) {
+	template, err := New(Options{}).BuildTemplate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coinbase := template.Block.Transactions[0]
+	if len(coinbase.Vin[0].Witness) != 1 || !bytes.Equal(coinbase.Vin[0].Witness[0], make([]byte, 32)) {
+		t.Errorf("coinbase witness %v", coinbase.Vin[0].Witness)
+	}
+	commitment := coinbase.Vout[len(coinbase.Vout)-1].ScriptPubKey
+	if got, want := hex.EncodeToString(commitment), "6a24aa21a9ede2f61c3f71d1defd3fa999dfa36953755c690689799962b48bebd836974e8cf9"; got != want {
+		t.Errorf("commitment output %s, want %s", got, want)
+	}
+	if err := CheckWitnessCommitment(coinbase, []merkle.Hash{{}}); err != nil {
+		t.Error(err)
+	}
+	if err := CheckWitnessCommitment(coinbase, []merkle.Hash{{}, {1}}); !errors.Is(err, ErrWitnessCommitment) {
+		t.Errorf("commitment to another block: got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// CoinView is the state of the outputs of blocks already mined, such as a
+// chain.UTXOSet. Coin reports false for an output no mined block created or
+// spent.
+type CoinView interface {
+	Coin(txid string, vout int) (chain.Coin, bool)
+}
+
+// Rejection reasons of spends checked against a CoinView
+var (
+	ErrSpentOutput      = errors.New("spends an output already spent in a mined block")
+	ErrPrevoutMismatch  = errors.New("prevout differs from the output the mined block created")
+	ErrImmatureCoinbase = errors.New("spends a coinbase output before it matures")
+)
+
+// withKnownCoins returns the transactions whose inputs agree with coins,
+// reporting the others to reject. Inputs spending outputs coins does not know
+// keep trusting their prevout; the others must spend an unspent output with
+// the same value and script, and a coinbase output only maturity blocks
+// after it was created.
+func withKnownCoins(entries []txpool.Entry, coins CoinView, height, maturity uint32, reject func(tx.Transaction, error)) []txpool.Entry {
+	kept := make([]txpool.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if err := checkKnownCoins(entry.Tx, coins, height, maturity); err != nil {
+			reject(entry.Tx, err)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// checkKnownCoins checks the inputs of a transaction against coins
+func checkKnownCoins(transaction tx.Transaction, coins CoinView, height, maturity uint32) error {
+	for i, vin := range transaction.Vin {
+		coin, ok := coins.Coin(vin.Txid, vin.Vout)
+		switch {
+		case !ok:
+		case coin.Spent:
+			return txerror.Input(i, fmt.Errorf("%w: %s:%d", ErrSpentOutput, vin.Txid, vin.Vout))
+		case coin.Value != vin.PrevOut.Value || !bytes.Equal(coin.ScriptPubKey, vin.PrevOut.ScriptPubKey):
+			return txerror.Input(i, fmt.Errorf("%w: %s:%d", ErrPrevoutMismatch, vin.Txid, vin.Vout))
+		case coin.Coinbase && height < coin.Height+maturity:
+			return txerror.Input(i, fmt.Errorf("%w: %s:%d from height %d at height %d", ErrImmatureCoinbase, vin.Txid, vin.Vout, coin.Height, height))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+func TestWithKnownCoins(t *testing.T) {
+	// A UTXO set that has connected a block at height 100 spending an
+	// outside output and paying a new one
+	paying, _ := hex.DecodeString("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a")
+	outside := tx.Prevout{ScriptPubKey: paying, Value: 100000}
+	outsideTxid := strings.Repeat("11", 32)
+	parent := tx.Transaction{
+		Version: 2,
+		Vin:     []tx.TxInput{{Txid: outsideTxid, PrevOut: outside}},
+		Vout:    []tx.TxOutput{{ScriptPubKey: paying, Value: 90000}},
+	}
+	template, err := New(Options{Height: 100}).BuildTemplate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined := block.Block{Transactions: []tx.Transaction{template.Block.Transactions[0], parent}}
+	coins, err := chain.OpenUTXOSet(filepath.Join(t.TempDir(), "utxo.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer coins.Close()
+	if err := coins.ConnectBlock(mined, 100); err != nil {
+		t.Fatal(err)
+	}
+	parentTxid, _ := tx.Txid(parent)
+	coinbaseTxid, _ := tx.Txid(mined.Transactions[0])
+	coinbase := tx.Prevout{ScriptPubKey: mined.Transactions[0].Vout[0].ScriptPubKey, Value: mined.Transactions[0].Vout[0].Value}
+
+	tests := []struct {
+		name    string
+		txid    string
+		prevout tx.Prevout
+		height  uint32
+		want    error
+	}{
+		{"outside output again", outsideTxid, outside, 150, ErrSpentOutput},
+		{"inflated prevout", parentTxid, tx.Prevout{ScriptPubKey: paying, Value: 95000}, 150, ErrPrevoutMismatch},
+		{"other script", parentTxid, tx.Prevout{ScriptPubKey: coinbase.ScriptPubKey, Value: 90000}, 150, ErrPrevoutMismatch},
+		{"immature coinbase", coinbaseTxid, coinbase, 199, ErrImmatureCoinbase},
+		{"mature coinbase", coinbaseTxid, coinbase, 200, nil},
+		{"created output", parentTxid, tx.Prevout{ScriptPubKey: paying, Value: 90000}, 150, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spend := tx.Transaction{
+				Version: 2,
+				Vin:     []tx.TxInput{{Txid: test.txid, PrevOut: test.prevout}},
+				Vout:    []tx.TxOutput{{ScriptPubKey: paying, Value: test.prevout.Value - 1000}},
+			}
+			entries := txpool.NewEntries([]tx.Transaction{spend}, func(_ tx.Transaction, err error) { t.Fatal(err) })
+			var reason error
+			kept := withKnownCoins(entries, coins, test.height, 100, func(_ tx.Transaction, err error) { reason = err })
+			if !errors.Is(reason, test.want) || (test.want == nil) != (len(kept) == 1) {
+				t.Errorf("kept %d, rejected with %v, want %v", len(kept), reason, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package miner
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Conflict records a transaction displaced by another one spending the same outpoint
+type Conflict struct {
+	Displaced       tx.Transaction
+	DisplacedTxid   string
+	ReplacementTxid string
+	Outpoint        string // txid:vout spent by both
+}
+
+// ResolveConflicts keeps at most one spender of every outpoint. As under
+// BIP125, a replacement displaces the transactions it conflicts with only by
+// paying a higher fee rate: transactions are taken by decreasing fee rate, then
+// decreasing fee, then their order in transactions, rather than whichever was
+// read first. The kept transactions stay in their original order.
+func ResolveConflicts(entries []txpool.Entry) ([]txpool.Entry, []Conflict) {
+	// Most mempools have no conflicts; skip ranking them
+	spenders := make(map[string]int)
+	conflicting := false
+	for _, entry := range entries {
+		for _, vin := range entry.Tx.Vin {
+			spenders[outpoint(vin)]++
+			conflicting = conflicting || spenders[outpoint(vin)] > 1
+		}
+	}
+	if !conflicting {
+		return entries, nil
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if c := cmp.Compare(entries[b].FeeRate(), entries[a].FeeRate()); c != 0 {
+			return c
+		}
+		return cmp.Compare(entries[b].Fee, entries[a].Fee)
+	})
+
+	spentBy := make(map[string]string) // outpoint to the txid of the kept spender
+	kept := make([]bool, len(entries))
+	var conflicts []Conflict
+	for _, index := range order {
+		transaction, txid := entries[index].Tx, entries[index].Txid
+		conflict := -1
+		for i, vin := range transaction.Vin {
+			if _, spent := spentBy[outpoint(vin)]; spent {
+				conflict = i
+				break
+			}
+		}
+		if conflict >= 0 {
+			spent := outpoint(transaction.Vin[conflict])
+			conflicts = append(conflicts, Conflict{
+				Displaced:       transaction,
+				DisplacedTxid:   txid,
+				ReplacementTxid: spentBy[spent],
+				Outpoint:        spent,
+			})
+			continue
+		}
+		for _, vin := range transaction.Vin {
+			spentBy[outpoint(vin)] = txid
+		}
+		kept[index] = true
+	}
+
+	var resolved []txpool.Entry
+	for i, entry := range entries {
+		if kept[i] {
+			resolved = append(resolved, entry)
+		}
+	}
+	return resolved, conflicts
+}
+
+// outpoint returns the txid:vout an input spends
+func outpoint(vin tx.TxInput) string {
+	return fmt.Sprintf("%s:%d", vin.Txid, vin.Vout)
+}
@@ -0,0 +1,108 @@
+package miner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// conflictEntry returns the entry of a transaction spending output vout of
+// txid, worth 100000 sats, and paying fee in fees. Outputs of extra bytes
+// make it heavier.
+func conflictEntry(t *testing.T, txid string, vout int, fee int64, extra int) txpool.Entry {
+	t.Helper()
+	entry, err := txpool.NewEntry(tx.Transaction{
+		Version: 2,
+		Vin:     []tx.TxInput{{Txid: txid, Vout: vout, Sequence: 0xfffffffd, PrevOut: tx.Prevout{Value: 100000}}},
+		Vout:    []tx.TxOutput{{ScriptPubKey: append([]byte{0x6a}, make([]byte, extra)...), Value: 100000 - fee}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestResolveConflicts(t *testing.T) {
+	outside := strings.Repeat("11", 32)
+	low := conflictEntry(t, outside, 0, 1000, 0)
+	high := conflictEntry(t, outside, 0, 3000, 0)
+	heavy := conflictEntry(t, outside, 0, 3000, 2000)   // higher fee, lower fee rate than low
+	heavier := conflictEntry(t, outside, 0, 3100, 2000) // same weight as heavy, higher fee
+	other := conflictEntry(t, outside, 1, 500, 0)       // spends another output
+	child := conflictEntry(t, low.Txid, 0, 2000, 0)     // spends an output of low, conflicting with nothing
+	twin := high.Tx                                     // same fee and weight as high
+	twin.Locktime = 1
+	twinEntry, err := txpool.NewEntry(twin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		entries     []txpool.Entry
+		kept        []string
+		displaced   []string
+		replacement string // kept spender of the outpoint in conflict
+	}{
+		{"no conflict", []txpool.Entry{low, other, child}, []string{low.Txid, other.Txid, child.Txid}, nil, ""},
+		{"replacement last", []txpool.Entry{low, high}, []string{high.Txid}, []string{low.Txid}, high.Txid},
+		{"replacement first", []txpool.Entry{high, low}, []string{high.Txid}, []string{low.Txid}, high.Txid},
+		{"fee rate over fee", []txpool.Entry{heavy, low}, []string{low.Txid}, []string{heavy.Txid}, low.Txid},
+		{"fee breaks a tie of fee rate", []txpool.Entry{heavy, heavier}, []string{heavier.Txid}, []string{heavy.Txid}, heavier.Txid},
+		{"first of equal fee rates and fees", []txpool.Entry{twinEntry, high}, []string{twinEntry.Txid}, []string{high.Txid}, twinEntry.Txid},
+		{"kept in their order", []txpool.Entry{other, low, child, high}, []string{other.Txid, child.Txid, high.Txid}, []string{low.Txid}, high.Txid},
+		{"three spenders", []txpool.Entry{low, heavy, high}, []string{high.Txid}, []string{low.Txid, heavy.Txid}, high.Txid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kept, conflicts := ResolveConflicts(test.entries)
+			var keptTxids, displaced []string
+			for _, entry := range kept {
+				keptTxids = append(keptTxids, entry.Txid)
+			}
+			for _, conflict := range conflicts {
+				displaced = append(displaced, conflict.DisplacedTxid)
+				if conflict.Outpoint != outside+":0" || conflict.ReplacementTxid != test.replacement {
+					t.Errorf("%s displaced by %s over %s, want %s over %s:0", conflict.DisplacedTxid, conflict.ReplacementTxid, conflict.Outpoint, test.replacement, outside)
+				}
+			}
+			if strings.Join(keptTxids, ",") != strings.Join(test.kept, ",") {
+				t.Errorf("kept %v, want %v", keptTxids, test.kept)
+			}
+			if strings.Join(displaced, ",") != strings.Join(test.displaced, ",") {
+				t.Errorf("displaced %v, want %v", displaced, test.displaced)
+			}
+		})
+	}
+}
+
+// The descendants of a displaced transaction spend outputs that will never be
+// mined, so BuildCandidates prunes them as orphans, however deep
+func TestBuildCandidatesPrunesDisplacedDescendants(t *testing.T) {
+	outside := strings.Repeat("11", 32)
+	low := conflictEntry(t, outside, 0, 1000, 0)
+	high := conflictEntry(t, outside, 0, 3000, 0)
+	child := conflictEntry(t, low.Txid, 0, 2000, 0)
+	grandchild := conflictEntry(t, child.Txid, 0, 2000, 0)
+	other := conflictEntry(t, outside, 1, 500, 0)
+	otherChild := conflictEntry(t, other.Txid, 0, 500, 0)
+	all := []txpool.Entry{grandchild, low, otherChild, child, high, other}
+
+	kept, conflicts := ResolveConflicts(all)
+	if len(conflicts) != 1 || conflicts[0].DisplacedTxid != low.Txid {
+		t.Fatalf("conflicts %+v, want low displaced", conflicts)
+	}
+	candidates := BuildCandidates(kept, all)
+	var txids []string
+	for _, candidate := range candidates {
+		txids = append(txids, candidate.Txid)
+	}
+	if want := []string{otherChild.Txid, high.Txid, other.Txid}; strings.Join(txids, ",") != strings.Join(want, ",") {
+		t.Fatalf("candidates %v, want %v", txids, want)
+	}
+	// Parents are indexes among the candidates left
+	if len(candidates[0].Parents) != 1 || candidates[0].Parents[0] != 2 {
+		t.Errorf("parents of the child of other %v, want [2]", candidates[0].Parents)
+	}
+}
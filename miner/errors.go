@@ -0,0 +1,13 @@
+package miner
+
+import "errors"
+
+// Failure classes of mining a block. The errors of each class wrap its
+// variable, so commands tell them apart with errors.Is, e.g. to exit with a
+// status of their own.
+var (
+	ErrMempoolLoad         = errors.New("loading the mempool")
+	ErrNoValidTransactions = errors.New("no candidate transaction is valid")
+	ErrTargetNotMet        = errors.New("no header hash met the target")
+	ErrOutputWrite         = errors.New("writing the output")
+)
@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// errExcluded is the rejection reason of transactions listed in Options.Exclude
+var errExcluded = errors.New("excluded by txid")
+
+// withoutExcluded returns the transactions whose txid is not in exclude,
+// reporting the others to reject
+func withoutExcluded(entries []txpool.Entry, exclude map[string]bool, reject func(tx.Transaction, error)) []txpool.Entry {
+	kept := make([]txpool.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if exclude[entry.Txid] {
+			reject(entry.Tx, errExcluded)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// scriptTypes returns the types of the prevouts spent by a transaction and of
+// its outputs, classified from the scripts themselves
+func scriptTypes(transaction tx.Transaction) []script.ScriptType {
+	var types []script.ScriptType
+	for _, vin := range transaction.Vin {
+		types = append(types, script.ClassifyScript(vin.PrevOut.ScriptPubKey))
+	}
+	for _, vout := range transaction.Vout {
+		types = append(types, script.ClassifyScript(vout.ScriptPubKey))
+	}
+	return types
+}
+
+// withScriptTypes returns the transactions whose inputs and outputs all have a
+// type in only, when only is not empty, and none a type in exclude, reporting
+// the others to reject
+func withScriptTypes(entries []txpool.Entry, only, exclude []script.ScriptType, reject func(tx.Transaction, error)) []txpool.Entry {
+	allowed := make(map[script.ScriptType]bool, len(only))
+	for _, scriptType := range only {
+		allowed[scriptType] = true
+	}
+	excluded := make(map[script.ScriptType]bool, len(exclude))
+	for _, scriptType := range exclude {
+		excluded[scriptType] = true
+	}
+
+	kept := make([]txpool.Entry, 0, len(entries))
+	for _, entry := range entries {
+		var reason error
+		for _, scriptType := range scriptTypes(entry.Tx) {
+			if len(allowed) > 0 && !allowed[scriptType] {
+				reason = fmt.Errorf("has a %s script, not among the allowed types", scriptType)
+				break
+			}
+			if excluded[scriptType] {
+				reason = fmt.Errorf("has an excluded %s script", scriptType)
+				break
+			}
+		}
+		if reason != nil {
+			reject(entry.Tx, reason)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
@@ -0,0 +1,410 @@
+// Package miner selects transactions for a block and searches for its proof of work.
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+	"unsafe"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/address"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Options configures a Miner. Zero values select the defaults.
+type Options struct {
+	Params               *chaincfg.Params    // network parameters, chaincfg.MainNetParams if nil
+	Target               [32]byte            // difficulty target, Params.DefaultTarget if zero
+	Version              uint32              // header version, chaincfg.VersionBitsTopBits (BIP9, no deployment signaled) if zero
+	PrevBlockHash        [32]byte            // hash of the block extended, in internal byte order; zero by default
+	Height               uint32              // height of the block, which sets its subsidy and starts the default coinbase scriptSig
+	MaxWeight            int                 // weight limit of the coinbase and selected transactions, Params.MaxWeight if zero
+	MinFeeRate           float64             // sat/vB below which transactions are dropped before validation
+	ScriptFlags          script.Flags        // rules input scripts are verified with, script.StandardFlags if zero
+	SigCache             *script.SigCache    // valid signatures skipped when validating again, a cache shared by all Miners if nil
+	ScriptCache          *ScriptCache        // transactions whose scripts are not verified again, a cache shared by all Miners if nil
+	Coins                CoinView            // outputs of mined blocks that spends are checked against, prevouts are trusted if nil
+	CoinbaseScript       []byte              // scriptSig of the coinbase input, CoinbaseScriptSig(Height, Extranonce, CoinbaseTag) if nil
+	CoinbaseTag          []byte              // miner tag pushed after the extranonce in the default coinbase scriptSig
+	Extranonce           uint64              // extranonce pushed by the default coinbase scriptSig
+	WitnessReservedValue [32]byte            // coinbase witness, committed to with the witness root; zero by convention
+	PayoutScript         []byte              // scriptPubKey of the coinbase output, empty if nil
+	Now                  func() time.Time    // timestamp source for the header, time.Now if nil
+	MedianTimePast       uint32              // median timestamp of the previous blocks, see block.MedianTimePast; the header is later
+	Workers              int                 // goroutines of the default CPUSolver, runtime.NumCPU() if zero
+	Solver               PowSolver           // proof-of-work backend, CPUSolver if nil
+	Selector             Selector            // selection strategy, AncestorSelector if nil
+	Include              []string            // txids placed first in the block with their ancestors, whatever their fee rate
+	Exclude              []string            // txids never selected
+	OnlyTypes            []script.ScriptType // if set, the only script types transactions may spend and create
+	ExcludeTypes         []script.ScriptType // script types transactions may neither spend nor create
+	Progress             func(MiningProgress)
+	Reject               func(transaction tx.Transaction, reason error) // called for every candidate dropped by validation
+	Assembled            func(template Result)                          // called by BuildAndMine before the proof-of-work search
+}
+
+// Miner builds block templates from candidate transactions and mines them
+type Miner struct {
+	options Options
+}
+
+// Result describes a mined block. When BuildAndMine fails, the fields filled in
+// before the failure are still set, so partial statistics can be reported.
+type Result struct {
+	Block          block.Block
+	Hash           [32]byte
+	Fees           int64         // total fees of the selected transactions
+	Weight         int           // total weight of the selected transactions
+	SigOps         int           // total signature operation cost of the selected transactions
+	Rejected       int           // candidates that failed validation
+	Hashes         uint64        // header hashes computed by the proof-of-work search
+	BestHash       [32]byte      // lowest header hash of the search, reported when no block is found
+	BestNonce      uint32        // nonce of BestHash
+	CoinbaseBranch []merkle.Hash // merkle branch of the coinbase, for updating the root when only the coinbase changes
+	WeightLimit    int           // weight shared by the coinbase and the selected transactions, Options.MaxWeight
+	CoinbaseWeight int           // weight of the coinbase, set aside before selection
+	MarginalTxid   string        // best-paying candidate left out with all its parents selected, empty if none
+	MarginalRate   float64       // fee rate of MarginalTxid in sat/vB
+	SelectionTime  time.Duration // time spent validating and selecting transactions
+	MiningTime     time.Duration // time spent in the proof-of-work search
+}
+
+// New creates a Miner, filling in defaults for unset options
+func New(options Options) *Miner {
+	if options.Params == nil {
+		options.Params = &chaincfg.MainNetParams
+	}
+	if options.Target == ([32]byte{}) {
+		options.Target = options.Params.DefaultTarget
+	}
+	if options.ScriptFlags == 0 {
+		options.ScriptFlags = script.StandardFlags
+	}
+	if options.SigCache == nil {
+		options.SigCache = defaultSigCache
+	}
+	if options.ScriptCache == nil {
+		options.ScriptCache = defaultScriptCache
+	}
+	if options.MaxWeight == 0 {
+		options.MaxWeight = options.Params.MaxWeight
+	}
+	if options.Version == 0 {
+		options.Version = chaincfg.VersionBitsTopBits
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.Workers == 0 {
+		options.Workers = runtime.NumCPU()
+	}
+	if options.Solver == nil {
+		options.Solver = CPUSolver{Workers: options.Workers}
+	}
+	if options.Selector == nil {
+		options.Selector = AncestorSelector{}
+	}
+	return &Miner{options: options}
+}
+
+// BuildAndMine builds a block template from txs and searches for its proof of work
+func (m *Miner) BuildAndMine(ctx context.Context, txs []tx.Transaction) (Result, error) {
+	result, err := m.BuildTemplate(ctx, txs)
+	if err != nil {
+		return result, err
+	}
+
+	if m.options.Assembled != nil {
+		m.options.Assembled(result)
+	}
+
+	// Search for a nonce that satisfies the difficulty target
+	start := time.Now()
+	progress := func(p MiningProgress) {
+		result.Hashes = p.Hashes
+		result.BestHash, result.BestNonce = p.BestHash, p.BestNonce
+		if m.options.Progress != nil {
+			m.options.Progress(p)
+		}
+	}
+	result.Hash, err = m.options.Solver.Solve(ctx, &result.Block.Header, m.options.Target, progress)
+	result.MiningTime = time.Since(start)
+	return result, err
+}
+
+// Errors of updating the coinbase of a template
+var (
+	ErrFixedCoinbaseScript = errors.New("cannot roll the extranonce of a fixed coinbase script")
+	errNoCoinbase          = errors.New("template has no coinbase")
+)
+
+// SetCoinbase replaces the coinbase of the block template, updating the
+// merkle root from CoinbaseBranch rather than rehashing every txid. The
+// caller keeps the coinbase valid, e.g. its scriptSig within limits and its
+// witness commitment unchanged.
+func (r *Result) SetCoinbase(coinbase tx.Transaction) error {
+	if len(r.Block.Transactions) == 0 {
+		return errNoCoinbase
+	}
+	txid, err := tx.Txid(coinbase)
+	if err != nil {
+		return err
+	}
+	hash, err := merkle.ParseHash(txid)
+	if err != nil {
+		return err
+	}
+	// A copy, so blocks sharing the transaction list keep their coinbase
+	r.Block.Transactions = append([]tx.Transaction{coinbase}, r.Block.Transactions[1:]...)
+	r.Block.Header.MerkleRoot = merkle.ProofRoot(hash, r.CoinbaseBranch, 0)
+	return nil
+}
+
+// RollExtranonce sets the extranonce pushed by the coinbase scriptSig of the
+// template, giving a new merkle root and so a fresh nonce space. It fails if
+// the Miner has a fixed CoinbaseScript.
+func (m *Miner) RollExtranonce(r *Result, extranonce uint64) error {
+	if m.options.CoinbaseScript != nil {
+		return ErrFixedCoinbaseScript
+	}
+	if len(r.Block.Transactions) == 0 {
+		return errNoCoinbase
+	}
+	scriptSig := CoinbaseScriptSig(m.options.Height, extranonce, m.options.CoinbaseTag)
+	if err := CheckCoinbaseScript(scriptSig); err != nil {
+		return err
+	}
+	coinbase := r.Block.Transactions[0]
+	coinbase.Vin = append([]tx.TxInput(nil), coinbase.Vin...)
+	coinbase.Vin[0].ScriptSig = scriptSig
+	return r.SetCoinbase(coinbase)
+}
+
+// setOf returns the set of the given strings
+func setOf(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// reject logs a transaction dropped by validation and reports it to the
+// Reject option
+func (m *Miner) reject(transaction tx.Transaction, reason error) {
+	reason = txerror.Transaction(transaction, reason)
+	logInvalid(transaction, reason)
+	if m.options.Reject != nil {
+		m.options.Reject(transaction, reason)
+	}
+}
+
+// reportDropped reports the valid transactions that BuildCandidates dropped
+// because they spend a rejected parent
+func (m *Miner) reportDropped(valid []txpool.Entry, candidates []Candidate) {
+	kept := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		kept[candidate.Txid] = true
+	}
+	for _, entry := range valid {
+		if !kept[entry.Txid] {
+			m.options.Reject(entry.Tx, txerror.Transaction(entry.Tx, errInvalidParent))
+		}
+	}
+}
+
+// ErrTimestampTooNew is returned when the median time past is so far ahead
+// of the clock that any valid header timestamp would be rejected as too far
+// in the future
+var ErrTimestampTooNew = errors.New("median time past is too far ahead of the clock")
+
+// headerTimestamp returns the timestamp of a new header: the current time, or
+// just after the median time past if the clock is behind it
+func (m *Miner) headerTimestamp() (uint32, error) {
+	now := uint32(m.options.Now().Unix())
+	if now > m.options.MedianTimePast {
+		return now, nil
+	}
+	timestamp := m.options.MedianTimePast + 1
+	if uint64(timestamp) > uint64(now)+block.MaxFutureBlockTime {
+		return 0, fmt.Errorf("%w: median time past %d, clock %d", ErrTimestampTooNew, m.options.MedianTimePast, now)
+	}
+	return timestamp, nil
+}
+
+// BuildTemplate selects transactions from txs and assembles an unmined block
+// with a coinbase transaction in front. If txs is not empty but every one of
+// them is rejected, the empty block is returned with ErrNoValidTransactions.
+func (m *Miner) BuildTemplate(ctx context.Context, txs []tx.Transaction) (Result, error) {
+	var result Result
+	if err := m.options.Params.CheckBlockVersion(m.options.Version); err != nil {
+		return result, err
+	}
+
+	// Validate each transaction, then let the selector choose what fits in the
+	// weight limit. The txids, fees and weights every stage needs are computed
+	// once, up front.
+	start := time.Now()
+	entries := txpool.NewEntries(txs, m.reject)
+	unfiltered := resolvePrevouts(entries, entries, m.reject)
+	if m.options.Coins != nil {
+		unfiltered = withKnownCoins(unfiltered, m.options.Coins, m.options.Height, m.options.Params.CoinbaseMaturity, m.reject)
+	}
+	if len(m.options.Exclude) > 0 {
+		unfiltered = withoutExcluded(unfiltered, setOf(m.options.Exclude), m.reject)
+	}
+	if len(m.options.OnlyTypes) > 0 || len(m.options.ExcludeTypes) > 0 {
+		unfiltered = withScriptTypes(unfiltered, m.options.OnlyTypes, m.options.ExcludeTypes, m.reject)
+	}
+	validTransactions, err := selectTransactions(ctx, unfiltered, m.options.MinFeeRate, m.options.ScriptFlags, m.options.SigCache, m.options.ScriptCache, m.reject)
+	if err != nil {
+		return result, err
+	}
+	validTransactions = slices.DeleteFunc(validTransactions, func(entry txpool.Entry) bool {
+		if addressesMatch(m.options.Params, entry.Tx) {
+			return false
+		}
+		m.reject(entry.Tx, fmt.Errorf("scriptpubkey_address does not match the script on %s", m.options.Params.Name))
+		return true
+	})
+	validTransactions, conflicts := ResolveConflicts(validTransactions)
+	for _, conflict := range conflicts {
+		slog.Info("dropped conflicting transaction", "txid", conflict.DisplacedTxid,
+			"replacement", conflict.ReplacementTxid, "outpoint", conflict.Outpoint)
+		if m.options.Reject != nil {
+			m.options.Reject(conflict.Displaced, txerror.Transaction(conflict.Displaced, fmt.Errorf("spends %s, as %s which pays a higher fee rate", conflict.Outpoint, conflict.ReplacementTxid)))
+		}
+	}
+	candidates := BuildCandidates(validTransactions, entries)
+	if m.options.Reject != nil {
+		m.reportDropped(validTransactions, candidates)
+	}
+	result.Rejected = len(txs) - len(candidates)
+	// Create a coinbase transaction
+	coinbaseTx := block.CreateCoinbaseTransaction()
+	scriptSig := m.options.CoinbaseScript
+	if scriptSig == nil {
+		scriptSig = CoinbaseScriptSig(m.options.Height, m.options.Extranonce, m.options.CoinbaseTag)
+	}
+	if err := CheckCoinbaseScript(scriptSig); err != nil {
+		return result, err
+	}
+	coinbaseTx.Vin[0].ScriptSig = scriptSig
+	if m.options.PayoutScript != nil {
+		coinbaseTx.Vout[0].ScriptPubKey = m.options.PayoutScript
+		coinbaseTx.Vout[0].ScriptPubKeyType = script.ClassifyScript(m.options.PayoutScript).String()
+		coinbaseTx.Vout[0].ScriptPubKeyAddr = address.FromScript(m.options.Params, m.options.PayoutScript)
+	}
+
+	// Commit to the witnesses (BIP141). The witness reserved value is the
+	// coinbase witness; the commitment is filled in once the block is chosen.
+	reserved := m.options.WitnessReservedValue
+	coinbaseTx.Vin[0].Witness = []tx.HexBytes{reserved[:]}
+	coinbaseTx.Vout = append(coinbaseTx.Vout, commitmentOutput(merkle.Hash{}))
+
+	// The coinbase takes its share of the weight limit before any transaction is selected
+	coinbaseWeight, err := tx.Weight(coinbaseTx)
+	if err != nil {
+		return result, err
+	}
+	maxWeight := m.options.MaxWeight - coinbaseWeight
+	coinbaseSigOps := script.TransactionSigOpCost(coinbaseTx)
+	var selectedTransactions []tx.Transaction
+	var selectedTxids, selectedWtxids []string
+	var selected []int
+	if len(m.options.Include) > 0 {
+		// The included transactions go first, the selector fills the rest of the block
+		forced, forcedWeight, rest, restIndex := prioritize(candidates, setOf(m.options.Include), maxWeight)
+		selected = forced
+		for _, i := range m.options.Selector.Select(rest, maxWeight-forcedWeight) {
+			selected = append(selected, restIndex[i])
+		}
+	} else {
+		selected = m.options.Selector.Select(candidates, maxWeight)
+	}
+	selected = limitSigOps(candidates, selected, block.MaxBlockSigOpsCost-coinbaseSigOps)
+	for _, i := range selected {
+		selectedTransactions = append(selectedTransactions, candidates[i].Tx)
+		selectedTxids = append(selectedTxids, candidates[i].Txid)
+		selectedWtxids = append(selectedWtxids, candidates[i].Wtxid)
+		result.Weight += candidates[i].Weight
+		result.Fees += candidates[i].Fee
+		result.SigOps += candidates[i].SigOps
+	}
+	result.WeightLimit, result.CoinbaseWeight = m.options.MaxWeight, coinbaseWeight
+	result.MarginalTxid, result.MarginalRate = marginalCandidate(candidates, selected)
+	result.SelectionTime = time.Since(start)
+
+	// The coinbase claims the subsidy and every fee; its value has a fixed size,
+	// so setting it after selection leaves the weight unchanged
+	coinbaseTx.Vout[0].Value = chaincfg.BlockSubsidy(m.options.Height, m.options.Params) + result.Fees
+
+	wtxids := []merkle.Hash{{}}
+	for _, wtxid := range selectedWtxids {
+		hash, err := merkle.ParseHash(wtxid)
+		if err != nil {
+			return result, err
+		}
+		wtxids = append(wtxids, hash)
+	}
+	commitment := merkle.WitnessCommitment(merkle.WitnessRoot(wtxids), m.options.WitnessReservedValue)
+	coinbaseTx.Vout[len(coinbaseTx.Vout)-1] = commitmentOutput(commitment)
+
+	// Ensure that the coinbase transaction is the first transaction in the block
+	blockTransactions := append([]tx.Transaction{coinbaseTx}, selectedTransactions...)
+
+	// Create a block
+	newBlock := block.Block{
+		Size:             0, // Calculate block size later
+		Header:           block.Header{},
+		TransactionCount: uint64(len(blockTransactions)),
+		Transactions:     blockTransactions,
+	}
+
+	// Set block header fields
+	newBlock.Header.Version = m.options.Version
+	newBlock.Header.PreviousBlockHash = m.options.PrevBlockHash
+	if newBlock.Header.Timestamp, err = m.headerTimestamp(); err != nil {
+		return result, err
+	}
+	newBlock.Header.Bits = block.TargetToCompact(m.options.Target)
+	newBlock.Header.Nonce = 0
+
+	// Commit to the transactions, coinbase first
+	coinbaseTxid, err := tx.Txid(coinbaseTx)
+	if err != nil {
+		return result, err
+	}
+	txids := make([]merkle.Hash, 0, len(blockTransactions))
+	for _, txid := range append([]string{coinbaseTxid}, selectedTxids...) {
+		hash, err := merkle.ParseHash(txid)
+		if err != nil {
+			return result, err
+		}
+		txids = append(txids, hash)
+	}
+	result.CoinbaseBranch = merkle.CoinbaseBranch(txids)
+	newBlock.Header.MerkleRoot = merkle.ProofRoot(txids[0], result.CoinbaseBranch, 0)
+
+	// Calculate block size (excluding block size field itself)
+	blockSize := uint64(len(block.SerializeHeader(newBlock.Header)) + 8) // 8 bytes for transaction counter
+	for _, transaction := range newBlock.Transactions {
+		blockSize += uint64(unsafe.Sizeof(transaction)) // Add size of each transaction
+	}
+	newBlock.Size = blockSize
+	result.Block = newBlock
+	if len(candidates) == 0 && len(txs) > 0 {
+		return result, fmt.Errorf("%w: all %d rejected", ErrNoValidTransactions, len(txs))
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chain"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// spendJSON is mempool transaction f615cdc4…, a P2WPKH spend
+const spendJSON = `{"version": 1, "locktime": 0, "vin": [{"txid": "9fdc20cdda2b7c68d54e6849dba6c0e4dfa6fd96583ee8c7bbc92c6a41041ee6", "vout": 0, "prevout": {"scriptpubkey": "001418b87714d53f15850a030a13ac61de8c0b1e2416", "scriptpubkey_asm": "OP_0 OP_PUSHBYTES_20 18b87714d53f15850a030a13ac61de8c0b1e2416", "scriptpubkey_type": "v0_p2wpkh", "scriptpubkey_address": "bc1qrzu8w9x48u2c2zsrpgf6ccw73s93ufqkk40np6", "value": 69410}, "scriptsig": "", "scriptsig_asm": "", "witness": ["30440220532c2c26abb3fc10fe9c41ff2790c2536d622d5d077337d53a19f90fabac05d202206e57ecde0fffa9b27c55263906ab0363f9d86f9d417ea651f186e266cd673ba601", "031f2de1fd3ee1e61372e8cf58e27b2b88d2cefaa6c3fb16ae8679a60901db396e"], "is_coinbase": false, "sequence": 0}], "vout": [{"scriptpubkey": "a9141741054896848103389bc291d3959c0c79de8bb487", "scriptpubkey_asm": "OP_HASH160 OP_PUSHBYTES_20 1741054896848103389bc291d3959c0c79de8bb4 OP_EQUAL", "scriptpubkey_type": "p2sh", "scriptpubkey_address": "33oyMfkum1r8gTm5EMWkRAdQUV3gLVHqk2", "value": 66413}]}`
+
+// parseTransaction decodes a mempool JSON transaction
+func parseTransaction(t *testing.T, data string) tx.Transaction {
+	t.Helper()
+	var transaction tx.Transaction
+	if err := json.Unmarshal([]byte(data), &transaction); err != nil {
+		t.Fatal(err)
+	}
+	return transaction
+}
+
+// A witness-malleated copy read first must not keep the valid variant of the
+// same txid out of the block
+func TestBuildTemplateWitnessVariants(t *testing.T) {
+	valid := parseTransaction(t, spendJSON)
+	malleated := parseTransaction(t, strings.Replace(spendJSON, "673ba601", "673ba701", 1))
+	wtxid, err := tx.Wtxid(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		txs  []tx.Transaction
+	}{
+		{"invalid variant first", []tx.Transaction{malleated, valid}},
+		{"invalid variant last", []tx.Transaction{valid, malleated}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New(Options{Now: func() time.Time { return time.Unix(1700000000, 0) }})
+			result, err := m.BuildTemplate(context.Background(), test.txs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(result.Block.Transactions); got != 2 {
+				t.Fatalf("block has %d transactions, want the coinbase and the valid variant", got)
+			}
+			if got, _ := tx.Wtxid(result.Block.Transactions[1]); got != wtxid {
+				t.Errorf("mined wtxid %s, want %s", got, wtxid)
+			}
+			if result.Rejected != 1 {
+				t.Errorf("rejected %d, want the invalid variant", result.Rejected)
+			}
+		})
+	}
+}
+
+// TestBuildTemplateVersion checks templates signal version bits by default
+func TestBuildTemplateVersion(t *testing.T) {
+	template, err := New(Options{}).BuildTemplate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := template.Block.Header.Version; version != chaincfg.VersionBitsTopBits {
+		t.Errorf("default version %#08x, want %#08x", version, chaincfg.VersionBitsTopBits)
+	}
+}
+
+// A clock behind the median time past moves the header just after it, unless
+// that is over two hours ahead
+func TestBuildTemplateTimestamp(t *testing.T) {
+	tests := []struct {
+		now            int64
+		medianTimePast uint32
+		want           uint32
+		err            error
+	}{
+		{1000, 1000, 1001, nil},
+		{2000, 1000, 2000, nil},
+		{1000, 1000 + block.MaxFutureBlockTime, 0, ErrTimestampTooNew},
+	}
+	for _, test := range tests {
+		m := New(Options{Now: func() time.Time { return time.Unix(test.now, 0) }, MedianTimePast: test.medianTimePast})
+		template, err := m.BuildTemplate(context.Background(), nil)
+		if !errors.Is(err, test.err) {
+			t.Errorf("clock %d, median time past %d: got %v, want %v", test.now, test.medianTimePast, err, test.err)
+			continue
+		}
+		if err == nil && template.Block.Header.Timestamp != test.want {
+			t.Errorf("clock %d, median time past %d: timestamp %d, want %d", test.now, test.medianTimePast, template.Block.Header.Timestamp, test.want)
+		}
+	}
+}
+
+// TestBuildTemplateStaticTip builds on block 839999, the parent of the fourth
+// halving block
+func TestBuildTemplateStaticTip(t *testing.T) {
+	const parent = "0000000000000000000320283a032748cef8227873ff4872689bf23f1cda83a5"
+	hash, err := merkle.ParseHash(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip, err := chain.StaticTip{PrevBlockHash: hash, Height: 840000}.Tip(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := New(Options{PrevBlockHash: tip.PrevBlockHash, Height: tip.Height}).BuildTemplate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := block.HashToString(template.Block.Header.PreviousBlockHash); got != parent {
+		t.Errorf("previous block hash %s, want %s", got, parent)
+	}
+	if value := template.Block.Transactions[0].Vout[0].Value; value != 3.125e8 {
+		t.Errorf("coinbase value %d at the halving, want 312500000", value)
+	}
+}
@@ -0,0 +1,249 @@
+package miner
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+)
+
+// ParseTarget decodes a big-endian hex difficulty target
+func ParseTarget(targetHex string) ([32]byte, error) {
+	var target [32]byte
+	decoded, err := hex.DecodeString(targetHex)
+	if err != nil {
+		return target, err
+	}
+	if len(decoded) != 32 {
+		return target, fmt.Errorf("invalid target length %d", len(decoded))
+	}
+	copy(target[:], decoded)
+	return target, nil
+}
+
+// HashMeetsTarget reports whether a block hash is below the big-endian target.
+// The hash is compared as a little-endian number, so it is read from the end.
+func HashMeetsTarget(hash [32]byte, target [32]byte) bool {
+	for i := 0; i < len(target); i++ {
+		if b := hash[len(hash)-1-i]; b != target[i] {
+			return b < target[i]
+		}
+	}
+	return false
+}
+
+// Errors of a search that ends without a block, both ErrTargetNotMet
+var (
+	ErrNonceRangeExhausted = fmt.Errorf("%w: nonce range exhausted", ErrTargetNotMet)
+	ErrLimitReached        = fmt.Errorf("%w: search limit reached", ErrTargetNotMet) // hash or time limit of a CPUSolver
+)
+
+// worstHash is above every header hash, the starting point when tracking the best one
+var worstHash = func() (hash [32]byte) {
+	for i := range hash {
+		hash[i] = 0xff
+	}
+	return hash
+}()
+
+// MiningProgress is a snapshot of a running proof-of-work search
+type MiningProgress struct {
+	FirstNonce uint32
+	LastNonce  uint32
+	Hashes     uint64
+	Elapsed    time.Duration
+	HashRate   float64       // hashes per second
+	ETA        time.Duration // expected time left, -1 when it cannot be estimated
+	BestHash   [32]byte      // lowest header hash seen so far
+	BestNonce  uint32        // nonce of BestHash
+}
+
+// progressInterval is how often MineBlock reports its progress
+const progressInterval = 500 * time.Millisecond
+
+// hashBatch is how many hashes a worker computes between checks for cancellation
+const hashBatch = 4096
+
+// headerMidstateSize is the part of the header hashed once per search: the
+// first SHA256 block, which ends inside the merkle root, before the nonce
+const headerMidstateSize = 64
+
+// expectedHashes returns the average number of hashes needed to find a hash below target
+func expectedHashes(target [32]byte) float64 {
+	work := new(big.Int).Lsh(big.NewInt(1), 256)
+	work.Div(work, new(big.Int).Add(new(big.Int).SetBytes(target[:]), big.NewInt(1)))
+	expected, _ := new(big.Float).SetInt(work).Float64()
+	return expected
+}
+
+// MineBlock searches for a nonce that brings the header hash below the target,
+// starting at the header's current nonce. The nonce space is interleaved across
+// workers goroutines; with a single worker the lowest valid nonce is found.
+// The header nonce is updated in place; report, if not nil, is called periodically
+// and once more when the search ends. The search stops early when ctx is cancelled.
+func MineBlock(ctx context.Context, header *block.Header, target [32]byte, workers int, report func(MiningProgress)) ([32]byte, error) {
+	return search(ctx, header, target, searchLimits{workers: workers, lastNonce: 0xFFFFFFFF}, report)
+}
+
+// searchLimits bounds a proof-of-work search
+type searchLimits struct {
+	workers   int
+	lastNonce uint32        // last nonce tried
+	maxHashes uint64        // stop after about this many hashes, no limit if zero
+	maxTime   time.Duration // stop after this long, no limit if zero
+}
+
+// hashLess reports whether hash a is below hash b, both little-endian numbers
+func hashLess(a, b [32]byte) bool {
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// search is MineBlock within limits. Workers count their hashes in batches, so
+// a hash limit can be overshot by up to a batch per worker.
+func search(ctx context.Context, header *block.Header, target [32]byte, limits searchLimits, report func(MiningProgress)) ([32]byte, error) {
+	workers := max(limits.workers, 1)
+	start := time.Now()
+	expected := expectedHashes(target)
+	firstNonce := header.Nonce
+	lastNonce := limits.lastNonce
+
+	var hashes atomic.Uint64
+	var bestMu sync.Mutex
+	best := MiningProgress{BestHash: worstHash}
+	offerBest := func(hash [32]byte, nonce uint32) {
+		bestMu.Lock()
+		if hashLess(hash, best.BestHash) {
+			best.BestHash, best.BestNonce = hash, nonce
+		}
+		bestMu.Unlock()
+	}
+	progress := func() MiningProgress {
+		elapsed := time.Since(start)
+		done := hashes.Load()
+		p := MiningProgress{
+			FirstNonce: firstNonce,
+			LastNonce:  uint32(min(uint64(firstNonce)+done, uint64(lastNonce)+1) - 1),
+			Hashes:     done,
+			Elapsed:    elapsed,
+			ETA:        -1,
+		}
+		bestMu.Lock()
+		p.BestHash, p.BestNonce = best.BestHash, best.BestNonce
+		bestMu.Unlock()
+		if elapsed > 0 {
+			p.HashRate = float64(done) / elapsed.Seconds()
+		}
+		if p.HashRate > 0 && float64(done) < expected {
+			p.ETA = time.Duration((expected - float64(done)) / p.HashRate * float64(time.Second))
+		}
+		return p
+	}
+
+	type solution struct {
+		nonce uint32
+		hash  [32]byte
+	}
+	solutions := make(chan solution, workers)
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if limits.maxTime > 0 {
+		var cancelTimeout context.CancelFunc
+		searchCtx, cancelTimeout = context.WithTimeout(searchCtx, limits.maxTime)
+		defer cancelTimeout()
+	}
+	var hashLimitReached atomic.Bool
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(offset uint64) {
+			defer wg.Done()
+			// Only the nonce changes between attempts, so the header is
+			// serialized once and its last four bytes overwritten in place.
+			// The first 64 bytes, which stay the same, are hashed only once.
+			var serialized [block.HeaderSize]byte
+			block.AppendHeader(serialized[:0], *header)
+			nonceBytes := serialized[block.NonceOffset:]
+			midstate := hashutil.NewMidstate(serialized[:headerMidstateSize])
+			pending := uint64(0)
+			localBest, localBestNonce := worstHash, uint32(0)
+			defer func() { offerBest(localBest, localBestNonce) }()
+			for nonce := uint64(firstNonce) + offset; nonce <= uint64(lastNonce); nonce += uint64(workers) {
+				binary.LittleEndian.PutUint32(nonceBytes, uint32(nonce))
+				hash := midstate.Hash256(serialized[headerMidstateSize:])
+				pending++
+				if hashLess(hash, localBest) {
+					localBest, localBestNonce = hash, uint32(nonce)
+				}
+				if HashMeetsTarget(hash, target) {
+					hashes.Add(pending)
+					solutions <- solution{uint32(nonce), hash}
+					cancel()
+					return
+				}
+				if pending == hashBatch {
+					done := hashes.Add(pending)
+					pending = 0
+					if limits.maxHashes > 0 && done >= limits.maxHashes {
+						hashLimitReached.Store(true)
+						cancel()
+					}
+					if searchCtx.Err() != nil {
+						return
+					}
+				}
+			}
+			hashes.Add(pending)
+		}(uint64(worker))
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if report != nil {
+				report(progress())
+			}
+		case <-finished:
+			final := progress()
+			if report != nil {
+				report(final)
+			}
+			select {
+			case found := <-solutions:
+				header.Nonce = found.nonce
+				return found.hash, nil
+			default:
+			}
+			header.Nonce = final.LastNonce
+			if err := ctx.Err(); err != nil {
+				return [32]byte{}, fmt.Errorf("mining stopped after %d hashes: %w", final.Hashes, err)
+			}
+			if hashLimitReached.Load() {
+				return [32]byte{}, fmt.Errorf("%w: %d hashes", ErrLimitReached, final.Hashes)
+			}
+			if searchCtx.Err() != nil {
+				return [32]byte{}, fmt.Errorf("%w: %v after %d hashes", ErrLimitReached, limits.maxTime, final.Hashes)
+			}
+			return [32]byte{}, fmt.Errorf("%w after %d hashes", ErrNonceRangeExhausted, final.Hashes)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// resolvePrevouts sets the prevout of every input spending an output of a
+// transaction in all to that output, rather than trusting the prevout
+// field of the JSON. A transaction whose prevout field contradicts its parent,
+// or that spends an output its parent does not have, is reported to reject.
+// An empty prevout field is filled in without complaint.
+func resolvePrevouts(transactions, all []txpool.Entry, reject func(tx.Transaction, error)) []txpool.Entry {
+	parents := make(map[string]tx.Transaction, len(all))
+	for _, entry := range all {
+		parents[entry.Txid] = entry.Tx
+	}
+
+	kept := make([]txpool.Entry, 0, len(transactions))
+	for _, entry := range transactions {
+		transaction := entry.Tx
+		var reason error
+		var vin []tx.TxInput // copied before the first change, the caller's inputs are shared
+		for i, input := range transaction.Vin {
+			parent, ok := parents[input.Txid]
+			if !ok {
+				continue
+			}
+			if input.Vout < 0 || input.Vout >= len(parent.Vout) {
+				reason = txerror.Input(i, fmt.Errorf("spends %s:%d, which its mempool parent does not have", input.Txid, input.Vout))
+				break
+			}
+			output := parent.Vout[input.Vout]
+			resolved := tx.Prevout{
+				ScriptPubKey:     output.ScriptPubKey,
+				ScriptPubKeyASM:  output.ScriptPubKeyASM,
+				ScriptPubKeyType: output.ScriptPubKeyType,
+				ScriptPubKeyAddr: output.ScriptPubKeyAddr,
+				Value:            output.Value,
+			}
+			if prevoutsEqual(input.PrevOut, resolved) {
+				continue
+			}
+			if !prevoutsEqual(input.PrevOut, tx.Prevout{}) && (!bytes.Equal(input.PrevOut.ScriptPubKey, output.ScriptPubKey) || input.PrevOut.Value != output.Value) {
+				reason = txerror.Input(i, fmt.Errorf("prevout does not match output %s:%d of its mempool parent", input.Txid, input.Vout))
+				break
+			}
+			if vin == nil {
+				vin = append([]tx.TxInput(nil), transaction.Vin...)
+			}
+			vin[i].PrevOut = resolved
+		}
+		if reason != nil {
+			reject(transaction, reason)
+			continue
+		}
+		if vin != nil {
+			// The prevouts are not serialized, so only the fee changes
+			entry.Tx.Vin = vin
+			entry.Fee = tx.Fee(entry.Tx)
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// prevoutsEqual reports whether a and b describe the same output
+func prevoutsEqual(a, b tx.Prevout) bool {
+	return bytes.Equal(a.ScriptPubKey, b.ScriptPubKey) && a.ScriptPubKeyASM == b.ScriptPubKeyASM &&
+		a.ScriptPubKeyType == b.ScriptPubKeyType && a.ScriptPubKeyAddr == b.ScriptPubKeyAddr && a.Value == b.Value
+}
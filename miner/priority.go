@@ -0,0 +1,75 @@
+package miner
+
+import "log/slog"
+
+// prioritize moves the candidates with a txid in include to the front of the
+// block, preceded by their unselected ancestors, as long as they fit in
+// maxWeight. It returns their indexes in block order, their total weight, and
+// the remaining candidates, with parent indexes renumbered and the already
+// selected parents dropped, for the selector to fill the rest of the block.
+// rest[i] is candidates[restIndex[i]].
+func prioritize(candidates []Candidate, include map[string]bool, maxWeight int) (forced []int, weight int, rest []Candidate, restIndex []int) {
+	placed := make([]bool, len(candidates))
+	found := make(map[string]bool, len(include))
+	for i, candidate := range candidates {
+		if !include[candidate.Txid] {
+			continue
+		}
+		found[candidate.Txid] = true
+
+		// Collect the unplaced ancestors in parents-first order
+		var pkg []int
+		visiting := make(map[int]bool)
+		var visit func(i int)
+		visit = func(i int) {
+			if placed[i] || visiting[i] {
+				return
+			}
+			visiting[i] = true
+			for _, parent := range candidates[i].Parents {
+				visit(parent)
+			}
+			pkg = append(pkg, i)
+		}
+		visit(i)
+
+		pkgWeight := 0
+		for _, j := range pkg {
+			pkgWeight += candidates[j].Weight
+		}
+		if weight+pkgWeight > maxWeight {
+			slog.Warn("included transaction does not fit in the block", "txid", candidate.Txid, "weight", pkgWeight)
+			continue
+		}
+		for _, j := range pkg {
+			placed[j] = true
+		}
+		forced = append(forced, pkg...)
+		weight += pkgWeight
+	}
+	for txid := range include {
+		if !found[txid] {
+			slog.Warn("included transaction is not a valid candidate", "txid", txid)
+		}
+	}
+
+	renumbered := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		if placed[i] {
+			continue
+		}
+		renumbered[i] = len(rest)
+		rest = append(rest, candidate)
+		restIndex = append(restIndex, i)
+	}
+	for i := range rest {
+		var parents []int
+		for _, parent := range rest[i].Parents {
+			if !placed[parent] {
+				parents = append(parents, renumbered[parent])
+			}
+		}
+		rest[i].Parents = parents
+	}
+	return forced, weight, rest, restIndex
+}
@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// DefaultScriptCacheSize is the number of transactions a cache from
+// NewScriptCache remembers when given no size
+const DefaultScriptCacheSize = 50000
+
+// ScriptCache remembers transactions whose input scripts all verified, so
+// validating them again with the same flags skips script evaluation. Entries
+// are keyed by the wtxid, the flags and the prevouts the inputs claim to
+// spend, which unlike in a node are not fixed by the outpoints. It is safe
+// for concurrent use.
+type ScriptCache struct {
+	mu         sync.RWMutex
+	salt       [32]byte
+	entries    map[[32]byte]struct{}
+	maxEntries int
+}
+
+// NewScriptCache creates a cache of at most maxEntries transactions,
+// DefaultScriptCacheSize if maxEntries is 0
+func NewScriptCache(maxEntries int) *ScriptCache {
+	if maxEntries == 0 {
+		maxEntries = DefaultScriptCacheSize
+	}
+	cache := &ScriptCache{entries: make(map[[32]byte]struct{}), maxEntries: maxEntries}
+	rand.Read(cache.salt[:])
+	return cache
+}
+
+// key returns the entry of a transaction verified with flags
+func (c *ScriptCache) key(entry txpool.Entry, flags script.Flags) [32]byte {
+	h := sha256.New()
+	h.Write(c.salt[:])
+	h.Write([]byte(entry.Wtxid))
+	h.Write(binary.LittleEndian.AppendUint32(nil, uint32(flags)))
+	for _, vin := range entry.Tx.Vin {
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(vin.PrevOut.Value)))
+		h.Write(tx.AppendVarInt(nil, uint64(len(vin.PrevOut.ScriptPubKey))))
+		h.Write(vin.PrevOut.ScriptPubKey)
+	}
+	var key [32]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// Contains reports whether the transaction of entry was added as valid under flags
+func (c *ScriptCache) Contains(entry txpool.Entry, flags script.Flags) bool {
+	key := c.key(entry, flags)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+// Add records that the input scripts of the transaction of entry verify under
+// flags. A full cache first evicts an arbitrary entry.
+func (c *ScriptCache) Add(entry txpool.Entry, flags script.Flags) {
+	key := c.key(entry, flags)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+	c.entries[key] = struct{}{}
+}
+
+// Len returns the number of transactions in the cache
+func (c *ScriptCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
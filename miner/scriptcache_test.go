@@ -0,0 +1,65 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+func TestScriptCache(t *testing.T) {
+	newEntry := func(transaction tx.Transaction) txpool.Entry {
+		t.Helper()
+		entry, err := txpool.NewEntry(transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	transaction := fixtureTransaction(1)
+	added := newEntry(transaction)
+	cache := NewScriptCache(2)
+	cache.Add(added, script.StandardFlags)
+
+	// The prevouts are not part of the wtxid but are part of the entry
+	withPrevout := func(change func(*tx.Prevout)) txpool.Entry {
+		changed := transaction
+		changed.Vin = append([]tx.TxInput(nil), transaction.Vin...)
+		change(&changed.Vin[0].PrevOut)
+		return newEntry(changed)
+	}
+	otherWitness := transaction
+	otherWitness.Vin = append([]tx.TxInput(nil), transaction.Vin...)
+	otherWitness.Vin[0].Witness = []tx.HexBytes{{0x01}}
+
+	tests := []struct {
+		name  string
+		entry txpool.Entry
+		flags script.Flags
+		want  bool
+	}{
+		{"added", added, script.StandardFlags, true},
+		{"other flags", added, script.ConsensusFlags, false},
+		{"other prevout value", withPrevout(func(p *tx.Prevout) { p.Value++ }), script.StandardFlags, false},
+		{"other prevout script", withPrevout(func(p *tx.Prevout) { p.ScriptPubKey = []byte{0x51} }), script.StandardFlags, false},
+		{"other prevout address", withPrevout(func(p *tx.Prevout) { p.ScriptPubKeyAddr = "elsewhere" }), script.StandardFlags, true},
+		{"other witness", newEntry(otherWitness), script.StandardFlags, false},
+		{"other transaction", newEntry(fixtureTransaction(2)), script.StandardFlags, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cache.Contains(test.entry, test.flags); got != test.want {
+				t.Errorf("Contains = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// A full cache evicts an entry to make room
+	cache.Add(newEntry(fixtureTransaction(2)), script.StandardFlags)
+	third := newEntry(fixtureTransaction(3))
+	cache.Add(third, script.StandardFlags)
+	if cache.Len() != 2 || !cache.Contains(third, script.StandardFlags) {
+		t.Errorf("full cache holds %d entries after adding a third", cache.Len())
+	}
+}
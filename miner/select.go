@@ -0,0 +1,162 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/secp256k1"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Rejection reasons reported for transactions dropped before selection
+var (
+	errNoFee         = errors.New("inputs do not exceed outputs")
+	errASMMismatch   = errors.New("scriptpubkey_asm does not match the script")
+	errInvalidParent = errors.New("spends an output of a rejected transaction")
+)
+
+// SelectTransactions validates each transaction and returns the ones to include in the block.
+// If ctx is cancelled, the transactions selected so far are returned with ctx's error.
+func SelectTransactions(ctx context.Context, transactions []tx.Transaction) ([]tx.Transaction, error) {
+	valid, err := selectTransactions(ctx, txpool.NewEntries(transactions, logInvalid), 0, script.StandardFlags, defaultSigCache, defaultScriptCache, logInvalid)
+	selected := make([]tx.Transaction, len(valid))
+	for i, entry := range valid {
+		selected[i] = entry.Tx
+	}
+	return selected, err
+}
+
+// The caches shared by every Miner without caches of its own
+var (
+	defaultSigCache    = script.NewSigCache(0)
+	defaultScriptCache = NewScriptCache(0)
+)
+
+// selectTransactions is SelectTransactions dropping transactions paying less
+// than minFeeRate sat/vB before validating them, verifying with flags the
+// scripts of transactions not in scripts and the signatures not in
+// signatures, and calling reject with the reason of every transaction it drops
+func selectTransactions(ctx context.Context, entries []txpool.Entry, minFeeRate float64, flags script.Flags, signatures *script.SigCache, scripts *ScriptCache, reject func(tx.Transaction, error)) ([]txpool.Entry, error) {
+	var validTransactions []txpool.Entry
+	var pending []batchedTransaction
+	queued := 0
+	for _, entry := range entries {
+		transaction := entry.Tx
+		if err := ctx.Err(); err != nil {
+			return append(validTransactions, verifyBatched(pending, flags, signatures, scripts, reject)...), err
+		}
+		if err := tx.CheckDuplicateInputs(transaction); err != nil {
+			reject(transaction, err)
+			continue
+		}
+		if err := tx.CheckValues(transaction); err != nil {
+			reject(transaction, err)
+			continue
+		}
+		if !tx.Validate(transaction) {
+			reject(transaction, errNoFee)
+			continue
+		}
+		if minFeeRate > 0 && entry.FeeRate() < minFeeRate {
+			reject(transaction, fmt.Errorf("fee rate %.2f sat/vB is below the minimum of %.2f", entry.FeeRate(), minFeeRate))
+			continue
+		}
+		if !scriptASMMatches(transaction) {
+			reject(transaction, errASMMismatch)
+			continue
+		}
+		batch := new(secp256k1.SchnorrBatch)
+		if !scripts.Contains(entry, flags) {
+			if err := validateInputs(transaction, flags, batch, signatures); err != nil {
+				reject(transaction, err)
+				continue
+			}
+		}
+		if err := checkSigOps(transaction); err != nil {
+			reject(transaction, err)
+			continue
+		}
+		pending = append(pending, batchedTransaction{entry, batch})
+		if queued += batch.Len(); queued >= schnorrBatchSize {
+			validTransactions = append(validTransactions, verifyBatched(pending, flags, signatures, scripts, reject)...)
+			pending, queued = nil, 0
+		}
+	}
+	return append(validTransactions, verifyBatched(pending, flags, signatures, scripts, reject)...), nil
+}
+
+// schnorrBatchSize is the number of Schnorr signatures verified together
+const schnorrBatchSize = 256
+
+// batchedTransaction is a transaction whose scripts passed but whose Schnorr
+// signatures, queued in batch, are still to be verified
+type batchedTransaction struct {
+	entry txpool.Entry
+	batch *secp256k1.SchnorrBatch
+}
+
+// verifyBatched verifies the queued Schnorr signatures of transactions and
+// returns the ones whose signatures are all valid, adding them to scripts
+// and their signatures to signatures. The signatures of all of them are
+// verified as one batch; only if that fails is each transaction checked on
+// its own, and a failing one validated again without batching to report
+// which input is invalid.
+func verifyBatched(transactions []batchedTransaction, flags script.Flags, signatures *script.SigCache, scripts *ScriptCache, reject func(tx.Transaction, error)) []txpool.Entry {
+	var all secp256k1.SchnorrBatch
+	for _, batched := range transactions {
+		all.Merge(batched.batch)
+	}
+	allValid := all.Verify()
+	valid := make([]txpool.Entry, 0, len(transactions))
+	for _, batched := range transactions {
+		if !allValid && !batched.batch.Verify() {
+			err := validateInputs(batched.entry.Tx, flags, nil, signatures)
+			if err == nil {
+				err = script.ErrSchnorrSig // unreachable unless batching disagrees with VerifySchnorr
+			}
+			reject(batched.entry.Tx, err)
+			continue
+		}
+		batched.batch.Each(func(key *secp256k1.XOnlyPublicKey, msg, sig []byte) {
+			signatures.Add(msg, key.Serialize(), sig)
+		})
+		scripts.Add(batched.entry, flags)
+		valid = append(valid, batched.entry)
+	}
+	return valid
+}
+
+// logInvalid logs a rejected transaction at debug level
+func logInvalid(transaction tx.Transaction, reason error) {
+	slog.Debug("invalid transaction", "err", txerror.Transaction(transaction, reason))
+}
+
+// scriptASMMatches reports whether the scriptpubkey_asm strings of a transaction
+// are the disassembly of the scripts they accompany. A mismatch means the JSON
+// was edited or generated inconsistently, so none of its derived fields can be
+// trusted.
+func scriptASMMatches(transaction tx.Transaction) bool {
+	matches := func(scriptPubKey []byte, asm string) bool {
+		if asm == "" {
+			return true
+		}
+		disasm, err := script.Disasm(scriptPubKey)
+		return err == nil && disasm == asm
+	}
+	for _, vin := range transaction.Vin {
+		if !matches(vin.PrevOut.ScriptPubKey, vin.PrevOut.ScriptPubKeyASM) {
+			return false
+		}
+	}
+	for _, vout := range transaction.Vout {
+		if !matches(vout.ScriptPubKey, vout.ScriptPubKeyASM) {
+			return false
+		}
+	}
+	return true
+}
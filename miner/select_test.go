@@ -0,0 +1,59 @@
+package miner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// fixtureTransaction builds a typical one-input, two-output P2WPKH
+// transaction. The seed varies the txid and output values so fixtures are not
+// identical.
+func fixtureTransaction(seed int) tx.Transaction {
+	txid := sha256.Sum256(binary.LittleEndian.AppendUint32(nil, uint32(seed)))
+	script := func(s string) tx.HexBytes {
+		data, _ := hex.DecodeString(s)
+		return data
+	}
+	return tx.Transaction{
+		Version: 2,
+		Vin: []tx.TxInput{{
+			Txid: hex.EncodeToString(txid[:]),
+			Vout: seed % 4,
+			Witness: []tx.HexBytes{
+				script("3044022100884219ecbb54a6ec4d09597ca6aca49692ded3c2ffb13d1858ca5b70e59fabb4021f2de73021471a01d8f03a71a923b662f00120d181d0f7fa8e06faa1bb750e8f01"),
+				script("0271d4e7a84804c075017593271c370e8983f704f123d22aa747cd321268981cba"),
+			},
+			Sequence: 0xFFFFFFFD,
+			PrevOut: tx.Prevout{
+				ScriptPubKey:     script("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a"),
+				ScriptPubKeyType: "v0_p2wpkh",
+				Value:            100000 + int64(seed),
+			},
+		}},
+		Vout: []tx.TxOutput{
+			{ScriptPubKey: script("a91450feb99697a4901d3fe082eca341204fb6711b9487"), ScriptPubKeyType: "p2sh", Value: 60000},
+			{ScriptPubKey: script("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a"), ScriptPubKeyType: "v0_p2wpkh", Value: 30000 + int64(seed%1000)},
+		},
+	}
+}
+
+// BenchmarkSelectTransactions measures selecting a block from a mempool of 5000 transactions
+func BenchmarkSelectTransactions(b *testing.B) {
+	transactions := make([]tx.Transaction, 5000)
+	for i := range transactions {
+		transactions[i] = fixtureTransaction(i)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SelectTransactions(ctx, transactions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
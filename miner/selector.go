@@ -0,0 +1,279 @@
+package miner
+
+import (
+	"container/heap"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txpool"
+)
+
+// Candidate is a validated transaction offered to a Selector
+type Candidate struct {
+	Tx      tx.Transaction
+	Txid    string
+	Wtxid   string
+	Fee     int64
+	Weight  int
+	SigOps  int   // signature operation cost, see script.TransactionSigOpCost
+	Parents []int // indexes of the candidates whose outputs this transaction spends
+}
+
+// FeeRate returns the candidate's fee per weight unit
+func (c Candidate) FeeRate() float64 {
+	return float64(c.Fee) / float64(c.Weight)
+}
+
+// Selector chooses transactions for a block. Select returns indexes into
+// candidates in block order: every transaction must come after its parents and
+// the total weight must not exceed maxWeight.
+type Selector interface {
+	Select(candidates []Candidate, maxWeight int) []int
+}
+
+// Selectors are the built-in selection strategies by name
+var Selectors = map[string]Selector{
+	"greedy":   GreedySelector{},
+	"ancestor": AncestorSelector{},
+	"bnb":      BranchAndBoundSelector{},
+}
+
+// SelectorByName returns a built-in selection strategy
+func SelectorByName(name string) (Selector, error) {
+	if selector, ok := Selectors[name]; ok {
+		return selector, nil
+	}
+	return nil, fmt.Errorf("unknown selector %q", name)
+}
+
+// BuildCandidates computes the signature operation costs and in-mempool
+// parents of the valid transactions. all is the full mempool the valid
+// transactions came from: a transaction spending an output of a mempool
+// transaction that is not valid itself can never be mined, so it is left out.
+func BuildCandidates(valid []txpool.Entry, all []txpool.Entry) []Candidate {
+	mempoolTxids := make(map[string]bool, len(all))
+	for _, entry := range all {
+		mempoolTxids[entry.Txid] = true
+	}
+
+	candidates := make([]Candidate, 0, len(valid))
+	for _, entry := range valid {
+		candidates = append(candidates, Candidate{
+			Tx: entry.Tx, Txid: entry.Txid, Wtxid: entry.Wtxid, Fee: entry.Fee, Weight: entry.Weight,
+			SigOps: script.TransactionSigOpCost(entry.Tx),
+		})
+	}
+
+	// Resolve parents; dropping a candidate can orphan others, so repeat until stable
+	for {
+		index := make(map[string]int, len(candidates))
+		for i, candidate := range candidates {
+			index[candidate.Txid] = i
+		}
+		kept := candidates[:0]
+		for _, candidate := range candidates {
+			candidate.Parents = nil
+			orphaned := false
+			for _, vin := range candidate.Tx.Vin {
+				if parent, ok := index[vin.Txid]; ok {
+					candidate.Parents = append(candidate.Parents, parent)
+				} else if mempoolTxids[vin.Txid] {
+					orphaned = true
+				}
+			}
+			if orphaned {
+				slog.Debug("transaction spends an invalid mempool transaction", "txid", candidate.Txid)
+				continue
+			}
+			kept = append(kept, candidate)
+		}
+		if len(kept) == len(index) {
+			return kept
+		}
+		candidates = kept
+	}
+}
+
+// GreedySelector picks the transaction with the highest fee rate whose parents are
+// already in the block, skipping transactions that do not fit
+type GreedySelector struct{}
+
+// feeRateHeap is a max-heap of candidate indexes ordered by fee rate
+type feeRateHeap struct {
+	candidates []Candidate
+	indexes    []int
+}
+
+func (h *feeRateHeap) Len() int { return len(h.indexes) }
+func (h *feeRateHeap) Less(i, j int) bool {
+	a, b := h.candidates[h.indexes[i]], h.candidates[h.indexes[j]]
+	if a.FeeRate() != b.FeeRate() {
+		return a.FeeRate() > b.FeeRate()
+	}
+	return h.indexes[i] < h.indexes[j] // keep the order stable between runs
+}
+func (h *feeRateHeap) Swap(i, j int) { h.indexes[i], h.indexes[j] = h.indexes[j], h.indexes[i] }
+func (h *feeRateHeap) Push(x any)    { h.indexes = append(h.indexes, x.(int)) }
+func (h *feeRateHeap) Pop() any {
+	last := h.indexes[len(h.indexes)-1]
+	h.indexes = h.indexes[:len(h.indexes)-1]
+	return last
+}
+
+// Select implements Selector
+func (GreedySelector) Select(candidates []Candidate, maxWeight int) []int {
+	children := make([][]int, len(candidates))
+	pendingParents := make([]int, len(candidates))
+	ready := &feeRateHeap{candidates: candidates}
+	for i, candidate := range candidates {
+		pendingParents[i] = len(candidate.Parents)
+		for _, parent := range candidate.Parents {
+			children[parent] = append(children[parent], i)
+		}
+		if pendingParents[i] == 0 {
+			ready.indexes = append(ready.indexes, i)
+		}
+	}
+	heap.Init(ready)
+
+	var selected []int
+	weight := 0
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		if weight+candidates[i].Weight > maxWeight {
+			continue // its descendants never become ready
+		}
+		selected = append(selected, i)
+		weight += candidates[i].Weight
+		for _, child := range children[i] {
+			if pendingParents[child]--; pendingParents[child] == 0 {
+				heap.Push(ready, child)
+			}
+		}
+	}
+	return selected
+}
+
+// AncestorSelector picks transactions by ancestor-package fee rate, so a
+// high-fee child pays for its low-fee parents (CPFP), similar to Bitcoin Core
+type AncestorSelector struct{}
+
+// Select implements Selector
+func (AncestorSelector) Select(candidates []Candidate, maxWeight int) []int {
+	ancestors := ancestorSets(candidates)
+
+	// Package fee and weight of every candidate with all its unselected ancestors
+	descendants := make([][]int, len(candidates))
+	packageFee := make([]int64, len(candidates))
+	packageWeight := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		packageFee[i] = candidate.Fee
+		packageWeight[i] = candidate.Weight
+		for ancestor := range ancestors[i] {
+			packageFee[i] += candidates[ancestor].Fee
+			packageWeight[i] += candidates[ancestor].Weight
+			descendants[ancestor] = append(descendants[ancestor], i)
+		}
+	}
+
+	done := make([]bool, len(candidates)) // selected or skipped
+	var selected []int
+	weight := 0
+	for {
+		best := -1
+		for i := range candidates {
+			if done[i] {
+				continue
+			}
+			if best < 0 || float64(packageFee[i])/float64(packageWeight[i]) > float64(packageFee[best])/float64(packageWeight[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return selected
+		}
+		if weight+packageWeight[best] > maxWeight {
+			done[best] = true
+			continue
+		}
+
+		// Add the unselected ancestors in topological order, then the transaction itself
+		var pkg []int
+		for ancestor := range ancestors[best] {
+			if !done[ancestor] {
+				pkg = append(pkg, ancestor)
+			}
+		}
+		sort.Slice(pkg, func(a, b int) bool {
+			if len(ancestors[pkg[a]]) != len(ancestors[pkg[b]]) {
+				return len(ancestors[pkg[a]]) < len(ancestors[pkg[b]])
+			}
+			return pkg[a] < pkg[b]
+		})
+		pkg = append(pkg, best)
+
+		for _, i := range pkg {
+			done[i] = true
+			selected = append(selected, i)
+			weight += candidates[i].Weight
+			for _, descendant := range descendants[i] {
+				packageFee[descendant] -= candidates[i].Fee
+				packageWeight[descendant] -= candidates[i].Weight
+			}
+		}
+	}
+}
+
+// ancestorSets returns, for every candidate, the set of all its in-mempool ancestors
+func ancestorSets(candidates []Candidate) []map[int]bool {
+	sets := make([]map[int]bool, len(candidates))
+	var visit func(i int) map[int]bool
+	visit = func(i int) map[int]bool {
+		if sets[i] != nil {
+			return sets[i]
+		}
+		set := make(map[int]bool)
+		sets[i] = set // parents always come from other transactions, so there are no cycles
+		for _, parent := range candidates[i].Parents {
+			set[parent] = true
+			for ancestor := range visit(parent) {
+				set[ancestor] = true
+			}
+		}
+		return set
+	}
+	for i := range candidates {
+		visit(i)
+	}
+	return sets
+}
+
+// marginalCandidate returns the txid and fee rate in sat/vB of the
+// best-paying candidate left out of selected whose parents were all selected:
+// the next transaction the block would have taken with more room
+func marginalCandidate(candidates []Candidate, selected []int) (string, float64) {
+	in := make([]bool, len(candidates))
+	for _, i := range selected {
+		in[i] = true
+	}
+	best := -1
+	for i, c := range candidates {
+		if in[i] || best >= 0 && c.FeeRate() <= candidates[best].FeeRate() {
+			continue
+		}
+		ready := true
+		for _, parent := range c.Parents {
+			ready = ready && in[parent]
+		}
+		if ready {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", 0
+	}
+	return candidates[best].Txid, tx.FeeRate(candidates[best].Tx)
+}
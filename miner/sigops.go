@@ -0,0 +1,45 @@
+package miner
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// MaxStandardTxSigOpsCost is the largest signature operation cost of a
+// transaction nodes relay, a fifth of the block limit
+const MaxStandardTxSigOpsCost = 16000
+
+// checkSigOps rejects transactions above the per-transaction signature operation limit
+func checkSigOps(transaction tx.Transaction) error {
+	if sigOps := script.TransactionSigOpCost(transaction); sigOps > MaxStandardTxSigOpsCost {
+		return fmt.Errorf("signature operation cost %d exceeds %d", sigOps, MaxStandardTxSigOpsCost)
+	}
+	return nil
+}
+
+// limitSigOps drops selected transactions, in block order, whose signature
+// operations would exceed budget, together with every descendant of a dropped
+// transaction. Selectors only budget weight; the signature operation limit
+// rarely binds, so it is enforced afterwards.
+func limitSigOps(candidates []Candidate, selected []int, budget int) []int {
+	dropped := make(map[int]bool)
+	kept := selected[:0:0]
+	sigOps := 0
+	for _, i := range selected {
+		drop := sigOps+candidates[i].SigOps > budget
+		for _, parent := range candidates[i].Parents {
+			drop = drop || dropped[parent]
+		}
+		if drop {
+			slog.Debug("transaction exceeds the block signature operation limit", "txid", candidates[i].Txid)
+			dropped[i] = true
+			continue
+		}
+		sigOps += candidates[i].SigOps
+		kept = append(kept, i)
+	}
+	return kept
+}
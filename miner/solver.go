@@ -0,0 +1,80 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+)
+
+// PowSolver finds the proof of work of an assembled header. Solve updates the
+// header nonce in place and returns the header hash; report, if not nil, receives
+// progress updates during the search.
+type PowSolver interface {
+	Solve(ctx context.Context, header *block.Header, target [32]byte, report func(MiningProgress)) ([32]byte, error)
+}
+
+// NonceRange is an inclusive range of header nonces. The zero value stands for
+// the whole nonce space.
+type NonceRange struct {
+	First, Last uint32
+}
+
+// ShardRange returns the index-th of count contiguous, nearly equal shares of
+// the nonce space, so that count miners can search without overlapping
+func ShardRange(index, count int) (NonceRange, error) {
+	if count < 1 || int64(count) > 1<<32 || index < 0 || index >= count {
+		return NonceRange{}, fmt.Errorf("invalid shard %d of %d", index, count)
+	}
+	size := uint64(1<<32) / uint64(count)
+	first := uint64(index) * size
+	last := first + size - 1
+	if index == count-1 {
+		last = 0xFFFFFFFF
+	}
+	return NonceRange{First: uint32(first), Last: uint32(last)}, nil
+}
+
+// CPUSolver searches the nonce space with goroutines on the local CPU. Without
+// a range it starts at the header's current nonce. When a limit is reached the
+// search fails with ErrLimitReached; the best hash seen is in its last report.
+type CPUSolver struct {
+	Workers   int           // goroutines, at least one is used
+	Range     NonceRange    // nonces to try, the whole nonce space if zero
+	MaxHashes uint64        // give up after about this many hashes, no limit if zero
+	MaxTime   time.Duration // give up after this long, no limit if zero
+}
+
+// Solve implements PowSolver
+func (s CPUSolver) Solve(ctx context.Context, header *block.Header, target [32]byte, report func(MiningProgress)) ([32]byte, error) {
+	limits := searchLimits{workers: s.Workers, lastNonce: 0xFFFFFFFF, maxHashes: s.MaxHashes, maxTime: s.MaxTime}
+	if s.Range != (NonceRange{}) {
+		if s.Range.First > s.Range.Last {
+			return [32]byte{}, fmt.Errorf("empty nonce range %d-%d", s.Range.First, s.Range.Last)
+		}
+		header.Nonce = s.Range.First
+		limits.lastNonce = s.Range.Last
+	}
+	return search(ctx, header, target, limits, report)
+}
+
+// SimulatedSolver pretends to solve the proof of work: it sets a fixed nonce and
+// returns the resulting hash without checking it against the target. It lets
+// tests and dry runs exercise block assembly without spending time hashing.
+type SimulatedSolver struct {
+	Nonce uint32
+}
+
+// Solve implements PowSolver
+func (s SimulatedSolver) Solve(ctx context.Context, header *block.Header, target [32]byte, report func(MiningProgress)) ([32]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return [32]byte{}, err
+	}
+	header.Nonce = s.Nonce
+	hash := block.HashHeader(block.SerializeHeader(*header))
+	if report != nil {
+		report(MiningProgress{FirstNonce: s.Nonce, LastNonce: s.Nonce, Hashes: 1, ETA: -1, BestHash: hash, BestNonce: s.Nonce})
+	}
+	return hash, nil
+}
@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/address"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/secp256k1"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+)
+
+// validateInputs routes every input to the checks of the output type it spends.
+// The type comes from the prevout's scriptPubKey itself, not from the
+// scriptpubkey_type field of the JSON. If batch is not nil, Schnorr
+// signatures are queued in it rather than verified; signatures in cache are
+// not verified again.
+func validateInputs(transaction tx.Transaction, flags script.Flags, batch *secp256k1.SchnorrBatch, cache *script.SigCache) error {
+	checker := script.TxSignatureChecker{Tx: transaction, Batch: batch, Cache: cache, SigHashes: tx.NewSigHashCache(transaction)}
+	for i, vin := range transaction.Vin {
+		scriptPubKey := []byte(vin.PrevOut.ScriptPubKey)
+		if err := checkWitnessProgram(scriptPubKey, vin); err != nil {
+			return txerror.Input(i, fmt.Errorf("%s: %w", script.ClassifyScript(scriptPubKey), err))
+		}
+		if err := validateInput(script.ClassifyScript(scriptPubKey), vin); err != nil {
+			return txerror.Input(i, fmt.Errorf("%s: %w", script.ClassifyScript(scriptPubKey), err))
+		}
+		if err := verifyInputScript(checker, i, scriptPubKey, flags); err != nil {
+			return txerror.Input(i, fmt.Errorf("%s: %w", script.ClassifyScript(scriptPubKey), err))
+		}
+	}
+	return nil
+}
+
+// checkWitnessProgram rejects malformed spends of a witness program before
+// any script runs: the scriptSig of a native witness spend must be empty, a
+// version 0 program must be 20 or 32 bytes and a version 1 program 32 bytes
+func checkWitnessProgram(scriptPubKey []byte, vin tx.TxInput) error {
+	version, program, ok := script.IsWitnessProgram(scriptPubKey)
+	if !ok {
+		return nil
+	}
+	if len(vin.ScriptSig) > 0 {
+		return fmt.Errorf("native witness input with non-empty scriptsig")
+	}
+	switch {
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return fmt.Errorf("version 0 witness program of %d bytes, want 20 or 32", len(program))
+	case version == 1 && len(program) != 32:
+		return fmt.Errorf("version 1 witness program of %d bytes, want 32", len(program))
+	}
+	return nil
+}
+
+// validateInput checks that the scriptSig and witness of an input have the
+// shape required by the output type it spends
+func validateInput(scriptType script.ScriptType, vin tx.TxInput) error {
+	switch scriptType {
+	case script.P2WPKH:
+		if len(vin.Witness) != 2 {
+			return fmt.Errorf("witness has %d items, want signature and public key", len(vin.Witness))
+		}
+	case script.P2WSH, script.P2TR:
+		if len(vin.Witness) == 0 {
+			return fmt.Errorf("empty witness")
+		}
+	case script.P2PKH:
+		if len(vin.Witness) > 0 {
+			return fmt.Errorf("unexpected witness")
+		}
+	case script.P2PK:
+		if len(vin.Witness) > 0 {
+			return fmt.Errorf("unexpected witness")
+		}
+		scriptSig := vin.ScriptSig
+		if pushes, err := script.Instructions(scriptSig); err != nil || len(pushes) != 1 || !script.IsPushOnly(scriptSig) {
+			return fmt.Errorf("scriptsig is not a single signature push")
+		}
+	case script.OpReturnData:
+		return fmt.Errorf("spends an unspendable output")
+	}
+	return nil
+}
+
+// verifyInputScript evaluates the scriptSig and witness of input index
+// against the scriptPubKey it spends under the given rules, checking its
+// signatures with checker, set up for the whole transaction
+func verifyInputScript(checker script.TxSignatureChecker, index int, scriptPubKey []byte, flags script.Flags) error {
+	vin := checker.Tx.Vin[index]
+	witness := make([][]byte, len(vin.Witness))
+	for i, item := range vin.Witness {
+		witness[i] = item
+	}
+	checker.Index, checker.Amount = index, vin.PrevOut.Value
+	return script.VerifyScript(vin.ScriptSig, scriptPubKey, witness, flags, checker)
+}
+
+// addressesMatch reports whether the scriptpubkey_address fields of a transaction
+// are the addresses of their scripts on the given network
+func addressesMatch(params *chaincfg.Params, transaction tx.Transaction) bool {
+	matches := func(scriptPubKey []byte, claimed string) bool {
+		if claimed == "" {
+			return true
+		}
+		return address.FromScript(params, scriptPubKey) == claimed
+	}
+	for _, vin := range transaction.Vin {
+		if !matches(vin.PrevOut.ScriptPubKey, vin.PrevOut.ScriptPubKeyAddr) {
+			return false
+		}
+	}
+	for _, vout := range transaction.Vout {
+		if !matches(vout.ScriptPubKey, vout.ScriptPubKeyAddr) {
+			return false
+		}
+	}
+	return true
+}
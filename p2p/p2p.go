@@ -0,0 +1,235 @@
+// Package p2p speaks just enough of the Bitcoin peer-to-peer protocol to hand
+// a mined block to a node: the version handshake, the block message and a
+// ping to learn when the node has processed it.
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/chaincfg"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+const (
+	// ProtocolVersion is the version announced in the handshake (wtxidrelay era)
+	ProtocolVersion = 70016
+
+	// UserAgent is sent in the version message
+	UserAgent = "/sob-miner:0.1/"
+
+	headerSize     = 24
+	commandSize    = 12
+	maxMessageSize = 32 << 20
+
+	// defaultTimeout bounds the whole exchange when ctx has no deadline
+	defaultTimeout = 30 * time.Second
+)
+
+// Message is one framed peer-to-peer message
+type Message struct {
+	Command string
+	Payload []byte
+}
+
+// WriteMessage frames and writes a message for the network with the given magic
+func WriteMessage(w io.Writer, magic uint32, msg Message) error {
+	if len(msg.Command) > commandSize {
+		return fmt.Errorf("p2p: command %q too long", msg.Command)
+	}
+	frame := make([]byte, headerSize, headerSize+len(msg.Payload))
+	binary.LittleEndian.PutUint32(frame[0:], magic)
+	copy(frame[4:4+commandSize], msg.Command)
+	binary.LittleEndian.PutUint32(frame[16:], uint32(len(msg.Payload)))
+	checksum := hashutil.Hash256(msg.Payload)
+	copy(frame[20:], checksum[:4])
+	_, err := w.Write(append(frame, msg.Payload...))
+	return err
+}
+
+// ReadMessage reads one message, checking its magic, size and checksum
+func ReadMessage(r io.Reader, magic uint32) (Message, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Message{}, err
+	}
+	if got := binary.LittleEndian.Uint32(header[0:]); got != magic {
+		return Message{}, fmt.Errorf("p2p: network magic %#08x, want %#08x", got, magic)
+	}
+	length := binary.LittleEndian.Uint32(header[16:])
+	if length > maxMessageSize {
+		return Message{}, fmt.Errorf("p2p: message of %d bytes exceeds limit", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return Message{}, err
+	}
+	checksum := hashutil.Hash256(payload)
+	if !bytes.Equal(checksum[:4], header[20:24]) {
+		return Message{}, errors.New("p2p: bad message checksum")
+	}
+	return Message{Command: string(bytes.TrimRight(header[4:16], "\x00")), Payload: payload}, nil
+}
+
+// appendNetAddr appends a version-message network address without timestamp
+func appendNetAddr(data []byte, addr *net.TCPAddr) []byte {
+	data = binary.LittleEndian.AppendUint64(data, 0) // services
+	ip := net.IPv6zero
+	port := 0
+	if addr != nil {
+		ip, port = addr.IP.To16(), addr.Port
+	}
+	data = append(data, ip...)
+	return binary.BigEndian.AppendUint16(data, uint16(port))
+}
+
+// versionPayload builds the payload of our version message
+func versionPayload(remote *net.TCPAddr, nonce uint64) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, ProtocolVersion)
+	data = binary.LittleEndian.AppendUint64(data, 0) // services: none, we serve nothing
+	data = binary.LittleEndian.AppendUint64(data, uint64(time.Now().Unix()))
+	data = appendNetAddr(data, remote)
+	data = appendNetAddr(data, nil)
+	data = binary.LittleEndian.AppendUint64(data, nonce)
+	data = tx.AppendVarInt(data, uint64(len(UserAgent)))
+	data = append(data, UserAgent...)
+	data = binary.LittleEndian.AppendUint32(data, 0) // start height
+	return append(data, 0)                           // relay: no transaction announcements
+}
+
+// randomNonce returns a random 64-bit nonce
+func randomNonce() (uint64, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// SubmitBlock connects to the node at address, completes the version
+// handshake, sends the block and waits for the answer to a ping sent after it
+func SubmitBlock(ctx context.Context, address string, params *chaincfg.Params, b block.Block) error {
+	payload, err := block.SerializeBlock(b)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	peer := &peer{conn: conn, magic: params.NetMagic}
+	if err := peer.handshake(); err != nil {
+		return fmt.Errorf("p2p: handshake with %s: %w", address, err)
+	}
+	if err := peer.send("block", payload); err != nil {
+		return err
+	}
+	if err := peer.ping(); err != nil {
+		return fmt.Errorf("p2p: waiting for %s to process the block: %w", address, err)
+	}
+	return nil
+}
+
+// peer is a connection to one node
+type peer struct {
+	conn  net.Conn
+	magic uint32
+}
+
+func (p *peer) send(command string, payload []byte) error {
+	return WriteMessage(p.conn, p.magic, Message{Command: command, Payload: payload})
+}
+
+// receive reads the next message, answering pings on the way
+func (p *peer) receive() (Message, error) {
+	for {
+		msg, err := ReadMessage(p.conn, p.magic)
+		if err != nil {
+			return msg, err
+		}
+		if msg.Command != "ping" {
+			return msg, nil
+		}
+		if err := p.send("pong", msg.Payload); err != nil {
+			return msg, err
+		}
+	}
+}
+
+// handshake exchanges version and verack messages
+func (p *peer) handshake() error {
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+	remote, _ := p.conn.RemoteAddr().(*net.TCPAddr)
+	if err := p.send("version", versionPayload(remote, nonce)); err != nil {
+		return err
+	}
+	gotVersion, gotVerack := false, false
+	for !gotVersion || !gotVerack {
+		msg, err := p.receive()
+		if err != nil {
+			return err
+		}
+		switch msg.Command {
+		case "version":
+			if len(msg.Payload) < 4 {
+				return errors.New("short version message")
+			}
+			if version := binary.LittleEndian.Uint32(msg.Payload); version < 70001 {
+				return fmt.Errorf("peer protocol version %d too old", version)
+			}
+			gotVersion = true
+			if err := p.send("verack", nil); err != nil {
+				return err
+			}
+		case "verack":
+			gotVerack = true
+		}
+	}
+	return nil
+}
+
+// ping sends a ping and waits for the matching pong. Nodes handle messages in
+// order, so the pong means everything sent before it was processed.
+func (p *peer) ping() error {
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+	payload := binary.LittleEndian.AppendUint64(nil, nonce)
+	if err := p.send("ping", payload); err != nil {
+		return err
+	}
+	for {
+		msg, err := p.receive()
+		if err != nil {
+			return err
+		}
+		if msg.Command == "pong" && bytes.Equal(msg.Payload, payload) {
+			return nil
+		}
+	}
+}
@@ -1,2 +1,2 @@
 # Update this file to run your own code
-go run main2.go
\ No newline at end of file
+go run ./cmd/miner
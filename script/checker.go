@@ -0,0 +1,137 @@
+package script
+
+import (
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/secp256k1"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/taggedhash"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TxSignatureChecker checks signatures against input Index of Tx, which
+// spends an output worth Amount satoshis. With a Batch, Schnorr signatures are
+// queued in it instead of verified, and only count as valid once it verifies.
+// With a Cache, signatures found in it are not verified again, and valid ones
+// verified here are added to it. SigHashes, when set, must be the cache of
+// Tx, shared by the checkers of all its inputs.
+type TxSignatureChecker struct {
+	Tx        tx.Transaction
+	Index     int
+	Amount    int64
+	Batch     *secp256k1.SchnorrBatch
+	Cache     *SigCache
+	SigHashes *tx.SigHashCache
+}
+
+// sigHashes returns c.SigHashes, or a cache for this signature only if it is nil
+func (c TxSignatureChecker) sigHashes() *tx.SigHashCache {
+	if c.SigHashes != nil {
+		return c.SigHashes
+	}
+	return tx.NewSigHashCache(c.Tx)
+}
+
+// CheckECDSASignature implements SignatureChecker
+func (c TxSignatureChecker) CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool {
+	if len(sig) == 0 {
+		return false
+	}
+	hashType := uint32(sig[len(sig)-1])
+	var hash [32]byte
+	var err error
+	if version == SigVersionWitnessV0 {
+		hash, err = c.sigHashes().WitnessV0SignatureHash(c.Index, scriptCode, c.Amount, hashType)
+	} else {
+		hash, err = tx.LegacySignatureHash(c.Tx, c.Index, removeCodeSeparators(scriptCode), hashType)
+	}
+	if err != nil {
+		return false
+	}
+	if c.Cache != nil && c.Cache.Contains(hash[:], pubKey, sig) {
+		return true
+	}
+	parsed, err := secp256k1.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		return false
+	}
+	key, err := secp256k1.ParsePubKey(pubKey)
+	if err != nil {
+		return false
+	}
+	if !secp256k1.VerifyECDSA(key, hash[:], parsed) {
+		return false
+	}
+	if c.Cache != nil {
+		c.Cache.Add(hash[:], pubKey, sig)
+	}
+	return true
+}
+
+// CheckLockTime implements SignatureChecker (BIP65)
+func (c TxSignatureChecker) CheckLockTime(lockTime int64) bool {
+	// Both must be heights or both timestamps
+	txLockTime := int64(c.Tx.Locktime)
+	if (txLockTime < tx.LockTimeThreshold) != (lockTime < tx.LockTimeThreshold) {
+		return false
+	}
+	if lockTime > txLockTime {
+		return false
+	}
+	// A final input would let the transaction be mined before its lock time
+	return c.Tx.Vin[c.Index].Sequence != tx.SequenceFinal
+}
+
+// CheckSequence implements SignatureChecker (BIP112)
+func (c TxSignatureChecker) CheckSequence(sequence int64) bool {
+	txSequence := int64(c.Tx.Vin[c.Index].Sequence)
+	// Relative lock times only apply from version 2 and when not disabled
+	if c.Tx.Version < 2 || txSequence&tx.SequenceLockTimeDisableFlag != 0 {
+		return false
+	}
+	const mask = tx.SequenceLockTimeTypeFlag | tx.SequenceLockTimeMask
+	txMasked, masked := txSequence&mask, sequence&mask
+	if (txMasked < tx.SequenceLockTimeTypeFlag) != (masked < tx.SequenceLockTimeTypeFlag) {
+		return false
+	}
+	return masked <= txMasked
+}
+
+// CheckSchnorrSignature implements SignatureChecker
+func (c TxSignatureChecker) CheckSchnorrSignature(sig, pubKey []byte, version SigVersion, execution *ExecutionData) error {
+	hashType := byte(tx.SigHashDefault)
+	switch len(sig) {
+	case 64:
+	case 65:
+		// The default type is only implied, never explicit
+		if hashType = sig[64]; hashType == tx.SigHashDefault {
+			return ErrSchnorrSigHashType
+		}
+		sig = sig[:64]
+	default:
+		return ErrSchnorrSigSize
+	}
+	message, err := c.sigHashes().TaprootSignatureMessage(c.Index, hashType, execution.Annex, execution.Tapscript)
+	if err != nil {
+		return ErrSchnorrSigHashType
+	}
+	hash := taggedhash.TapSighash(message)
+	if c.Cache != nil && c.Cache.Contains(hash[:], pubKey, sig) {
+		return nil
+	}
+	key, err := secp256k1.ParseXOnlyPubKey(pubKey)
+	if err != nil {
+		return ErrSchnorrSig // an output key off the curve can only be spent by script
+	}
+	if c.Batch != nil {
+		// A taproot signature that fails fails the script, so it never
+		// matters to the rest of the script whether it is valid. Whoever
+		// verifies the batch adds it to the cache.
+		c.Batch.Add(key, hash[:], sig)
+		return nil
+	}
+	if !secp256k1.VerifySchnorr(key, hash[:], sig) {
+		return ErrSchnorrSig
+	}
+	if c.Cache != nil {
+		c.Cache.Add(hash[:], pubKey, sig)
+	}
+	return nil
+}
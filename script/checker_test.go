@@ -0,0 +1,42 @@
+package script
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TestCheckTimelocks runs <n> OP_CHECKLOCKTIMEVERIFY (or
+// OP_CHECKSEQUENCEVERIFY) OP_DROP OP_1 against the locktime and sequence of a
+// transaction
+func TestCheckTimelocks(t *testing.T) {
+	locked := tx.Transaction{Version: 2, Locktime: 800000, Vin: []tx.TxInput{{Sequence: 10}}}
+	final := locked
+	final.Vin = []tx.TxInput{{Sequence: tx.SequenceFinal}}
+	version1 := locked
+	version1.Version = 1
+	tests := []struct {
+		transaction tx.Transaction
+		opcode      byte
+		n           int64
+		want        error
+	}{
+		{locked, OpCheckLockTimeVerify, 800000, nil},
+		{locked, OpCheckLockTimeVerify, 800001, ErrUnsatisfiedLockTime},
+		{locked, OpCheckLockTimeVerify, tx.LockTimeThreshold, ErrUnsatisfiedLockTime}, // a timestamp
+		{locked, OpCheckLockTimeVerify, -1, ErrNegativeLockTime},
+		{final, OpCheckLockTimeVerify, 1, ErrUnsatisfiedLockTime},
+		{locked, OpCheckSequenceVerify, 10, nil},
+		{locked, OpCheckSequenceVerify, 11, ErrUnsatisfiedLockTime},
+		{locked, OpCheckSequenceVerify, tx.SequenceLockTimeDisableFlag, nil},
+		{version1, OpCheckSequenceVerify, 1, ErrUnsatisfiedLockTime},
+	}
+	for _, test := range tests {
+		scriptPubKey := new(Builder).AddInt64(test.n).AddOp(test.opcode).AddOp(OpDrop).AddOp(Op1).Script()
+		checker := TxSignatureChecker{Tx: test.transaction}
+		if err := VerifyScript(nil, scriptPubKey, nil, StandardFlags, checker); !errors.Is(err, test.want) {
+			t.Errorf("opcode %#x with %d: got %v, want %v", test.opcode, test.n, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScriptType is the standard output template a scriptPubKey follows
+type ScriptType int
+
+const (
+	NonStandard ScriptType = iota
+	P2PKH
+	P2SH
+	P2WPKH
+	P2WSH
+	P2TR
+	OpReturnData
+	Multisig // bare m-of-n OP_CHECKMULTISIG
+	P2PK     // <pubkey> OP_CHECKSIG
+)
+
+// scriptTypeNames are the scriptpubkey_type names used in the mempool files
+var scriptTypeNames = map[ScriptType]string{
+	NonStandard:  "nonstandard",
+	P2PKH:        "p2pkh",
+	P2SH:         "p2sh",
+	P2WPKH:       "v0_p2wpkh",
+	P2WSH:        "v0_p2wsh",
+	P2TR:         "v1_p2tr",
+	OpReturnData: "op_return",
+	Multisig:     "multisig",
+	P2PK:         "p2pk",
+}
+
+func (t ScriptType) String() string {
+	return scriptTypeNames[t]
+}
+
+// ParseScriptType returns the script type of a mempool name such as
+// "v0_p2wpkh", or of its short form without the witness version, "p2wpkh"
+func ParseScriptType(name string) (ScriptType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for scriptType, typeName := range scriptTypeNames {
+		if name == typeName || name == strings.TrimPrefix(strings.TrimPrefix(typeName, "v0_"), "v1_") {
+			return scriptType, nil
+		}
+	}
+	return NonStandard, fmt.Errorf("unknown script type %q", name)
+}
+
+// ClassifyScript determines the template of a scriptPubKey from its bytes
+func ClassifyScript(scriptPubKey []byte) ScriptType {
+	s := scriptPubKey
+	switch {
+	case len(s) == 25 && s[0] == OpDup && s[1] == OpHash160 && s[2] == 20 && s[23] == OpEqualVerify && s[24] == OpCheckSig:
+		return P2PKH
+	case len(s) == 23 && s[0] == OpHash160 && s[1] == 20 && s[22] == OpEqual:
+		return P2SH
+	case len(s) == 22 && s[0] == Op0 && s[1] == 20:
+		return P2WPKH
+	case len(s) == 34 && s[0] == Op0 && s[1] == 32:
+		return P2WSH
+	case len(s) == 34 && s[0] == Op1 && s[1] == 32:
+		return P2TR
+	case len(s) > 0 && s[0] == OpReturn && IsPushOnly(s[1:]):
+		return OpReturnData
+	case len(s) == 35 && s[0] == 33 && s[34] == OpCheckSig && (s[1] == 0x02 || s[1] == 0x03):
+		return P2PK
+	case len(s) == 67 && s[0] == 65 && s[66] == OpCheckSig && (s[1] == 0x04 || s[1] == 0x06 || s[1] == 0x07):
+		return P2PK
+	}
+	if _, _, ok := ParseMultisig(s); ok {
+		return Multisig
+	}
+	return NonStandard
+}
+
+// ParseMultisig recognizes the m-of-n template
+// OP_m <pubkey>... OP_n OP_CHECKMULTISIG, with 1 <= m <= n <= 16 and
+// 33- or 65-byte public keys, returning m and the public keys
+func ParseMultisig(script []byte) (required int, pubKeys [][]byte, ok bool) {
+	instructions, err := Instructions(script)
+	if err != nil || len(instructions) < 4 || instructions[len(instructions)-1].Opcode != OpCheckMultiSig {
+		return 0, nil, false
+	}
+	first, last := instructions[0].Opcode, instructions[len(instructions)-2].Opcode
+	if first < Op1 || first > Op16 || last < Op1 || last > Op16 {
+		return 0, nil, false
+	}
+	required, total := int(first-Op1)+1, int(last-Op1)+1
+	keys := instructions[1 : len(instructions)-2]
+	if len(keys) != total || required > total {
+		return 0, nil, false
+	}
+	for _, key := range keys {
+		if key.Opcode > OpPushData4 || (len(key.Data) != 33 && len(key.Data) != 65) {
+			return 0, nil, false
+		}
+		pubKeys = append(pubKeys, key.Data)
+	}
+	return required, pubKeys, true
+}
+
+// IsPushOnly reports whether a script parses and contains only push opcodes
+func IsPushOnly(script []byte) bool {
+	instructions, err := Instructions(script)
+	if err != nil {
+		return false
+	}
+	for _, instruction := range instructions {
+		if instruction.Opcode > Op16 {
+			return false
+		}
+	}
+	return true
+}
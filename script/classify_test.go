@@ -0,0 +1,37 @@
+package script
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestClassifyScript checks every standard script type and scripts that only
+// nearly match one
+func TestClassifyScript(t *testing.T) {
+	tests := []struct {
+		script string
+		want   ScriptType
+	}{
+		{"76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", P2PKH},
+		{"a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87", P2SH},
+		{"0014751e76e8199196d454941c45d1b3a323f1433bd6", P2WPKH},
+		{"00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262", P2WSH},
+		{"512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", P2TR},
+		{"6a0474657374", OpReturnData},
+		{"6a04746573", NonStandard},
+		{"5210751e76e8199196d454941c45d1b3a323", NonStandard},
+		{"512102" + strings.Repeat("11", 32) + "51ae", Multisig},
+		{"522102" + strings.Repeat("11", 32) + "51ae", NonStandard}, // 2-of-1
+		{"4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac", P2PK}, // the genesis coinbase output
+		{"2102" + strings.Repeat("11", 32) + "ac", P2PK},
+		{"2105" + strings.Repeat("11", 32) + "ac", NonStandard},
+		{"", NonStandard},
+	}
+	for _, test := range tests {
+		scriptPubKey, _ := hex.DecodeString(test.script)
+		if got := ClassifyScript(scriptPubKey); got != test.want {
+			t.Errorf("%s: classified as %s, want %s", test.script, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,1221 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/secp256k1"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/taggedhash"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// SigVersion selects the signature hashing and evaluation rules of a script
+type SigVersion int
+
+const (
+	SigVersionBase      SigVersion = iota // scriptPubKeys, scriptSigs and P2SH redeem scripts
+	SigVersionWitnessV0                   // P2WPKH and P2WSH scripts (BIP143)
+	SigVersionTaproot                     // taproot key path spends (BIP341)
+	SigVersionTapscript                   // tapscripts of taproot script path spends (BIP342)
+)
+
+// Flags select the verification rules applied on top of the original ones
+type Flags uint32
+
+const (
+	VerifyP2SH                               Flags = 1 << iota // evaluate P2SH redeem scripts (BIP16)
+	VerifyStrictEnc                                            // public keys and sighash types must be of a defined form
+	VerifyDERSig                                               // signatures must be strict DER (BIP66)
+	VerifyLowS                                                 // signatures must use the low S value (BIP146)
+	VerifyNullDummy                                            // the CHECKMULTISIG dummy element must be empty (BIP147)
+	VerifySigPushOnly                                          // scriptSigs may only push data
+	VerifyWitness                                              // evaluate witness programs (BIP141)
+	VerifyMinimalIf                                            // IF arguments in witness scripts must be empty or 1
+	VerifyNullFail                                             // failed signature checks must have empty signatures (BIP146)
+	VerifyWitnessPubKeyType                                    // witness scripts may only use compressed public keys
+	VerifyCheckLockTimeVerify                                  // evaluate OP_CHECKLOCKTIMEVERIFY instead of treating it as a NOP (BIP65)
+	VerifyCheckSequenceVerify                                  // evaluate OP_CHECKSEQUENCEVERIFY instead of treating it as a NOP (BIP112)
+	VerifyMinimalData                                          // pushes and numbers must use their shortest encoding
+	VerifyCleanStack                                           // scripts must leave a single element, as witness scripts always must
+	VerifyDiscourageUpgradableWitnessProgram                   // reject spends of witness versions and lengths without a meaning yet
+	VerifyTaproot                                              // verify taproot spends (BIP341)
+	VerifyDiscourageUpgradableTaprootVersion                   // reject script path spends of leaf versions without a meaning yet
+	VerifyDiscourageOpSuccess                                  // reject tapscripts containing an OP_SUCCESSx opcode
+	VerifyDiscourageUpgradablePubKeyType                       // reject tapscript signature checks with keys of unknown types
+)
+
+// ConsensusFlags are the rules every block must follow. StandardFlags adds the
+// policy rules nodes apply before relaying or mining a transaction.
+const (
+	ConsensusFlags = VerifyP2SH | VerifyDERSig | VerifyNullDummy | VerifyWitness |
+		VerifyCheckLockTimeVerify | VerifyCheckSequenceVerify | VerifyTaproot
+	StandardFlags = ConsensusFlags | VerifyStrictEnc | VerifyLowS | VerifySigPushOnly |
+		VerifyMinimalIf | VerifyNullFail | VerifyWitnessPubKeyType | VerifyMinimalData | VerifyCleanStack |
+		VerifyDiscourageUpgradableWitnessProgram | VerifyDiscourageUpgradableTaprootVersion | VerifyDiscourageOpSuccess |
+		VerifyDiscourageUpgradablePubKeyType
+)
+
+// Script evaluation errors, named after Bitcoin Core's script errors
+var (
+	ErrEvalFalse                          = errors.New("script: evaluated to false")
+	ErrOpReturn                           = errors.New("script: OP_RETURN executed")
+	ErrVerify                             = errors.New("script: OP_VERIFY failed")
+	ErrEqualVerify                        = errors.New("script: OP_EQUALVERIFY failed")
+	ErrNumEqualVerify                     = errors.New("script: OP_NUMEQUALVERIFY failed")
+	ErrCheckSigVerify                     = errors.New("script: OP_CHECKSIGVERIFY failed")
+	ErrCheckMultiSigVerify                = errors.New("script: OP_CHECKMULTISIGVERIFY failed")
+	ErrBadOpcode                          = errors.New("script: bad opcode")
+	ErrDisabledOpcode                     = errors.New("script: disabled opcode")
+	ErrInvalidStackOperation              = errors.New("script: operation on too few stack elements")
+	ErrUnbalancedConditional              = errors.New("script: unbalanced conditional")
+	ErrNumberOverflow                     = errors.New("script: number longer than 4 bytes")
+	ErrNonMinimalNumber                   = errors.New("script: non-minimally encoded number")
+	ErrPubKeyCount                        = errors.New("script: public key count out of range")
+	ErrSigCount                           = errors.New("script: signature count out of range")
+	ErrSigDER                             = errors.New("script: signature is not strict DER")
+	ErrSigHighS                           = errors.New("script: signature S value is high")
+	ErrSigHashType                        = errors.New("script: undefined sighash type")
+	ErrPubKeyType                         = errors.New("script: public key is neither compressed nor uncompressed")
+	ErrWitnessPubKeyType                  = errors.New("script: witness public key is not compressed")
+	ErrNullDummy                          = errors.New("script: OP_CHECKMULTISIG dummy element is not empty")
+	ErrNullFail                           = errors.New("script: failed signature check with a non-empty signature")
+	ErrMinimalIf                          = errors.New("script: OP_IF argument is not empty or 1")
+	ErrSigPushOnly                        = errors.New("script: scriptsig is not push-only")
+	ErrCleanStack                         = errors.New("script: stack not clean after evaluation")
+	ErrWitnessProgramWrongLength          = errors.New("script: witness program has the wrong length")
+	ErrWitnessProgramWitnessEmpty         = errors.New("script: witness program spent with an empty witness")
+	ErrWitnessProgramMismatch             = errors.New("script: witness does not match the witness program")
+	ErrWitnessMalleated                   = errors.New("script: native witness spend with a scriptsig")
+	ErrWitnessMalleatedP2SH               = errors.New("script: P2SH witness spend with a non-canonical scriptsig")
+	ErrWitnessUnexpected                  = errors.New("script: witness for a non-witness spend")
+	ErrDiscourageUpgradableWitnessProgram = errors.New("script: spend of a witness program reserved for upgrades")
+	ErrMinimalData                        = errors.New("script: data push not in its shortest encoding")
+	ErrScriptSize                         = errors.New("script: script larger than 10000 bytes")
+	ErrPushSize                           = errors.New("script: push or stack element larger than 520 bytes")
+	ErrStackSize                          = errors.New("script: more than 1000 stack elements")
+	ErrSchnorrSigSize                     = errors.New("script: schnorr signature is not 64 or 65 bytes")
+	ErrSchnorrSigHashType                 = errors.New("script: undefined taproot sighash type")
+	ErrSchnorrSig                         = errors.New("script: invalid schnorr signature")
+	ErrOpCount                            = errors.New("script: more than 201 non-push opcodes")
+	ErrTaprootWrongControlSize            = errors.New("script: taproot control block of invalid size")
+	ErrTapscriptValidationWeight          = errors.New("script: too many signature checks for the witness size")
+	ErrTapscriptCheckMultiSig             = errors.New("script: OP_CHECKMULTISIG in a tapscript")
+	ErrTapscriptMinimalIf                 = errors.New("script: tapscript OP_IF argument is not empty or 1")
+	ErrTapscriptEmptyPubKey               = errors.New("script: empty tapscript public key")
+	ErrDiscourageUpgradableTaprootVersion = errors.New("script: spend of a taproot leaf version reserved for upgrades")
+	ErrDiscourageOpSuccess                = errors.New("script: tapscript with an OP_SUCCESSx opcode")
+	ErrDiscourageUpgradablePubKeyType     = errors.New("script: tapscript public key of a type reserved for upgrades")
+	ErrNegativeLockTime                   = errors.New("script: negative lock time")
+	ErrUnsatisfiedLockTime                = errors.New("script: lock time requirement not satisfied")
+)
+
+// maxPubKeysPerMultisig bounds the public keys of an OP_CHECKMULTISIG
+const maxPubKeysPerMultisig = 20
+
+// Limits bounding the work a legacy or witness v0 script can cause
+const (
+	MaxScriptSize        = 10000 // bytes of a script
+	MaxScriptElementSize = 520   // bytes of a push or witness stack element
+	MaxOpsPerScript      = 201   // non-push opcodes, counting each public key of an OP_CHECKMULTISIG
+	MaxStackSize         = 1000  // elements of the main and alternate stacks together
+)
+
+// lockTimeNumSize is the size OP_CHECKLOCKTIMEVERIFY and
+// OP_CHECKSEQUENCEVERIFY allow their argument, to reach lock times up to 2³⁹
+const lockTimeNumSize = 5
+
+// SignatureChecker verifies the signatures checked by OP_CHECKSIG and
+// OP_CHECKMULTISIG against the transaction being validated
+type SignatureChecker interface {
+	// CheckECDSASignature reports whether sig, a DER signature followed by
+	// its sighash type, is a signature by pubKey of the transaction hashed
+	// with scriptCode
+	CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool
+
+	// CheckLockTime reports whether the transaction's lock time is at least
+	// lockTime, of the same kind, and in force
+	CheckLockTime(lockTime int64) bool
+
+	// CheckSequence reports whether the input's relative lock time is at
+	// least sequence, of the same kind, and in force
+	CheckSequence(sequence int64) bool
+
+	// CheckSchnorrSignature verifies sig, a BIP340 signature optionally followed
+	// by its sighash type, by the x-only pubKey of the transaction hashed with
+	// the taproot execution data
+	CheckSchnorrSignature(sig, pubKey []byte, version SigVersion, execution *ExecutionData) error
+}
+
+// ExecutionData is what a taproot signature commits to beyond the transaction
+type ExecutionData struct {
+	Annex     []byte             // the annex with its 0x50 prefix, nil if the witness has none
+	Tapscript *tx.TapscriptSpend // the leaf executed and its last executed OP_CODESEPARATOR, nil for key path spends
+
+	validationWeightLeft int64 // the signature checks a tapscript can still afford, in weight units
+}
+
+// noCodeSeparator is the CodeSeparatorPos of a tapscript before any
+// OP_CODESEPARATOR is executed (BIP342)
+const noCodeSeparator = 0xffffffff
+
+// Sizes of a taproot control block: the leaf version and parity byte, the
+// internal key and up to 128 merkle path hashes (BIP341)
+const (
+	taprootControlBaseSize = 33
+	taprootControlNodeSize = 32
+	taprootControlMaxNodes = 128
+)
+
+// Tapscript leaves are version 0xc0; the low bit of the control block byte
+// holding it is the output key parity (BIP341)
+const (
+	taprootLeafMask      = 0xfe
+	taprootLeafTapscript = 0xc0
+)
+
+// Each signature a tapscript checks costs validation weight, of which a spend
+// gets its witness size plus the offset: the cost of a signature check is
+// borne by the fee paid for the signature (BIP342)
+const (
+	validationWeightPerSigOp = 50
+	validationWeightOffset   = 50
+)
+
+// annexTag starts the annex, an optional last witness element of taproot
+// spends reserved for future extensions (BIP341)
+const annexTag = 0x50
+
+// IsWitnessProgram reports whether a scriptPubKey is a witness program: a
+// version opcode followed by a single push of 2 to 40 bytes
+func IsWitnessProgram(scriptPubKey []byte) (version int, program []byte, ok bool) {
+	if len(scriptPubKey) < 4 || len(scriptPubKey) > 42 {
+		return 0, nil, false
+	}
+	if scriptPubKey[0] != Op0 && (scriptPubKey[0] < Op1 || scriptPubKey[0] > Op16) {
+		return 0, nil, false
+	}
+	if int(scriptPubKey[1])+2 != len(scriptPubKey) {
+		return 0, nil, false
+	}
+	if scriptPubKey[0] != Op0 {
+		version = int(scriptPubKey[0]-Op1) + 1
+	}
+	return version, scriptPubKey[2:], true
+}
+
+// VerifyScript checks that scriptSig and witness satisfy scriptPubKey: the
+// scriptSig is evaluated, then the scriptPubKey on the resulting stack, then
+// the P2SH redeem script or witness program the scriptPubKey commits to
+func VerifyScript(scriptSig, scriptPubKey []byte, witness [][]byte, flags Flags, checker SignatureChecker) error {
+	if flags&VerifySigPushOnly != 0 && !IsPushOnly(scriptSig) {
+		return ErrSigPushOnly
+	}
+
+	var main stack
+	if err := eval(&main, scriptSig, flags, checker, SigVersionBase, nil); err != nil {
+		return err
+	}
+	var p2shStack stack
+	if flags&VerifyP2SH != 0 {
+		p2shStack = append(stack(nil), main...)
+	}
+	if err := eval(&main, scriptPubKey, flags, checker, SigVersionBase, nil); err != nil {
+		return err
+	}
+	if len(main) == 0 || !castToBool(main[len(main)-1]) {
+		return ErrEvalFalse
+	}
+
+	hadWitness := false
+	if flags&VerifyWitness != 0 {
+		if version, program, ok := IsWitnessProgram(scriptPubKey); ok {
+			hadWitness = true
+			if len(scriptSig) != 0 {
+				return ErrWitnessMalleated
+			}
+			if err := verifyWitnessProgram(witness, version, program, false, flags, checker); err != nil {
+				return err
+			}
+			main = main[:1] // for the clean stack rule
+		}
+	}
+
+	if flags&VerifyP2SH != 0 && ClassifyScript(scriptPubKey) == P2SH {
+		if !IsPushOnly(scriptSig) {
+			return ErrSigPushOnly
+		}
+		main = p2shStack
+		redeemScript, err := main.pop()
+		if err != nil {
+			return err
+		}
+		if err := eval(&main, redeemScript, flags, checker, SigVersionBase, nil); err != nil {
+			return err
+		}
+		if len(main) == 0 || !castToBool(main[len(main)-1]) {
+			return ErrEvalFalse
+		}
+		if flags&VerifyWitness != 0 {
+			if version, program, ok := IsWitnessProgram(redeemScript); ok {
+				hadWitness = true
+				if !bytes.Equal(scriptSig, pushData(redeemScript)) {
+					return ErrWitnessMalleatedP2SH
+				}
+				if err := verifyWitnessProgram(witness, version, program, true, flags, checker); err != nil {
+					return err
+				}
+				main = main[:1]
+			}
+		}
+	}
+
+	// Extra elements could be pushed by anyone relaying the transaction. Only
+	// witness scripts are bound to this by consensus, in verifyWitnessProgram;
+	// for legacy and P2SH scripts it is policy.
+	if flags&VerifyCleanStack != 0 && len(main) != 1 {
+		return ErrCleanStack
+	}
+
+	if flags&VerifyWitness != 0 && !hadWitness && len(witness) > 0 {
+		return ErrWitnessUnexpected
+	}
+	return nil
+}
+
+// IsTaprootProgram reports whether a witness program is a taproot output:
+// version 1 with a 32-byte program, not nested in P2SH (BIP341)
+func IsTaprootProgram(version int, program []byte, nested bool) bool {
+	return version == 1 && len(program) == 32 && !nested
+}
+
+// verifyWitnessProgram executes the script a witness program commits to with
+// the rest of the witness as its stack; nested tells a P2SH-wrapped program.
+// Programs of other versions and lengths succeed, to be given a meaning by
+// later soft forks, unless their spends are discouraged.
+func verifyWitnessProgram(witness [][]byte, version int, program []byte, nested bool, flags Flags, checker SignatureChecker) error {
+	if IsTaprootProgram(version, program, nested) && flags&VerifyTaproot != 0 {
+		return verifyTaproot(witness, program, flags, checker)
+	}
+	if version != 0 {
+		if flags&VerifyDiscourageUpgradableWitnessProgram != 0 {
+			return ErrDiscourageUpgradableWitnessProgram
+		}
+		return nil
+	}
+	var witnessScript []byte
+	var initial stack
+	switch len(program) {
+	case 32:
+		if len(witness) == 0 {
+			return ErrWitnessProgramWitnessEmpty
+		}
+		witnessScript = witness[len(witness)-1]
+		if hash := sha256.Sum256(witnessScript); !bytes.Equal(hash[:], program) {
+			return ErrWitnessProgramMismatch
+		}
+		initial = append(initial, witness[:len(witness)-1]...)
+	case 20:
+		if len(witness) != 2 {
+			return ErrWitnessProgramMismatch
+		}
+		witnessScript = append(append([]byte{OpDup, OpHash160, 20}, program...), OpEqualVerify, OpCheckSig)
+		initial = append(initial, witness...)
+	default:
+		return ErrWitnessProgramWrongLength
+	}
+
+	return executeWitnessScript(initial, witnessScript, flags, checker, SigVersionWitnessV0, nil)
+}
+
+// executeWitnessScript executes a witness v0 script or a tapscript on the
+// witness elements before it, which it must reduce to exactly one true
+// element. A tapscript containing an OP_SUCCESSx opcode succeeds without
+// being executed, so that soft forks can give those opcodes any meaning.
+func executeWitnessScript(initial stack, script []byte, flags Flags, checker SignatureChecker, version SigVersion, execution *ExecutionData) error {
+	if version == SigVersionTapscript {
+		for pc := 0; pc < len(script); {
+			instruction, next, err := decodeInstruction(script, pc)
+			if err != nil {
+				return ErrBadOpcode
+			}
+			if isOpSuccess(instruction.Opcode) {
+				if flags&VerifyDiscourageOpSuccess != 0 {
+					return ErrDiscourageOpSuccess
+				}
+				return nil
+			}
+			pc = next
+		}
+		if len(initial) > MaxStackSize {
+			return ErrStackSize
+		}
+	}
+	for _, element := range initial {
+		if len(element) > MaxScriptElementSize {
+			return ErrPushSize
+		}
+	}
+	if err := eval(&initial, script, flags, checker, version, execution); err != nil {
+		return err
+	}
+	// Witness scripts must leave exactly one true element
+	if len(initial) != 1 {
+		return ErrCleanStack
+	}
+	if !castToBool(initial[0]) {
+		return ErrEvalFalse
+	}
+	return nil
+}
+
+// verifyTaproot verifies the witness of a taproot output: a single signature
+// by the output key for key path spends, the script inputs, the script and a
+// control block for script path spends, each followed by an optional annex.
+// Script path spends of leaf versions other than tapscript succeed, to be
+// given a meaning by later soft forks, unless their spends are discouraged.
+func verifyTaproot(witness [][]byte, outputKey []byte, flags Flags, checker SignatureChecker) error {
+	if len(witness) == 0 {
+		return ErrWitnessProgramWitnessEmpty
+	}
+	// The serialized size of the whole witness, annex included
+	witnessSize := tx.VarIntSize(uint64(len(witness)))
+	for _, item := range witness {
+		witnessSize += tx.VarIntSize(uint64(len(item))) + len(item)
+	}
+	var execution ExecutionData
+	if last := witness[len(witness)-1]; len(witness) >= 2 && len(last) > 0 && last[0] == annexTag {
+		execution.Annex = last
+		witness = witness[:len(witness)-1]
+	}
+	if len(witness) == 1 {
+		return checker.CheckSchnorrSignature(witness[0], outputKey, SigVersionTaproot, &execution)
+	}
+	control := witness[len(witness)-1]
+	tapScript := witness[len(witness)-2]
+	if len(control) < taprootControlBaseSize || len(control) > taprootControlBaseSize+taprootControlNodeSize*taprootControlMaxNodes ||
+		(len(control)-taprootControlBaseSize)%taprootControlNodeSize != 0 {
+		return ErrTaprootWrongControlSize
+	}
+	leafVersion := control[0] & taprootLeafMask
+	leafHash := taggedhash.TapLeaf(leafVersion, tapScript)
+	if !verifyTaprootCommitment(control, outputKey, leafHash) {
+		return ErrWitnessProgramMismatch
+	}
+	if leafVersion != taprootLeafTapscript {
+		if flags&VerifyDiscourageUpgradableTaprootVersion != 0 {
+			return ErrDiscourageUpgradableTaprootVersion
+		}
+		return nil
+	}
+	execution.Tapscript = &tx.TapscriptSpend{LeafHash: leafHash, CodeSeparatorPos: noCodeSeparator}
+	execution.validationWeightLeft = int64(witnessSize) + validationWeightOffset
+	initial := append(stack(nil), witness[:len(witness)-2]...)
+	return executeWitnessScript(initial, tapScript, flags, checker, SigVersionTapscript, &execution)
+}
+
+// verifyTaprootCommitment reports whether the output key commits to the
+// script tree in which the control block places the leaf: the internal key
+// tweaked by the merkle root recomputed from the leaf hash and the merkle path
+func verifyTaprootCommitment(control, outputKey []byte, leafHash [32]byte) bool {
+	odd := control[0]&1 == 1
+	internal, err := secp256k1.ParseXOnlyPubKey(control[1:taprootControlBaseSize])
+	if err != nil {
+		return false
+	}
+	output, err := secp256k1.ParseXOnlyPubKey(outputKey)
+	if err != nil {
+		return false
+	}
+	node := leafHash
+	for path := control[taprootControlBaseSize:]; len(path) > 0; path = path[taprootControlNodeSize:] {
+		node = taggedhash.TapBranch(node, [32]byte(path[:taprootControlNodeSize]))
+	}
+	tweak := taggedhash.TapTweak(control[1:taprootControlBaseSize], node[:])
+	return output.CheckTapTweak(internal, tweak, odd)
+}
+
+// pushData returns the script pushing data with the shortest push opcode,
+// without turning small values into OP_1 through OP_16
+func pushData(data []byte) []byte {
+	var b Builder
+	b.addPush(pushOpcodeFor(len(data)), data)
+	return b.script
+}
+
+// pushOpcodeFor returns the smallest push opcode able to push n bytes
+func pushOpcodeFor(n int) byte {
+	switch {
+	case n < OpPushData1:
+		return byte(n)
+	case n <= 0xff:
+		return OpPushData1
+	case n <= 0xffff:
+		return OpPushData2
+	}
+	return OpPushData4
+}
+
+// findAndDelete removes every instruction-aligned occurrence of pattern from
+// script, as legacy signature hashing does with the signatures being checked
+func findAndDelete(script, pattern []byte) []byte {
+	if len(pattern) == 0 {
+		return script
+	}
+	var result []byte
+	found := false
+	pc, kept := 0, 0
+	for {
+		result = append(result, script[kept:pc]...)
+		for len(script)-pc >= len(pattern) && bytes.Equal(script[pc:pc+len(pattern)], pattern) {
+			pc += len(pattern)
+			found = true
+		}
+		kept = pc
+		if pc >= len(script) {
+			break
+		}
+		_, next, err := decodeInstruction(script, pc)
+		if err != nil {
+			break
+		}
+		pc = next
+	}
+	if !found {
+		return script
+	}
+	return append(result, script[kept:]...)
+}
+
+// removeCodeSeparators drops the OP_CODESEPARATORs of a legacy scriptCode,
+// as legacy signature hashing does. From a malformed push on, the script is
+// kept as is.
+func removeCodeSeparators(script []byte) []byte {
+	var result []byte
+	pc := 0
+	for pc < len(script) {
+		instruction, next, err := decodeInstruction(script, pc)
+		if err != nil {
+			break
+		}
+		if instruction.Opcode != OpCodeSeparator {
+			result = append(result, script[pc:next]...)
+		}
+		pc = next
+	}
+	return append(result, script[pc:]...)
+}
+
+// isMinimalPush reports whether a push uses the shortest encoding of its data:
+// OP_0, OP_1 through OP_16 and OP_1NEGATE for the values they stand for, a
+// direct push up to 75 bytes, then the smallest OP_PUSHDATA
+func isMinimalPush(instruction Instruction) bool {
+	data := instruction.Data
+	switch n := len(data); {
+	case n == 0:
+		return instruction.Opcode == Op0
+	case n == 1 && data[0] >= 1 && data[0] <= 16:
+		return instruction.Opcode == Op1+data[0]-1
+	case n == 1 && data[0] == 0x81:
+		return instruction.Opcode == Op1Negate
+	}
+	return instruction.Opcode == pushOpcodeFor(len(data))
+}
+
+// isDisabled reports whether an opcode fails a script even in an unexecuted branch
+func isDisabled(opcode byte) bool {
+	switch opcode {
+	case OpCat, OpSubstr, OpLeft, OpRight, OpInvert, OpAnd, OpOr, OpXor,
+		Op2Mul, Op2Div, OpMul, OpDiv, OpMod, OpLShift, OpRShift:
+		return true
+	}
+	return false
+}
+
+// isOpSuccess reports whether an opcode is one of the OP_SUCCESSx of
+// tapscripts, the unassigned and disabled opcodes reserved for soft forks
+// (BIP342)
+func isOpSuccess(opcode byte) bool {
+	return opcode == 80 || opcode == 98 || (opcode >= 126 && opcode <= 129) ||
+		(opcode >= 131 && opcode <= 134) || (opcode >= 137 && opcode <= 138) ||
+		(opcode >= 141 && opcode <= 142) || (opcode >= 149 && opcode <= 153) ||
+		(opcode >= 187 && opcode <= 254)
+}
+
+// eval executes script on the stack. Errors raised by an instruction name it
+// and its offset in the script. execution is nil outside of tapscripts; in a
+// tapscript, it records the position of the last executed OP_CODESEPARATOR
+// and the validation weight left. Tapscripts are bound by the validation
+// weight instead of the script size and opcode count limits.
+func eval(main *stack, script []byte, flags Flags, checker SignatureChecker, version SigVersion, execution *ExecutionData) (err error) {
+	tapscript := version == SigVersionTapscript
+	if !tapscript && len(script) > MaxScriptSize {
+		return ErrScriptSize
+	}
+	start, opcode := -1, byte(0) // the instruction being executed
+	defer func() {
+		if err != nil && start >= 0 {
+			err = fmt.Errorf("%w (%s at byte %d)", err, opcodeNames[opcode], start)
+		}
+	}()
+	var alt stack
+	opCount := 0
+	requireMinimal := flags&VerifyMinimalData != 0
+	codeStart := 0        // signatures hash the script from just after the last executed OP_CODESEPARATOR
+	var conditions []bool // one entry per open IF, whether its branch executes
+	executing := func() bool {
+		for _, condition := range conditions {
+			if !condition {
+				return false
+			}
+		}
+		return true
+	}
+
+	// opcodePos counts instructions, pushes included, in executed branches or not
+	for pc, opcodePos := 0, uint32(0); pc < len(script); opcodePos++ {
+		// The limit applies after every instruction, checked here for the previous one
+		if len(*main)+len(alt) > MaxStackSize {
+			return ErrStackSize
+		}
+		start = pc
+		instruction, next, err := decodeInstruction(script, pc)
+		if err != nil {
+			return err
+		}
+		pc = next
+		opcode = instruction.Opcode
+		if len(instruction.Data) > MaxScriptElementSize {
+			return ErrPushSize
+		}
+		// Opcodes count even in unexecuted branches
+		if opcode > Op16 && !tapscript {
+			if opCount++; opCount > MaxOpsPerScript {
+				return ErrOpCount
+			}
+		}
+		if isDisabled(opcode) {
+			return ErrDisabledOpcode
+		}
+		inBranch := executing()
+		if opcode <= OpPushData4 {
+			if inBranch && requireMinimal && !isMinimalPush(instruction) {
+				return ErrMinimalData
+			}
+			if inBranch {
+				main.push(instruction.Data)
+			}
+			continue
+		}
+		if !inBranch && (opcode < OpIf || opcode > OpEndIf) {
+			continue
+		}
+
+		switch {
+		case opcode == Op1Negate || (opcode >= Op1 && opcode <= Op16):
+			if opcode == Op1Negate {
+				main.pushInt(-1)
+			} else {
+				main.pushInt(int64(opcode-Op1) + 1)
+			}
+			continue
+		case opcode == OpCheckLockTimeVerify && flags&VerifyCheckLockTimeVerify != 0:
+			if err := checkLockTimeVerify(*main, requireMinimal, checker); err != nil {
+				return err
+			}
+			continue
+		case opcode == OpCheckSequenceVerify && flags&VerifyCheckSequenceVerify != 0:
+			if err := checkSequenceVerify(*main, requireMinimal, checker); err != nil {
+				return err
+			}
+			continue
+		case opcode >= OpNop1 && opcode <= OpNop10 || opcode == OpNop:
+			continue
+		}
+
+		switch opcode {
+		case OpIf, OpNotIf:
+			value := false
+			if inBranch {
+				top, err := main.top(0)
+				if err != nil {
+					return ErrUnbalancedConditional
+				}
+				minimal := len(top) == 0 || (len(top) == 1 && top[0] == 1)
+				if tapscript && !minimal {
+					return ErrTapscriptMinimalIf
+				}
+				if version == SigVersionWitnessV0 && flags&VerifyMinimalIf != 0 && !minimal {
+					return ErrMinimalIf
+				}
+				value = castToBool(top)
+				if opcode == OpNotIf {
+					value = !value
+				}
+				main.pop()
+			}
+			conditions = append(conditions, value)
+		case OpElse:
+			if len(conditions) == 0 {
+				return ErrUnbalancedConditional
+			}
+			conditions[len(conditions)-1] = !conditions[len(conditions)-1]
+		case OpEndIf:
+			if len(conditions) == 0 {
+				return ErrUnbalancedConditional
+			}
+			conditions = conditions[:len(conditions)-1]
+		case OpVerify:
+			value, err := main.popBool()
+			if err != nil {
+				return err
+			}
+			if !value {
+				return ErrVerify
+			}
+		case OpReturn:
+			return ErrOpReturn
+
+		case OpToAltStack:
+			data, err := main.pop()
+			if err != nil {
+				return err
+			}
+			alt.push(data)
+		case OpFromAltStack:
+			data, err := alt.pop()
+			if err != nil {
+				return err
+			}
+			main.push(data)
+		case Op2Drop:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			*main = (*main)[:len(*main)-2]
+		case Op2Dup, Op3Dup:
+			n := 2
+			if opcode == Op3Dup {
+				n = 3
+			}
+			if err := main.need(n); err != nil {
+				return err
+			}
+			*main = append(*main, (*main)[len(*main)-n:]...)
+		case Op2Over:
+			if err := main.need(4); err != nil {
+				return err
+			}
+			*main = append(*main, (*main)[len(*main)-4:len(*main)-2]...)
+		case Op2Rot:
+			if err := main.need(6); err != nil {
+				return err
+			}
+			first, _ := main.remove(5)
+			second, _ := main.remove(4)
+			main.push(first)
+			main.push(second)
+		case Op2Swap:
+			if err := main.need(4); err != nil {
+				return err
+			}
+			s := *main
+			n := len(s)
+			s[n-4], s[n-3], s[n-2], s[n-1] = s[n-2], s[n-1], s[n-4], s[n-3]
+		case OpIfDup:
+			top, err := main.top(0)
+			if err != nil {
+				return err
+			}
+			if castToBool(top) {
+				main.push(top)
+			}
+		case OpDepth:
+			main.pushInt(int64(len(*main)))
+		case OpDrop:
+			if _, err := main.pop(); err != nil {
+				return err
+			}
+		case OpDup:
+			top, err := main.top(0)
+			if err != nil {
+				return err
+			}
+			main.push(top)
+		case OpNip:
+			if _, err := main.remove(1); err != nil {
+				return err
+			}
+		case OpOver:
+			data, err := main.top(1)
+			if err != nil {
+				return err
+			}
+			main.push(data)
+		case OpPick, OpRoll:
+			n, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			if n < 0 || n >= int64(len(*main)) {
+				return ErrInvalidStackOperation
+			}
+			var data []byte
+			if opcode == OpRoll {
+				data, _ = main.remove(int(n))
+			} else {
+				data, _ = main.top(int(n))
+			}
+			main.push(data)
+		case OpRot:
+			data, err := main.remove(2)
+			if err != nil {
+				return err
+			}
+			main.push(data)
+		case OpSwap:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			s := *main
+			s[len(s)-1], s[len(s)-2] = s[len(s)-2], s[len(s)-1]
+		case OpTuck:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			top, _ := main.top(0)
+			s := *main
+			*main = append(s[:len(s)-2], append([][]byte{top}, s[len(s)-2:]...)...)
+
+		case OpSize:
+			top, err := main.top(0)
+			if err != nil {
+				return err
+			}
+			main.pushInt(int64(len(top)))
+
+		case OpEqual, OpEqualVerify:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			a, _ := main.pop()
+			b, _ := main.pop()
+			equal := bytes.Equal(a, b)
+			if opcode == OpEqualVerify {
+				if !equal {
+					return ErrEqualVerify
+				}
+			} else {
+				main.pushBool(equal)
+			}
+
+		case Op1Add, Op1Sub, OpNegate, OpAbs, OpNot, Op0NotEqual:
+			n, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			switch opcode {
+			case Op1Add:
+				n++
+			case Op1Sub:
+				n--
+			case OpNegate:
+				n = -n
+			case OpAbs:
+				n = max(n, -n)
+			case OpNot:
+				n = boolInt(n == 0)
+			case Op0NotEqual:
+				n = boolInt(n != 0)
+			}
+			main.pushInt(n)
+
+		case OpAdd, OpSub, OpBoolAnd, OpBoolOr, OpNumEqual, OpNumEqualVerify, OpNumNotEqual,
+			OpLessThan, OpGreaterThan, OpLessThanOrEqual, OpGreaterThanOrEqual, OpMin, OpMax:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			b, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			a, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			var n int64
+			switch opcode {
+			case OpAdd:
+				n = a + b
+			case OpSub:
+				n = a - b
+			case OpBoolAnd:
+				n = boolInt(a != 0 && b != 0)
+			case OpBoolOr:
+				n = boolInt(a != 0 || b != 0)
+			case OpNumEqual, OpNumEqualVerify:
+				n = boolInt(a == b)
+			case OpNumNotEqual:
+				n = boolInt(a != b)
+			case OpLessThan:
+				n = boolInt(a < b)
+			case OpGreaterThan:
+				n = boolInt(a > b)
+			case OpLessThanOrEqual:
+				n = boolInt(a <= b)
+			case OpGreaterThanOrEqual:
+				n = boolInt(a >= b)
+			case OpMin:
+				n = min(a, b)
+			case OpMax:
+				n = max(a, b)
+			}
+			if opcode == OpNumEqualVerify {
+				if n == 0 {
+					return ErrNumEqualVerify
+				}
+				continue
+			}
+			main.pushInt(n)
+		case OpWithin:
+			if err := main.need(3); err != nil {
+				return err
+			}
+			upper, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			lower, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			n, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			main.pushBool(lower <= n && n < upper)
+
+		case OpRipemd160, OpSha1, OpSha256, OpHash160, OpHash256:
+			data, err := main.pop()
+			if err != nil {
+				return err
+			}
+			var digest []byte
+			switch opcode {
+			case OpRipemd160:
+				h := hashutil.NewRIPEMD160()
+				h.Write(data)
+				digest = h.Sum(nil)
+			case OpSha1:
+				sum := sha1.Sum(data)
+				digest = sum[:]
+			case OpSha256:
+				sum := sha256.Sum256(data)
+				digest = sum[:]
+			case OpHash160:
+				sum := hashutil.Hash160(data)
+				digest = sum[:]
+			case OpHash256:
+				sum := hashutil.Hash256(data)
+				digest = sum[:]
+			}
+			main.push(digest)
+		case OpCodeSeparator:
+			codeStart = pc
+			if execution != nil && execution.Tapscript != nil {
+				execution.Tapscript.CodeSeparatorPos = opcodePos
+			}
+
+		case OpCheckSig, OpCheckSigVerify:
+			if err := main.need(2); err != nil {
+				return err
+			}
+			pubKey, _ := main.pop()
+			sig, _ := main.pop()
+			if tapscript {
+				success, err := checkTapscriptSignature(sig, pubKey, flags, checker, execution)
+				if err != nil {
+					return err
+				}
+				if opcode == OpCheckSigVerify {
+					if !success {
+						return ErrCheckSigVerify
+					}
+				} else {
+					main.pushBool(success)
+				}
+				continue
+			}
+			scriptCode := script[codeStart:]
+			if version == SigVersionBase {
+				scriptCode = findAndDelete(scriptCode, pushData(sig))
+			}
+			if err := checkSignatureEncoding(sig, flags); err != nil {
+				return err
+			}
+			if err := checkPubKeyEncoding(pubKey, flags, version); err != nil {
+				return err
+			}
+			success := len(sig) > 0 && checker.CheckECDSASignature(sig, pubKey, scriptCode, version)
+			if !success && flags&VerifyNullFail != 0 && len(sig) > 0 {
+				return ErrNullFail
+			}
+			if opcode == OpCheckSigVerify {
+				if !success {
+					return ErrCheckSigVerify
+				}
+			} else {
+				main.pushBool(success)
+			}
+
+		case OpCheckSigAdd:
+			if !tapscript {
+				return ErrBadOpcode
+			}
+			if err := main.need(3); err != nil {
+				return err
+			}
+			pubKey, _ := main.pop()
+			n, err := main.popInt(requireMinimal)
+			if err != nil {
+				return err
+			}
+			sig, _ := main.pop()
+			success, err := checkTapscriptSignature(sig, pubKey, flags, checker, execution)
+			if err != nil {
+				return err
+			}
+			main.pushInt(n + boolInt(success))
+
+		case OpCheckMultiSig, OpCheckMultiSigVerify:
+			if tapscript {
+				return ErrTapscriptCheckMultiSig
+			}
+			success, err := checkMultisig(main, script[codeStart:], &opCount, flags, checker, version)
+			if err != nil {
+				return err
+			}
+			if opcode == OpCheckMultiSigVerify {
+				if !success {
+					return ErrCheckMultiSigVerify
+				}
+			} else {
+				main.pushBool(success)
+			}
+
+		default:
+			return ErrBadOpcode
+		}
+	}
+	if len(*main)+len(alt) > MaxStackSize {
+		return ErrStackSize
+	}
+	start = -1
+	if len(conditions) > 0 {
+		return ErrUnbalancedConditional
+	}
+	return nil
+}
+
+// checkLockTimeVerify executes OP_CHECKLOCKTIMEVERIFY, which leaves its
+// argument on the stack so the opcode stays a NOP for old nodes
+func checkLockTimeVerify(main stack, requireMinimal bool, checker SignatureChecker) error {
+	top, err := main.top(0)
+	if err != nil {
+		return err
+	}
+	lockTime, err := decodeScriptNum(top, requireMinimal, lockTimeNumSize)
+	if err != nil {
+		return err
+	}
+	if lockTime < 0 {
+		return ErrNegativeLockTime
+	}
+	if !checker.CheckLockTime(lockTime) {
+		return ErrUnsatisfiedLockTime
+	}
+	return nil
+}
+
+// checkSequenceVerify executes OP_CHECKSEQUENCEVERIFY. Arguments with the
+// disable flag set pass, reserved for future soft forks.
+func checkSequenceVerify(main stack, requireMinimal bool, checker SignatureChecker) error {
+	top, err := main.top(0)
+	if err != nil {
+		return err
+	}
+	sequence, err := decodeScriptNum(top, requireMinimal, lockTimeNumSize)
+	if err != nil {
+		return err
+	}
+	if sequence < 0 {
+		return ErrNegativeLockTime
+	}
+	if sequence&tx.SequenceLockTimeDisableFlag != 0 {
+		return nil
+	}
+	if !checker.CheckSequence(sequence) {
+		return ErrUnsatisfiedLockTime
+	}
+	return nil
+}
+
+func boolInt(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// checkTapscriptSignature executes the signature check of OP_CHECKSIG,
+// OP_CHECKSIGVERIFY and OP_CHECKSIGADD in a tapscript. An empty signature is
+// a failed check; any other must be valid, and spends validation weight.
+// Keys other than 32-byte x-only keys are reserved for soft forks, their
+// signatures passing unchecked.
+func checkTapscriptSignature(sig, pubKey []byte, flags Flags, checker SignatureChecker, execution *ExecutionData) (bool, error) {
+	success := len(sig) > 0
+	if success {
+		if execution.validationWeightLeft -= validationWeightPerSigOp; execution.validationWeightLeft < 0 {
+			return false, ErrTapscriptValidationWeight
+		}
+	}
+	switch len(pubKey) {
+	case 0:
+		return false, ErrTapscriptEmptyPubKey
+	case 32:
+		if success {
+			if err := checker.CheckSchnorrSignature(sig, pubKey, SigVersionTapscript, execution); err != nil {
+				return false, err
+			}
+		}
+	default:
+		if flags&VerifyDiscourageUpgradablePubKeyType != 0 {
+			return false, ErrDiscourageUpgradablePubKeyType
+		}
+	}
+	return success, nil
+}
+
+// checkMultisig executes OP_CHECKMULTISIG on the stack
+// <dummy> <sig>... <m> <pubkey>... <n>, consuming all of it. Signatures
+// must be in the order of their public keys; each key is tried once.
+func checkMultisig(main *stack, scriptCode []byte, opCount *int, flags Flags, checker SignatureChecker, version SigVersion) (bool, error) {
+	requireMinimal := flags&VerifyMinimalData != 0
+	keyCount, err := main.popInt(requireMinimal)
+	if err != nil {
+		return false, err
+	}
+	if keyCount < 0 || keyCount > maxPubKeysPerMultisig {
+		return false, ErrPubKeyCount
+	}
+	if *opCount += int(keyCount); *opCount > MaxOpsPerScript {
+		return false, ErrOpCount
+	}
+	if err := main.need(int(keyCount) + 1); err != nil {
+		return false, err
+	}
+	pubKeys := make([][]byte, keyCount)
+	for i := range pubKeys {
+		pubKeys[i], _ = main.pop() // from the last key to the first
+	}
+	sigCount, err := main.popInt(requireMinimal)
+	if err != nil {
+		return false, err
+	}
+	if sigCount < 0 || sigCount > keyCount {
+		return false, ErrSigCount
+	}
+	if err := main.need(int(sigCount)); err != nil {
+		return false, err
+	}
+	sigs := make([][]byte, sigCount)
+	for i := range sigs {
+		sigs[i], _ = main.pop() // from the last signature to the first
+	}
+
+	if version == SigVersionBase {
+		for _, sig := range sigs {
+			scriptCode = findAndDelete(scriptCode, pushData(sig))
+		}
+	}
+
+	// Match the last signature against the last key, walking down both as
+	// Bitcoin Core does, until too few keys are left for the signatures. Only
+	// the signatures and keys reached have their encodings checked.
+	success := true
+	for isig, ikey := 0, 0; success && isig < len(sigs); {
+		sig, pubKey := sigs[isig], pubKeys[ikey]
+		if err := checkSignatureEncoding(sig, flags); err != nil {
+			return false, err
+		}
+		if err := checkPubKeyEncoding(pubKey, flags, version); err != nil {
+			return false, err
+		}
+		if len(sig) > 0 && checker.CheckECDSASignature(sig, pubKey, scriptCode, version) {
+			isig++
+		}
+		ikey++
+		if len(sigs)-isig > len(pubKeys)-ikey {
+			success = false
+		}
+	}
+	if !success && flags&VerifyNullFail != 0 {
+		for _, sig := range sigs {
+			if len(sig) > 0 {
+				return false, ErrNullFail
+			}
+		}
+	}
+
+	dummy, err := main.pop()
+	if err != nil {
+		return false, err
+	}
+	if flags&VerifyNullDummy != 0 && len(dummy) != 0 {
+		return false, ErrNullDummy
+	}
+	return success, nil
+}
+
+// checkSignatureEncoding applies the DER, low S and sighash type rules to a
+// signature with its sighash type byte. Empty signatures are always allowed,
+// to fail a CHECKSIG on purpose.
+func checkSignatureEncoding(sig []byte, flags Flags) error {
+	if len(sig) == 0 {
+		return nil
+	}
+	if flags&(VerifyDERSig|VerifyLowS|VerifyStrictEnc) != 0 && !secp256k1.IsValidDER(sig[:len(sig)-1]) {
+		return ErrSigDER
+	}
+	if flags&VerifyLowS != 0 {
+		parsed, err := secp256k1.ParseDERSignature(sig[:len(sig)-1])
+		if err != nil {
+			return ErrSigDER
+		}
+		if !parsed.IsLowS() {
+			return ErrSigHighS
+		}
+	}
+	if flags&VerifyStrictEnc != 0 {
+		if baseType := sig[len(sig)-1] &^ 0x80; baseType < 1 || baseType > 3 {
+			return ErrSigHashType
+		}
+	}
+	return nil
+}
+
+// checkPubKeyEncoding applies the public key encoding rules
+func checkPubKeyEncoding(pubKey []byte, flags Flags, version SigVersion) error {
+	compressed := len(pubKey) == 33 && (pubKey[0] == 0x02 || pubKey[0] == 0x03)
+	uncompressed := len(pubKey) == 65 && pubKey[0] == 0x04
+	if flags&VerifyStrictEnc != 0 && !compressed && !uncompressed {
+		return ErrPubKeyType
+	}
+	if flags&VerifyWitnessPubKeyType != 0 && version == SigVersionWitnessV0 && !compressed {
+		return ErrWitnessPubKeyType
+	}
+	return nil
+}
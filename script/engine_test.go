@@ -0,0 +1,682 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// spentOutput decodes the output an input of a test vector spends
+func spentOutput(scriptPubKey string, value int64) tx.Prevout {
+	decoded, err := hex.DecodeString(scriptPubKey)
+	if err != nil {
+		panic(err)
+	}
+	return tx.Prevout{ScriptPubKey: decoded, Value: value}
+}
+
+// signedTransaction parses a signed transaction test vector and attaches the
+// outputs its inputs spend
+func signedTransaction(raw string, spent []tx.Prevout) tx.Transaction {
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		panic(err)
+	}
+	transaction, err := tx.Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	for i := range transaction.Vin {
+		transaction.Vin[i].PrevOut = spent[i]
+	}
+	return transaction
+}
+
+// verifyInput verifies input index of transaction against the output it spends
+func verifyInput(transaction tx.Transaction, index int, flags Flags) error {
+	vin := transaction.Vin[index]
+	witness := make([][]byte, len(vin.Witness))
+	for i, item := range vin.Witness {
+		witness[i] = item
+	}
+	checker := TxSignatureChecker{Tx: transaction, Index: index, Amount: vin.PrevOut.Value}
+	return VerifyScript(vin.ScriptSig, vin.PrevOut.ScriptPubKey, witness, flags, checker)
+}
+
+// tapscriptSpend is mempool transaction f19b49bb…, a script path spend of
+// <key> OP_CHECKSIG followed by an unexecuted inscription envelope, and
+// tapscriptSpent the output it spends
+const tapscriptSpend = "02000000000101890d3cdceaf5d5f8384fa15729952f4871b7827e5455831371b785e0d0d27afb0000000000fdffffff022601000000000000160014c8096bcd95b1bae34237d1d30544641cca8b58c7750400000000000016001464a67018b148ae07bc3ba4336c474d9aa037384c03406753dfadf55c6af1d32e48952aa5b04df58f3649ac027c0117e60c1c4b008ba42cd7e7eee5be0e284fb5067fd670baff151eed8f7802743021d5f8819e5aedb28120ab9d9f56b12de7a7eca1137a9384b3a63b99b79a58cd7282d3a9d071bdec4180ac0063036f7264010118746578742f706c61696e3b636861727365743d7574662d38003b7b2270223a226272632d3230222c226f70223a227472616e73666572222c227469636b223a2261616161222c22616d74223a22323330303030227d6821c184b07005f2b8816c6444608807c57c8c89f8e8a3befee88cb7bff634b9e1019900000000"
+
+var tapscriptSpent = []tx.Prevout{spentOutput("51208968ba754990d8162d10d7b520c3e022dd19f350c4bff2abe67eec9490d24c24", 4000)}
+
+// TestVerifyScriptSignedVectors verifies every input of the signed
+// transactions of the BIP143 examples and the BIP341 keyPathSpending wallet
+// test vectors and a mempool tapscript spend, then checks a change to the signed locktime invalidates them
+func TestVerifyScriptSignedVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		tx    string
+		spent []tx.Prevout
+		flags Flags
+	}{
+		{"BIP143 native P2WPKH", "01000000000102fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f00000000494830450221008b9d1dc26ba6a9cb62127b02742fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0a19c0489bc22ede944ccf4ecbab4cc618ef3ed01eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac000247304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee0121025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee635711000000", []tx.Prevout{
+			spentOutput("2103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432ac", 625000000),
+			spentOutput("00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1", 600000000),
+		}, StandardFlags},
+		{"BIP143 P2SH-P2WPKH", "01000000000101db6b1b20aa0fd7b23880be2ecbd4a98130974cf4748fb66092ac4d3ceb1a5477010000001716001479091972186c449eb1ded22b78e40d009bdf0089feffffff02b8b4eb0b000000001976a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac0008af2f000000001976a914fd270b1ee6abcaea97fea7ad0402e8bd8ad6d77c88ac02473044022047ac8e878352d3ebbde1c94ce3a10d057c24175747116f8288e5d794d12d482f0220217f36a485cae903c713331d877c1f64677e3622ad4010726870540656fe9dcb012103ad1d8e89212f0b92c74d23bb710c00662ad1470198ac48c43f7d6f93a2a2687392040000", []tx.Prevout{
+			spentOutput("a9144733f37cf4db86fbc2efed2500b4f4e49f31202387", 1000000000),
+		}, StandardFlags},
+		{"BIP143 P2WSH executed OP_CODESEPARATOR", "01000000000102fe3dc9208094f3ffd12645477b3dc56f60ec4fa8e6f5d67c565d1c6b9216b36e000000004847304402200af4e47c9b9629dbecc21f73af989bdaa911f7e6f6c2e9394588a3aa68f81e9902204f3fcf6ade7e5abb1295b6774c8e0abd94ae62217367096bc02ee5e435b67da201ffffffff0815cf020f013ed6cf91d29f4202e8a58726b1ac6c79da47c23d1bee0a6925f80000000000ffffffff0100f2052a010000001976a914a30741f8145e5acadf23f751864167f32e0963f788ac000347304402200de66acf4527789bfda55fc5459e214fa6083f936b430a762c629656216805ac0220396f550692cd347171cbc1ef1f51e15282e837bb2b30860dc77c8f78bc8501e503473044022027dc95ad6b740fe5129e7e62a75dd00f291a2aeb1200b84b09d9e3789406b6c002201a9ecd315dd6a0e632ab20bbb98948bc0c6fb204f2c286963bb48517a7058e27034721026dccc749adc2a9d0d89497ac511f760f45c47dc5ed9cf352a58ac706453880aeadab210255a9626aebf5e29c0e6538428ba0d1dcf6ca98ffdf086aa8ced5e0d0215ea465ac00000000", []tx.Prevout{
+			spentOutput("21036d5c20fa14fb2f635474c1dc4ef5909d4568e5569b79fc94d3448486e14685f8ac", 156250000),
+			spentOutput("00205d1b56b63d714eebe542309525f484b7e9d6f686b3781b6f61ef925d66d6f6a0", 4900000000),
+		}, StandardFlags},
+		{"BIP143 P2WSH unexecuted OP_CODESEPARATOR", "01000000000102e9b542c5176808107ff1df906f46bb1f2583b16112b95ee5380665ba7fcfc0010000000000ffffffff80e68831516392fcd100d186b3c2c7b95c80b53c77e77c35ba03a66b429a2a1b0000000000ffffffff0280969800000000001976a914de4b231626ef508c9a74a8517e6783c0546d6b2888ac80969800000000001976a9146648a8cd4531e1ec47f35916de8e259237294d1e88ac02483045022100f6a10b8604e6dc910194b79ccfc93e1bc0ec7c03453caaa8987f7d6c3413566002206216229ede9b4d6ec2d325be245c5b508ff0339bf1794078e20bfe0babc7ffe683270063ab68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac024730440220032521802a76ad7bf74d0e2c218b72cf0cbc867066e2e53db905ba37f130397e02207709e2188ed7f08f4c952d9d13986da504502b8c3be59617e043552f506c46ff83275163ab68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac00000000", []tx.Prevout{
+			spentOutput("0020ba468eea561b26301e4cf69fa34bde4ad60c81e70f059f045ca9a79931004a4d", 16777215),
+			spentOutput("0020d9bbfbe56af7c4b7f960a70d7ea107156913d9e5a26b0a71429df5e097ca6537", 16777215),
+		}, StandardFlags},
+		// SINGLE|ANYONECANPAY does not commit to the input index
+		{"BIP143 P2WSH swapped inputs", "0100000000010280e68831516392fcd100d186b3c2c7b95c80b53c77e77c35ba03a66b429a2a1b0000000000ffffffffe9b542c5176808107ff1df906f46bb1f2583b16112b95ee5380665ba7fcfc0010000000000ffffffff0280969800000000001976a9146648a8cd4531e1ec47f35916de8e259237294d1e88ac80969800000000001976a914de4b231626ef508c9a74a8517e6783c0546d6b2888ac024730440220032521802a76ad7bf74d0e2c218b72cf0cbc867066e2e53db905ba37f130397e02207709e2188ed7f08f4c952d9d13986da504502b8c3be59617e043552f506c46ff83275163ab68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac02483045022100f6a10b8604e6dc910194b79ccfc93e1bc0ec7c03453caaa8987f7d6c3413566002206216229ede9b4d6ec2d325be245c5b508ff0339bf1794078e20bfe0babc7ffe683270063ab68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac00000000", []tx.Prevout{
+			spentOutput("0020d9bbfbe56af7c4b7f960a70d7ea107156913d9e5a26b0a71429df5e097ca6537", 16777215),
+			spentOutput("0020ba468eea561b26301e4cf69fa34bde4ad60c81e70f059f045ca9a79931004a4d", 16777215),
+		}, StandardFlags},
+		{"BIP143 P2SH-P2WSH 6-of-6", "0100000000010136641869ca081e70f394c6948e8af409e18b619df2ed74aa106c1ca29787b96e0100000023220020a16b5755f7f6f96dbd65f5f0d6ab9418b89af4b1f14a1bb8a09062c35f0dcb54ffffffff0200e9a435000000001976a914389ffce9cd9ae88dcc0631e88a821ffdbe9bfe2688acc0832f05000000001976a9147480a33f950689af511e6e84c138dbbd3c3ee41588ac080047304402206ac44d672dac41f9b00e28f4df20c52eeb087207e8d758d76d92c6fab3b73e2b0220367750dbbe19290069cba53d096f44530e4f98acaa594810388cf7409a1870ce01473044022068c7946a43232757cbdf9176f009a928e1cd9a1a8c212f15c1e11ac9f2925d9002205b75f937ff2f9f3c1246e547e54f62e027f64eefa2695578cc6432cdabce271502473044022059ebf56d98010a932cf8ecfec54c48e6139ed6adb0728c09cbe1e4fa0915302e022007cd986c8fa870ff5d2b3a89139c9fe7e499259875357e20fcbb15571c76795403483045022100fbefd94bd0a488d50b79102b5dad4ab6ced30c4069f1eaa69a4b5a763414067e02203156c6a5c9cf88f91265f5a942e96213afae16d83321c8b31bb342142a14d16381483045022100a5263ea0553ba89221984bd7f0b13613db16e7a70c549a86de0cc0444141a407022005c360ef0ae5a5d4f9f2f87a56c1546cc8268cab08c73501d6b3be2e1e1a8a08824730440220525406a1482936d5a21888260dc165497a90a15669636d8edca6b9fe490d309c022032af0c646a34a44d1f4576bf6a4a74b67940f8faa84c7df9abe12a01a11e2b4783cf56210307b8ae49ac90a048e9b53357a2354b3334e9c8bee813ecb98e99a7e07e8c3ba32103b28f0c28bfab54554ae8c658ac5c3e0ce6e79ad336331f78c428dd43eea8449b21034b8113d703413d57761b8b9781957b8c0ac1dfe69f492580ca4195f50376ba4a21033400f6afecb833092a9a21cfdf1ed1376e58c5d1f47de74683123987e967a8f42103a6d48b1131e94ba04d9737d61acdaa1322008af9602b3b14862c07a1789aac162102d8b661b0b3302ee2f162b09e07a55ad5dfbe673a9f01d9f0c19617681024306b56ae00000000", []tx.Prevout{
+			spentOutput("a9149993a429037b5d912407a71c252019287b8d27a587", 987654321),
+		}, StandardFlags},
+		// The signatures of the FindAndDelete examples were fixed before their
+		// keys were recovered, and use the high S value policy rejects
+		{"BIP143 no FindAndDelete", "0100000000010169c12106097dc2e0526493ef67f21269fe888ef05c7a3a5dacab38e1ac8387f14c1d000000ffffffff01010000000000000000034830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e012102a9781d66b61fb5a7ef00ac5ad5bc6ffc78be7b44a566e3c87870e1079368df4c4aad4830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0100000000", []tx.Prevout{
+			spentOutput("00209e1be07558ea5cc8e02ed1d80c0911048afad949affa36d5c3951e3159dbea19", 200000),
+		}, ConsensusFlags},
+		{"BIP143 no FindAndDelete OP_CHECKMULTISIGVERIFY", "010000000001019275cb8d4a485ce95741c013f7c0d28722160008021bb469a11982d47a6628964c1d000000ffffffff0101000000000000000007004830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0148304502205286f726690b2e9b0207f0345711e63fa7012045b9eb0f19c2458ce1db90cf43022100e89f17f86abc5b149eba4115d4f128bcf45d77fb3ecdd34f594091340c0395960101022102966f109c54e85d3aee8321301136cedeb9fc710fdef58a9de8a73942f8e567c021034ffc99dd9a79dd3cb31e2ab3e0b09e0e67db41ac068c625cd1f491576016c84e9552af4830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0148304502205286f726690b2e9b0207f0345711e63fa7012045b9eb0f19c2458ce1db90cf43022100e89f17f86abc5b149eba4115d4f128bcf45d77fb3ecdd34f594091340c039596017500000000", []tx.Prevout{
+			spentOutput("00209b66c15b4e0b4eb49fa877982cafded24859fe5b0e2dbfbe4f0df1de7743fd52", 200000),
+		}, ConsensusFlags},
+		// Seven taproot key path spends of every sighash type, a P2PKH and a P2WPKH input
+		{"BIP341 keyPathSpending", "020000000001097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a41842000000006b4830450221008f3b8f8f0537c420654d2283673a761b7ee2ea3c130753103e08ce79201cf32a022079e7ab904a1980ef1c5890b648c8783f4d10103dd62f740d13daa79e298d50c201210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0141ed7c1647cb97379e76892be0cacff57ec4a7102aa24296ca39af7541246d8ff14d38958d4cc1e2e478e4d4a764bbfd835b16d4e314b72937b29833060b87276c030141052aedffc554b41f52b521071793a6b88d6dbca9dba94cf34c83696de0c1ec35ca9c5ed4ab28059bd606a4f3a657eec0bb96661d42921b5f50a95ad33675b54f83000141ff45f742a876139946a149ab4d9185574b98dc919d2eb6754f8abaa59d18b025637a3aa043b91817739554f4ed2026cf8022dbd83e351ce1fabc272841d2510a010140b4010dd48a617db09926f729e79c33ae0b4e94b79f04a1ae93ede6315eb3669de185a17d2b0ac9ee09fd4c64b678a0b61a0a86fa888a273c8511be83bfd6810f0247304402202b795e4de72646d76eab3f0ab27dfa30b810e856ff3a46c9a702df53bb0d8cc302203ccc4d822edab5f35caddb10af1be93583526ccfbade4b4ead350781e2f8adcd012102f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f90141a3785919a2ce3c4ce26f298c3d51619bc474ae24014bcdd31328cd8cfbab2eff3395fa0a16fe5f486d12f22a9cedded5ae74feb4bbe5351346508c5405bcfee0020141ea0c6ba90763c2d3a296ad82ba45881abb4f426b3f87af162dd24d5109edc1cdd11915095ba47c3a9963dc1e6c432939872bc49212fe34c632cd3ab9fed429c4820141bbc9584a11074e83bc8c6759ec55401f0ae7b03ef290c3139814f545b58a9f8127258000874f44bc46db7646322107d4d86aec8e73b8719a61fff761d75b5dd9810065cd1d", []tx.Prevout{
+			spentOutput("512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000),
+			spentOutput("5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000),
+			spentOutput("76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000),
+			spentOutput("5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000),
+			spentOutput("512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000),
+			spentOutput("00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000),
+			spentOutput("512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000),
+			spentOutput("5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000),
+			spentOutput("512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000),
+		}, StandardFlags},
+		{"mempool tapscript spend", tapscriptSpend, tapscriptSpent, StandardFlags},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transaction := signedTransaction(test.tx, test.spent)
+			for index := range transaction.Vin {
+				if err := verifyInput(transaction, index, test.flags); err != nil {
+					t.Errorf("input %d: %v", index, err)
+				}
+			}
+			// Every sighash type signs the locktime
+			transaction.Locktime++
+			for index := range transaction.Vin {
+				if err := verifyInput(transaction, index, test.flags); err == nil {
+					t.Errorf("input %d: changed locktime accepted", index)
+				}
+			}
+		})
+	}
+}
+
+// TestEvalCodeSeparatorPos checks a tapscript records the position of its last
+// executed OP_CODESEPARATOR, counting every instruction before it
+func TestEvalCodeSeparatorPos(t *testing.T) {
+	tests := []struct {
+		script string
+		want   uint32
+	}{
+		{"51", noCodeSeparator},
+		{"ab51", 0},
+		{"51ab51ab", 3},
+		{"02ababab", 1},
+		// OP_CODESEPARATOR in an unexecuted branch is not recorded
+		{"0063ab6851", noCodeSeparator},
+		{"5163ab6851", 2},
+	}
+	for _, test := range tests {
+		script, err := hex.DecodeString(test.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		execution := ExecutionData{Tapscript: &tx.TapscriptSpend{CodeSeparatorPos: noCodeSeparator}}
+		var main stack
+		if err := eval(&main, script, StandardFlags, nil, SigVersionTapscript, &execution); err != nil {
+			t.Fatalf("%s: %v", test.script, err)
+		}
+		if got := execution.Tapscript.CodeSeparatorPos; got != test.want {
+			t.Errorf("%s: got position %#x, want %#x", test.script, got, test.want)
+		}
+	}
+}
+
+// TestVerifyTapscriptSpend checks the tapscript of a script path spend is
+// executed once the control block commits to it
+func TestVerifyTapscriptSpend(t *testing.T) {
+	corrupted := func(witness []tx.HexBytes) []tx.HexBytes {
+		witness[0] = append([]byte(nil), witness[0]...)
+		witness[0][5] ^= 1
+		return witness
+	}
+	tests := []struct {
+		name   string
+		tamper func(witness []tx.HexBytes) []tx.HexBytes
+		want   error
+	}{
+		{"as mined", func(witness []tx.HexBytes) []tx.HexBytes { return witness }, nil},
+		{"corrupted signature", corrupted, ErrSchnorrSig},
+		// An empty signature is a failed check, leaving false on the stack
+		{"empty signature", func(witness []tx.HexBytes) []tx.HexBytes {
+			witness[0] = nil
+			return witness
+		}, ErrEvalFalse},
+		{"signature with an undefined sighash type", func(witness []tx.HexBytes) []tx.HexBytes {
+			witness[0] = append(append([]byte(nil), witness[0]...), 0x04)
+			return witness
+		}, ErrSchnorrSigHashType},
+		// The annex is left out of the script inputs but signed, and the
+		// signature does not commit to one
+		{"added annex", func(witness []tx.HexBytes) []tx.HexBytes {
+			return append(witness, tx.HexBytes{annexTag})
+		}, ErrSchnorrSig},
+	}
+	for _, test := range tests {
+		transaction := signedTransaction(tapscriptSpend, tapscriptSpent)
+		vin := &transaction.Vin[0]
+		vin.Witness = append(vin.Witness[:0:0], vin.Witness...)
+		vin.Witness = test.tamper(vin.Witness)
+		for _, flags := range []Flags{ConsensusFlags, StandardFlags} {
+			if err := verifyInput(transaction, 0, flags); !errors.Is(err, test.want) {
+				t.Errorf("%s, flags %#x: got %v, want %v", test.name, flags, err, test.want)
+			}
+		}
+	}
+}
+
+// tapscriptChecker accepts the Schnorr signatures starting with 1 and counts
+// the signatures it is asked to check. With last set, it records the
+// execution data of the last one.
+type tapscriptChecker struct {
+	checked *int
+	last    *ExecutionData
+}
+
+func (tapscriptChecker) CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool {
+	return false
+}
+
+func (tapscriptChecker) CheckLockTime(int64) bool { return false }
+
+func (tapscriptChecker) CheckSequence(int64) bool { return false }
+
+func (c tapscriptChecker) CheckSchnorrSignature(sig, pubKey []byte, version SigVersion, execution *ExecutionData) error {
+	*c.checked++
+	if c.last != nil {
+		*c.last = *execution
+	}
+	if version != SigVersionTapscript || execution.Tapscript == nil {
+		return fmt.Errorf("signature checked as %d", version)
+	}
+	if sig[0] != 1 {
+		return ErrSchnorrSig
+	}
+	return nil
+}
+
+// TestExecuteTapscript checks the rules BIP342 changes for tapscripts
+func TestExecuteTapscript(t *testing.T) {
+	valid, invalid := append([]byte{1}, make([]byte, 63)...), make([]byte, 64)
+	key := "20" + strings.Repeat("11", 32)
+	tests := []struct {
+		name    string
+		initial [][]byte
+		script  string
+		flags   Flags
+		weight  int64 // validation weight left, 1000 if zero
+		checked int   // signatures given to the checker
+		want    error
+	}{
+		{"checksig", [][]byte{valid}, key + "ac", StandardFlags, 0, 1, nil},
+		{"checksig with an invalid signature", [][]byte{invalid}, key + "ac", ConsensusFlags, 0, 1, ErrSchnorrSig},
+		{"checksig with an empty signature", [][]byte{nil}, key + "ac", StandardFlags, 0, 0, ErrEvalFalse},
+		{"checksigverify with an empty signature", [][]byte{nil}, key + "ad51", StandardFlags, 0, 0, ErrCheckSigVerify},
+		{"checksig with an empty key", [][]byte{valid}, "00ac", ConsensusFlags, 0, 0, ErrTapscriptEmptyPubKey},
+		// Keys of other sizes are unknown types, reserved for soft forks
+		{"checksig with an unknown key type", [][]byte{valid}, "020202ac", ConsensusFlags, 0, 0, nil},
+		{"checksig with a discouraged key type", [][]byte{valid}, "020202ac", StandardFlags, 0, 0, ErrDiscourageUpgradablePubKeyType},
+		// 2-of-2: <key> OP_CHECKSIG <key> OP_CHECKSIGADD OP_2 OP_NUMEQUAL
+		{"checksigadd 2-of-2", [][]byte{valid, valid}, key + "ac" + key + "ba5287", StandardFlags, 0, 2, nil},
+		{"checksigadd 1-of-2", [][]byte{nil, valid}, key + "ac" + key + "ba5187", StandardFlags, 0, 1, nil},
+		{"checksigadd with an invalid signature", [][]byte{invalid, valid}, key + "ac" + key + "ba5187", ConsensusFlags, 0, 2, ErrSchnorrSig},
+		{"checksigadd with too few elements", nil, "00" + key + "ba", ConsensusFlags, 0, 0, ErrInvalidStackOperation},
+		{"checksigadd with a 5-byte number", [][]byte{valid}, "050000000080" + key + "ba", ConsensusFlags, 0, 0, ErrNumberOverflow},
+		{"checkmultisig", nil, "000000ae", ConsensusFlags, 0, 0, ErrTapscriptCheckMultiSig},
+		// Each non-empty signature costs 50
+		{"validation weight of two signatures", [][]byte{valid, valid}, key + "ac" + key + "ba5287", ConsensusFlags, 100, 2, nil},
+		{"validation weight exceeded", [][]byte{valid, valid}, key + "ac" + key + "ba5287", ConsensusFlags, 99, 1, ErrTapscriptValidationWeight},
+		{"empty signatures cost no validation weight", [][]byte{nil, nil}, key + "ac" + key + "ba0087", ConsensusFlags, 1, 0, nil},
+		{"minimal if", [][]byte{{2}}, "635168", ConsensusFlags &^ VerifyMinimalIf, 0, 0, ErrTapscriptMinimalIf},
+		// OP_SUCCESSx makes the script succeed before anything else is checked
+		{"op_success", [][]byte{invalid}, key + "ac50", ConsensusFlags, 0, 0, nil},
+		{"op_success in an unexecuted branch", nil, "00635068", ConsensusFlags, 0, 0, nil},
+		{"op_success before a truncated push", nil, "bb4c", ConsensusFlags, 0, 0, nil},
+		{"discouraged op_success", nil, "fe", StandardFlags, 0, 0, ErrDiscourageOpSuccess},
+		{"truncated push before op_success", nil, "4cbb", ConsensusFlags, 0, 0, ErrBadOpcode},
+		{"disabled opcode made op_success", [][]byte{{1}, {1}}, "7e", ConsensusFlags, 0, 0, nil},
+		// The script size and opcode count limits do not apply
+		{"10001-byte script", nil, strings.Repeat("61", 10000) + "51", StandardFlags, 0, 0, nil},
+		{"too many stack elements", make([][]byte, MaxStackSize+1), "75", ConsensusFlags, 0, 0, ErrStackSize},
+		{"oversized stack element", [][]byte{make([]byte, MaxScriptElementSize+1)}, "7551", ConsensusFlags, 0, 0, ErrPushSize},
+		{"unclean stack", [][]byte{{1}}, "51", ConsensusFlags, 0, 0, ErrCleanStack},
+	}
+	for _, test := range tests {
+		script, err := hex.DecodeString(test.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		execution := ExecutionData{Tapscript: &tx.TapscriptSpend{CodeSeparatorPos: noCodeSeparator}, validationWeightLeft: 1000}
+		if test.weight != 0 {
+			execution.validationWeightLeft = test.weight
+		}
+		checked := 0
+		err = executeWitnessScript(append(stack(nil), test.initial...), script, test.flags, tapscriptChecker{checked: &checked}, SigVersionTapscript, &execution)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+		if checked != test.checked {
+			t.Errorf("%s: checked %d signatures, want %d", test.name, checked, test.checked)
+		}
+	}
+}
+
+// TestVerifyTaprootScriptPath spends leaves of the BIP341 scriptPubKey wallet
+// test vectors: a tapscript pushing "Taproot", and a leaf of the unknown
+// version 0xfa pushing "BIP341", which succeeds unexecuted
+func TestVerifyTaprootScriptPath(t *testing.T) {
+	const (
+		tapscriptOutput = "512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220"
+		tapscriptLeaf   = "07546170726f6f74"
+		tapscriptBlock  = "c1f9f400803e683727b14f463836e1e78e1c64417638aa066919291a225f0e8dd864512fecdb5afa04f98839b50e6f0cb7b1e539bf6f205f67934083cdcc3c8d89"
+		unknownOutput   = "5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5"
+		unknownLeaf     = "06424950333431"
+		unknownBlock    = "faee4fe085983462a184015d1f782d6a5f8b9c2b60130aff050ce221ecf37865928ad69ec7cf41c2a4001fd1f738bf1e505ce2277acdcaa63fe4765192497f47a7"
+		// The unknown leaf claimed as a tapscript commits to another leaf hash
+		unknownAsTapscript = "c0ee4fe085983462a184015d1f782d6a5f8b9c2b60130aff050ce221ecf37865928ad69ec7cf41c2a4001fd1f738bf1e505ce2277acdcaa63fe4765192497f47a7"
+	)
+	tests := []struct {
+		name         string
+		scriptPubKey string
+		witness      []string
+		flags        Flags
+		want         error
+	}{
+		{"tapscript", tapscriptOutput, []string{tapscriptLeaf, tapscriptBlock}, StandardFlags, nil},
+		{"tapscript with an annex", tapscriptOutput, []string{tapscriptLeaf, tapscriptBlock, "50"}, StandardFlags, nil},
+		{"tapscript with an annex of data", tapscriptOutput, []string{tapscriptLeaf, tapscriptBlock, "50ac00"}, StandardFlags, nil},
+		{"tapscript with an extra input", tapscriptOutput, []string{"01", tapscriptLeaf, tapscriptBlock}, ConsensusFlags, ErrCleanStack},
+		// Only a last element starting with 0x50 is an annex; here it is the control block
+		{"tapscript with the annex first", tapscriptOutput, []string{"50", tapscriptLeaf, tapscriptBlock}, ConsensusFlags, ErrCleanStack},
+		{"unknown leaf version", unknownOutput, []string{unknownLeaf, unknownBlock}, ConsensusFlags, nil},
+		{"unknown leaf version with an annex", unknownOutput, []string{unknownLeaf, unknownBlock, "50"}, ConsensusFlags, nil},
+		{"unknown leaf version with inputs", unknownOutput, []string{"", "01", unknownLeaf, unknownBlock}, ConsensusFlags, nil},
+		{"discouraged unknown leaf version", unknownOutput, []string{unknownLeaf, unknownBlock}, StandardFlags, ErrDiscourageUpgradableTaprootVersion},
+		{"unknown leaf as a tapscript", unknownOutput, []string{unknownLeaf, unknownAsTapscript}, ConsensusFlags, ErrWitnessProgramMismatch},
+	}
+	for _, test := range tests {
+		scriptPubKey, err := hex.DecodeString(test.scriptPubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness := make([][]byte, len(test.witness))
+		for i, item := range test.witness {
+			if witness[i], err = hex.DecodeString(item); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checked := 0
+		if err := VerifyScript(nil, scriptPubKey, witness, test.flags, tapscriptChecker{checked: &checked}); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// TestVerifyTaprootScriptPathExecutionData checks the signatures of a
+// tapscript commit to the annex and the leaf, and that the annex counts in
+// the validation weight. The leaf is <key> OP_CHECKSIG from the BIP341
+// scriptPubKey wallet test vectors.
+func TestVerifyTaprootScriptPathExecutionData(t *testing.T) {
+	scriptPubKey, _ := hex.DecodeString("5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3")
+	leaf, _ := hex.DecodeString("20d85a959b0290bf19bb89ed43c916be835475d013da4b362117393e25a48229b8ac")
+	control, _ := hex.DecodeString("c1187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27")
+	leafHash, _ := hex.DecodeString("5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21")
+	sig := append([]byte{1}, make([]byte, 63)...)
+	tests := []struct {
+		name   string
+		annex  []byte
+		weight int64 // validation weight left after the signature
+	}{
+		// The witness takes 1 + 65 + 35 + 34 bytes, plus the 50 of the
+		// offset, less the 50 of the signature
+		{"no annex", nil, 135},
+		{"annex", []byte{annexTag}, 135 + 2},
+		{"annex of data", append([]byte{annexTag}, make([]byte, 299)...), 135 + 3 + 300},
+	}
+	for _, test := range tests {
+		witness := [][]byte{sig, leaf, control}
+		if test.annex != nil {
+			witness = append(witness, test.annex)
+		}
+		checked := 0
+		var last ExecutionData
+		if err := VerifyScript(nil, scriptPubKey, witness, StandardFlags, tapscriptChecker{checked: &checked, last: &last}); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if checked != 1 {
+			t.Fatalf("%s: checked %d signatures, want 1", test.name, checked)
+		}
+		if !bytes.Equal(last.Annex, test.annex) {
+			t.Errorf("%s: signature committed to annex %x, want %x", test.name, last.Annex, test.annex)
+		}
+		if !bytes.Equal(last.Tapscript.LeafHash[:], leafHash) || last.Tapscript.CodeSeparatorPos != noCodeSeparator {
+			t.Errorf("%s: signature committed to leaf %x, position %#x", test.name, last.Tapscript.LeafHash, last.Tapscript.CodeSeparatorPos)
+		}
+		if last.validationWeightLeft != test.weight {
+			t.Errorf("%s: %d validation weight left, want %d", test.name, last.validationWeightLeft, test.weight)
+		}
+	}
+}
+
+// TestCheckSigAddOutsideTapscript checks OP_CHECKSIGADD is an invalid opcode
+// in legacy and witness v0 scripts
+func TestCheckSigAddOutsideTapscript(t *testing.T) {
+	for _, version := range []SigVersion{SigVersionBase, SigVersionWitnessV0} {
+		main := stack{{1}, {0}, {2}}
+		if err := eval(&main, []byte{OpCheckSigAdd}, ConsensusFlags, nil, version, nil); !errors.Is(err, ErrBadOpcode) {
+			t.Errorf("version %d: got %v", version, err)
+		}
+	}
+}
+
+// keyChecker accepts every ECDSA signature by one public key
+type keyChecker struct {
+	tapscriptChecker
+	key []byte
+}
+
+func (c keyChecker) CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool {
+	return bytes.Equal(pubKey, c.key)
+}
+
+// TestCheckMultisigEncodingOrder checks OP_CHECKMULTISIG checks the encodings
+// of the keys it reaches from the last one down, as Bitcoin Core does: with
+// the last key signing a 1-of-2, the first is never reached
+func TestCheckMultisigEncodingOrder(t *testing.T) {
+	valid := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	malformed := append([]byte{0x05}, bytes.Repeat([]byte{0x22}, 32)...)
+	sig, _ := hex.DecodeString("300602010102010101") // r = s = 1, SIGHASH_ALL
+	tests := []struct {
+		name       string
+		key1, key2 []byte
+		want       error
+	}{
+		{"signed by the last key, first key malformed", malformed, valid, nil},
+		{"signed by the first key, last key malformed", valid, malformed, ErrPubKeyType},
+		{"signed by the first key", valid, append([]byte{0x03}, valid[1:]...), nil},
+	}
+	for _, test := range tests {
+		scriptPubKey := new(Builder).AddOp(Op1).AddData(test.key1).AddData(test.key2).AddOp(Op1 + 1).AddOp(OpCheckMultiSig).Script()
+		scriptSig := new(Builder).AddOp(Op0).AddData(sig).Script()
+		err := VerifyScript(scriptSig, scriptPubKey, nil, StandardFlags, keyChecker{key: valid})
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// indexChecker accepts a one-byte signature i for the public key made of the
+// byte i, without any cryptography
+type indexChecker struct{}
+
+func (indexChecker) CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool {
+	return len(sig) == 1 && pubKey[1] == sig[0]
+}
+
+func (indexChecker) CheckLockTime(int64) bool { return false }
+
+func (indexChecker) CheckSequence(int64) bool { return false }
+
+func (indexChecker) CheckSchnorrSignature([]byte, []byte, SigVersion, *ExecutionData) error {
+	return ErrSchnorrSig
+}
+
+// indexKey is the public key indexChecker accepts the signature i for
+func indexKey(i byte) []byte {
+	return append([]byte{0x02}, bytes.Repeat([]byte{i}, 32)...)
+}
+
+// TestCheckMultisig spends a 2-of-3 bare multisig and a P2PK output
+func TestCheckMultisig(t *testing.T) {
+	scriptPubKey := new(Builder).AddOp(Op1 + 1).
+		AddData(indexKey(1)).AddData(indexKey(2)).AddData(indexKey(3)).
+		AddOp(Op1 + 2).AddOp(OpCheckMultiSig).Script()
+	p2pk := new(Builder).AddData(indexKey(1)).AddOp(OpCheckSig).Script()
+	flags := VerifyP2SH | VerifyNullDummy
+	tests := []struct {
+		name         string
+		scriptSig    []byte
+		scriptPubKey []byte
+		want         error
+	}{
+		{"signatures in key order", new(Builder).AddOp(Op0).AddData([]byte{1}).AddData([]byte{3}).Script(), scriptPubKey, nil},
+		{"signatures out of key order", new(Builder).AddOp(Op0).AddData([]byte{3}).AddData([]byte{1}).Script(), scriptPubKey, ErrEvalFalse},
+		{"non-empty dummy", new(Builder).AddData([]byte{1}).AddData([]byte{1}).AddData([]byte{3}).Script(), scriptPubKey, ErrNullDummy},
+		{"P2PK", []byte{1, 1}, p2pk, nil},
+		{"P2PK with the wrong signature", []byte{1, 2}, p2pk, ErrEvalFalse},
+	}
+	for _, test := range tests {
+		if err := VerifyScript(test.scriptSig, test.scriptPubKey, nil, flags, indexChecker{}); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// scriptCodeRecorder accepts every signature, keeping the scriptCode last signed
+type scriptCodeRecorder struct {
+	indexChecker
+	scriptCode *[]byte
+}
+
+func (r scriptCodeRecorder) CheckECDSASignature(sig, pubKey, scriptCode []byte, version SigVersion) bool {
+	*r.scriptCode = scriptCode
+	return true
+}
+
+// Signatures commit to the script after the last executed OP_CODESEPARATOR
+func TestCodeSeparatorScriptCode(t *testing.T) {
+	tests := []struct{ asm, scriptCode string }{
+		{"OP_CODESEPARATOR OP_PUSHBYTES_1 01 OP_CHECKSIG", "0101ac"},
+		{"OP_PUSHNUM_1 OP_IF OP_CODESEPARATOR OP_ENDIF OP_PUSHBYTES_1 01 OP_CHECKSIG", "680101ac"},
+		{"OP_0 OP_IF OP_CODESEPARATOR OP_ENDIF OP_PUSHBYTES_1 01 OP_CHECKSIG", "0063ab680101ac"},
+	}
+	for _, test := range tests {
+		scriptPubKey, err := Parse(test.asm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var scriptCode []byte
+		if err := VerifyScript([]byte{1, 2}, scriptPubKey, nil, 0, scriptCodeRecorder{scriptCode: &scriptCode}); err != nil {
+			t.Errorf("%s: %v", test.asm, err)
+		} else if got := hex.EncodeToString(scriptCode); got != test.scriptCode {
+			t.Errorf("%s: signed scriptCode %s, want %s", test.asm, got, test.scriptCode)
+		}
+	}
+}
+
+// TestMinimalData checks non-minimal pushes and numbers break MINIMALDATA only
+func TestMinimalData(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want error // under MINIMALDATA; every script is valid without it
+	}{
+		{"0107" + "57" + "87", ErrMinimalData},               // OP_PUSHBYTES_1 07 for OP_7
+		{"4c0107" + "0107" + "87", ErrMinimalData},           // OP_PUSHDATA1 of one byte
+		{"020100" + "8b" + "52" + "87", ErrNonMinimalNumber}, // 1 padded to two bytes, OP_1ADD
+		{"57" + "57" + "87", nil},
+	}
+	for _, test := range tests {
+		scriptPubKey, _ := hex.DecodeString(test.hex)
+		if err := VerifyScript(nil, scriptPubKey, nil, StandardFlags, indexChecker{}); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.hex, err, test.want)
+		}
+		if err := VerifyScript(nil, scriptPubKey, nil, StandardFlags&^VerifyMinimalData, indexChecker{}); err != nil {
+			t.Errorf("%s without MINIMALDATA: %v", test.hex, err)
+		}
+	}
+}
+
+// TestCleanStack checks extra stack elements are nonstandard in legacy
+// scripts and break consensus in a P2WSH witness script
+func TestCleanStack(t *testing.T) {
+	// OP_1 OP_1 satisfies OP_1 but leaves two elements
+	scriptSig, scriptPubKey := []byte{Op1}, []byte{Op1}
+	if err := VerifyScript(scriptSig, scriptPubKey, nil, StandardFlags, indexChecker{}); !errors.Is(err, ErrCleanStack) {
+		t.Errorf("legacy spend: got %v", err)
+	}
+	if err := VerifyScript(scriptSig, scriptPubKey, nil, ConsensusFlags, indexChecker{}); err != nil {
+		t.Errorf("legacy spend under consensus rules: %v", err)
+	}
+	witnessScript := []byte{Op1}
+	hash := sha256.Sum256(witnessScript)
+	p2wsh := append([]byte{Op0, 32}, hash[:]...)
+	if err := VerifyScript(nil, p2wsh, [][]byte{{1}, witnessScript}, ConsensusFlags, indexChecker{}); !errors.Is(err, ErrCleanStack) {
+		t.Errorf("P2WSH spend: got %v", err)
+	}
+}
+
+// TestWitnessPrograms checks malformed v0 programs and that unknown versions
+// are valid but nonstandard
+func TestWitnessPrograms(t *testing.T) {
+	program := func(version byte, size int) []byte {
+		return append([]byte{version, byte(size)}, bytes.Repeat([]byte{0x11}, size)...)
+	}
+	tests := []struct {
+		scriptSig, scriptPubKey []byte
+		flags                   Flags
+		want                    error
+	}{
+		{nil, program(Op0, 16), ConsensusFlags, ErrWitnessProgramWrongLength},
+		{[]byte{Op1}, program(Op0, 20), ConsensusFlags, ErrWitnessMalleated},
+		{nil, program(Op1+1, 32), ConsensusFlags, nil},
+		{nil, program(Op1+1, 32), StandardFlags, ErrDiscourageUpgradableWitnessProgram},
+		{nil, program(Op1, 20), StandardFlags, ErrDiscourageUpgradableWitnessProgram},
+	}
+	for _, test := range tests {
+		if err := VerifyScript(test.scriptSig, test.scriptPubKey, [][]byte{{1}}, test.flags, indexChecker{}); !errors.Is(err, test.want) {
+			t.Errorf("%x: got %v, want %v", test.scriptPubKey, err, test.want)
+		}
+	}
+}
+
+// TestLimits checks each script limit at the largest size allowed and one past it
+func TestLimits(t *testing.T) {
+	verify := func(scriptPubKey []byte) error {
+		return VerifyScript(nil, scriptPubKey, nil, ConsensusFlags, indexChecker{})
+	}
+	nops := func(n int) []byte { return append(bytes.Repeat([]byte{OpNop}, n), Op1) }
+	push := func(n int) []byte {
+		return new(Builder).AddData(make([]byte, n)).AddOp(OpDrop).AddOp(Op1).Script()
+	}
+	ones := func(n int) []byte { return bytes.Repeat([]byte{Op1}, n) }
+	tests := []struct {
+		name         string
+		scriptPubKey []byte
+		want         error
+	}{
+		{"201 OP_NOPs", nops(201), nil}, // OP_1 is a push and does not count
+		{"202 OP_NOPs", nops(202), ErrOpCount},
+		{"520-byte push", push(MaxScriptElementSize), nil},
+		{"521-byte push", push(MaxScriptElementSize + 1), ErrPushSize},
+		{"10001-byte script", nops(10000), ErrScriptSize},
+		{"1000 stack elements", ones(1000), nil},
+		{"1001 stack elements", ones(1001), ErrStackSize},
+		{"1000 elements and one on the alternate stack", append(ones(1000), OpToAltStack, Op1), ErrStackSize},
+	}
+	for _, test := range tests {
+		if err := verify(test.scriptPubKey); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+	// Errors name the instruction that raised them
+	if err := verify(nops(202)); err == nil || !strings.HasSuffix(err.Error(), "(OP_NOP at byte 201)") {
+		t.Errorf("op count error %q does not name the 202nd OP_NOP", err)
+	}
+}
+
+// A mainnet key path spend, which an annex must change the signature hash of
+func TestVerifyTaprootKeyPath(t *testing.T) {
+	output, _ := hex.DecodeString("00143919d928e617770c9b365142cb95d85743baba29")
+	transaction := tx.Transaction{
+		Version: 2,
+		Vin: []tx.TxInput{{
+			Txid:     "db62a18ca041349736b8a744ed1c040a60daefea86b77b49fbe281ae4b244669",
+			Sequence: 0xffffffff,
+			PrevOut:  spentOutput("51205b82158f27e4580131f4cfbb16a6a96cdc4856d651c56f42cb943122a40db9d1", 5000),
+		}},
+		Vout: []tx.TxOutput{{ScriptPubKey: output, Value: 3792}},
+	}
+	scriptPubKey := transaction.Vin[0].PrevOut.ScriptPubKey
+	sig, _ := hex.DecodeString("c28b45ad734b33343cdd8fcf3b030c6b6734baa65841504a4fc78bb8c78305a2feaf189fdc247a6de9218b13ca27d82e88231e64432a08be9308a0a0d8a744b701")
+	cache := NewSigCache(0)
+	checker := TxSignatureChecker{Tx: transaction, Amount: 5000, Cache: cache}
+	if err := VerifyScript(nil, scriptPubKey, [][]byte{sig}, StandardFlags, checker); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("verified signature not cached, cache holds %d", cache.Len())
+	}
+	if err := VerifyScript(nil, scriptPubKey, [][]byte{sig, {0x50}}, StandardFlags, checker); !errors.Is(err, ErrSchnorrSig) {
+		t.Errorf("key path spend with an annex: got %v", err)
+	}
+	explicitDefault := append(append([]byte(nil), sig[:64]...), tx.SigHashDefault)
+	if err := VerifyScript(nil, scriptPubKey, [][]byte{explicitDefault}, StandardFlags, checker); !errors.Is(err, ErrSchnorrSigHashType) {
+		t.Errorf("explicit SIGHASH_DEFAULT: got %v", err)
+	}
+}
+
+// A mainnet script path spend of <key> OP_CHECKSIG at the root of its tree,
+// with its control block intact and tampered with
+func TestVerifyTaprootControlBlock(t *testing.T) {
+	scriptPubKey, _ := hex.DecodeString("51208bf039717af29d3c10c872448e41f6c2ab034c6100f6445c0580cc2457d63405")
+	tapScript, _ := hex.DecodeString("20d8e9b8e4a359220c1e3c5a92a292b113b9cf4eb7645bb01dddadeacd6718ae28ac")
+	control, _ := hex.DecodeString("c1d8e9b8e4a359220c1e3c5a92a292b113b9cf4eb7645bb01dddadeacd6718ae28")
+	tests := []struct {
+		name    string
+		control []byte
+		want    error
+	}{
+		// The commitment holds, so the tapscript runs, and its signature, which
+		// indexChecker never accepts, fails the spend
+		{"committed tapscript", control, ErrSchnorrSig},
+		{"wrong parity", append([]byte{control[0] ^ 1}, control[1:]...), ErrWitnessProgramMismatch},
+		{"extra merkle node", append(append([]byte(nil), control...), make([]byte, 32)...), ErrWitnessProgramMismatch},
+		{"32-byte control block", control[:32], ErrTaprootWrongControlSize},
+		{"34-byte control block", append(append([]byte(nil), control...), 0), ErrTaprootWrongControlSize},
+	}
+	for _, test := range tests {
+		err := VerifyScript(nil, scriptPubKey, [][]byte{{1}, tapScript, test.control}, StandardFlags, indexChecker{})
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
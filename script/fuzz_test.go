@@ -0,0 +1,33 @@
+package script
+
+import (
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// FuzzVerifyScript verifies input index of a raw transaction against a
+// scriptPubKey worth amount satoshis, under the standard and the consensus
+// rules. The standard rules only add restrictions, so a spend they accept the
+// consensus rules must accept too. The corpus in testdata/fuzz holds valid
+// mempool spends of each output type.
+func FuzzVerifyScript(f *testing.F) {
+	f.Fuzz(func(t *testing.T, rawTx []byte, index uint, scriptPubKey []byte, amount int64) {
+		transaction, err := tx.Parse(rawTx)
+		if err != nil || len(transaction.Vin) == 0 {
+			return
+		}
+		index %= uint(len(transaction.Vin))
+		vin := transaction.Vin[index]
+		witness := make([][]byte, len(vin.Witness))
+		for i, item := range vin.Witness {
+			witness[i] = item
+		}
+		checker := TxSignatureChecker{Tx: transaction, Index: int(index), Amount: amount}
+		standard := VerifyScript(vin.ScriptSig, scriptPubKey, witness, StandardFlags, checker)
+		consensus := VerifyScript(vin.ScriptSig, scriptPubKey, witness, ConsensusFlags, checker)
+		if standard == nil && consensus != nil {
+			t.Fatalf("standard rules accept the spend, consensus rules reject it: %v", consensus)
+		}
+	})
+}
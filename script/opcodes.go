@@ -0,0 +1,161 @@
+// Package script parses, disassembles, assembles and evaluates Bitcoin scripts.
+package script
+
+import "fmt"
+
+// Opcodes with a name of their own. Small pushes and the numbers 1 through 16
+// are written as OP_PUSHBYTES_n and OP_PUSHNUM_n in disassembly.
+const (
+	Op0         = 0x00
+	OpPushData1 = 0x4c
+	OpPushData2 = 0x4d
+	OpPushData4 = 0x4e
+	Op1Negate   = 0x4f
+	OpReserved  = 0x50
+	Op1         = 0x51
+	Op16        = 0x60
+
+	OpNop      = 0x61
+	OpVer      = 0x62
+	OpIf       = 0x63
+	OpNotIf    = 0x64
+	OpVerIf    = 0x65
+	OpVerNotIf = 0x66
+	OpElse     = 0x67
+	OpEndIf    = 0x68
+	OpVerify   = 0x69
+	OpReturn   = 0x6a
+
+	OpToAltStack   = 0x6b
+	OpFromAltStack = 0x6c
+	Op2Drop        = 0x6d
+	Op2Dup         = 0x6e
+	Op3Dup         = 0x6f
+	Op2Over        = 0x70
+	Op2Rot         = 0x71
+	Op2Swap        = 0x72
+	OpIfDup        = 0x73
+	OpDepth        = 0x74
+	OpDrop         = 0x75
+	OpDup          = 0x76
+	OpNip          = 0x77
+	OpOver         = 0x78
+	OpPick         = 0x79
+	OpRoll         = 0x7a
+	OpRot          = 0x7b
+	OpSwap         = 0x7c
+	OpTuck         = 0x7d
+
+	OpCat    = 0x7e
+	OpSubstr = 0x7f
+	OpLeft   = 0x80
+	OpRight  = 0x81
+	OpSize   = 0x82
+
+	OpInvert      = 0x83
+	OpAnd         = 0x84
+	OpOr          = 0x85
+	OpXor         = 0x86
+	OpEqual       = 0x87
+	OpEqualVerify = 0x88
+	OpReserved1   = 0x89
+	OpReserved2   = 0x8a
+
+	Op1Add               = 0x8b
+	Op1Sub               = 0x8c
+	Op2Mul               = 0x8d
+	Op2Div               = 0x8e
+	OpNegate             = 0x8f
+	OpAbs                = 0x90
+	OpNot                = 0x91
+	Op0NotEqual          = 0x92
+	OpAdd                = 0x93
+	OpSub                = 0x94
+	OpMul                = 0x95
+	OpDiv                = 0x96
+	OpMod                = 0x97
+	OpLShift             = 0x98
+	OpRShift             = 0x99
+	OpBoolAnd            = 0x9a
+	OpBoolOr             = 0x9b
+	OpNumEqual           = 0x9c
+	OpNumEqualVerify     = 0x9d
+	OpNumNotEqual        = 0x9e
+	OpLessThan           = 0x9f
+	OpGreaterThan        = 0xa0
+	OpLessThanOrEqual    = 0xa1
+	OpGreaterThanOrEqual = 0xa2
+	OpMin                = 0xa3
+	OpMax                = 0xa4
+	OpWithin             = 0xa5
+
+	OpRipemd160           = 0xa6
+	OpSha1                = 0xa7
+	OpSha256              = 0xa8
+	OpHash160             = 0xa9
+	OpHash256             = 0xaa
+	OpCodeSeparator       = 0xab
+	OpCheckSig            = 0xac
+	OpCheckSigVerify      = 0xad
+	OpCheckMultiSig       = 0xae
+	OpCheckMultiSigVerify = 0xaf
+
+	OpNop1                = 0xb0
+	OpCheckLockTimeVerify = 0xb1
+	OpCheckSequenceVerify = 0xb2
+	OpNop4                = 0xb3
+	OpNop10               = 0xb9
+	OpCheckSigAdd         = 0xba
+
+	OpInvalidOpcode = 0xff
+)
+
+// opcodeNames holds the disassembly name of every opcode, in the notation of
+// the scriptpubkey_asm fields of the mempool files
+var opcodeNames [256]string
+
+// opcodeByName maps disassembly names back to opcodes
+var opcodeByName = map[string]byte{}
+
+func init() {
+	named := map[byte]string{
+		Op0: "OP_0", OpPushData1: "OP_PUSHDATA1", OpPushData2: "OP_PUSHDATA2", OpPushData4: "OP_PUSHDATA4",
+		Op1Negate: "OP_PUSHNUM_NEG1", OpReserved: "OP_RESERVED",
+		OpNop: "OP_NOP", OpVer: "OP_VER", OpIf: "OP_IF", OpNotIf: "OP_NOTIF", OpVerIf: "OP_VERIF",
+		OpVerNotIf: "OP_VERNOTIF", OpElse: "OP_ELSE", OpEndIf: "OP_ENDIF", OpVerify: "OP_VERIFY", OpReturn: "OP_RETURN",
+		OpToAltStack: "OP_TOALTSTACK", OpFromAltStack: "OP_FROMALTSTACK", Op2Drop: "OP_2DROP", Op2Dup: "OP_2DUP",
+		Op3Dup: "OP_3DUP", Op2Over: "OP_2OVER", Op2Rot: "OP_2ROT", Op2Swap: "OP_2SWAP", OpIfDup: "OP_IFDUP",
+		OpDepth: "OP_DEPTH", OpDrop: "OP_DROP", OpDup: "OP_DUP", OpNip: "OP_NIP", OpOver: "OP_OVER",
+		OpPick: "OP_PICK", OpRoll: "OP_ROLL", OpRot: "OP_ROT", OpSwap: "OP_SWAP", OpTuck: "OP_TUCK",
+		OpCat: "OP_CAT", OpSubstr: "OP_SUBSTR", OpLeft: "OP_LEFT", OpRight: "OP_RIGHT", OpSize: "OP_SIZE",
+		OpInvert: "OP_INVERT", OpAnd: "OP_AND", OpOr: "OP_OR", OpXor: "OP_XOR", OpEqual: "OP_EQUAL",
+		OpEqualVerify: "OP_EQUALVERIFY", OpReserved1: "OP_RESERVED1", OpReserved2: "OP_RESERVED2",
+		Op1Add: "OP_1ADD", Op1Sub: "OP_1SUB", Op2Mul: "OP_2MUL", Op2Div: "OP_2DIV", OpNegate: "OP_NEGATE",
+		OpAbs: "OP_ABS", OpNot: "OP_NOT", Op0NotEqual: "OP_0NOTEQUAL", OpAdd: "OP_ADD", OpSub: "OP_SUB",
+		OpMul: "OP_MUL", OpDiv: "OP_DIV", OpMod: "OP_MOD", OpLShift: "OP_LSHIFT", OpRShift: "OP_RSHIFT",
+		OpBoolAnd: "OP_BOOLAND", OpBoolOr: "OP_BOOLOR", OpNumEqual: "OP_NUMEQUAL",
+		OpNumEqualVerify: "OP_NUMEQUALVERIFY", OpNumNotEqual: "OP_NUMNOTEQUAL", OpLessThan: "OP_LESSTHAN",
+		OpGreaterThan: "OP_GREATERTHAN", OpLessThanOrEqual: "OP_LESSTHANOREQUAL",
+		OpGreaterThanOrEqual: "OP_GREATERTHANOREQUAL", OpMin: "OP_MIN", OpMax: "OP_MAX", OpWithin: "OP_WITHIN",
+		OpRipemd160: "OP_RIPEMD160", OpSha1: "OP_SHA1", OpSha256: "OP_SHA256", OpHash160: "OP_HASH160",
+		OpHash256: "OP_HASH256", OpCodeSeparator: "OP_CODESEPARATOR", OpCheckSig: "OP_CHECKSIG",
+		OpCheckSigVerify: "OP_CHECKSIGVERIFY", OpCheckMultiSig: "OP_CHECKMULTISIG",
+		OpCheckMultiSigVerify: "OP_CHECKMULTISIGVERIFY", OpNop1: "OP_NOP1", OpCheckLockTimeVerify: "OP_CLTV",
+		OpCheckSequenceVerify: "OP_CSV", OpCheckSigAdd: "OP_CHECKSIGADD", OpInvalidOpcode: "OP_INVALIDOPCODE",
+	}
+	for op := 0; op < 256; op++ {
+		switch name, ok := named[byte(op)]; {
+		case ok:
+			opcodeNames[op] = name
+		case op < OpPushData1:
+			opcodeNames[op] = fmt.Sprintf("OP_PUSHBYTES_%d", op)
+		case op >= Op1 && op <= Op16:
+			opcodeNames[op] = fmt.Sprintf("OP_PUSHNUM_%d", op-Op1+1)
+		case op >= OpNop4 && op <= OpNop10:
+			opcodeNames[op] = fmt.Sprintf("OP_NOP%d", op-OpNop4+4)
+		default:
+			opcodeNames[op] = fmt.Sprintf("OP_RETURN_%d", op)
+		}
+		opcodeByName[opcodeNames[op]] = byte(op)
+	}
+}
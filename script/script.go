@@ -0,0 +1,237 @@
+package script
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrTruncatedPush is returned when a push opcode runs past the end of the script
+var ErrTruncatedPush = errors.New("script: push past end of script")
+
+// Instruction is one opcode of a script together with the data it pushes
+type Instruction struct {
+	Opcode byte
+	Data   []byte // pushed data, nil for opcodes that push nothing
+}
+
+// Instructions splits a script into its instructions. Data slices alias script.
+func Instructions(script []byte) ([]Instruction, error) {
+	var instructions []Instruction
+	for pc := 0; pc < len(script); {
+		instruction, next, err := decodeInstruction(script, pc)
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, instruction)
+		pc = next
+	}
+	return instructions, nil
+}
+
+// decodeInstruction decodes the instruction at script[pc] and returns it with
+// the offset of the next one
+func decodeInstruction(script []byte, pc int) (Instruction, int, error) {
+	opcode := script[pc]
+	pc++
+	var length int
+	switch {
+	case opcode < OpPushData1:
+		length = int(opcode)
+	case opcode == OpPushData1:
+		if pc+1 > len(script) {
+			return Instruction{}, 0, ErrTruncatedPush
+		}
+		length = int(script[pc])
+		pc++
+	case opcode == OpPushData2:
+		if pc+2 > len(script) {
+			return Instruction{}, 0, ErrTruncatedPush
+		}
+		length = int(binary.LittleEndian.Uint16(script[pc:]))
+		pc += 2
+	case opcode == OpPushData4:
+		if pc+4 > len(script) {
+			return Instruction{}, 0, ErrTruncatedPush
+		}
+		length = int(binary.LittleEndian.Uint32(script[pc:]))
+		pc += 4
+	default:
+		return Instruction{Opcode: opcode}, pc, nil
+	}
+	if length > len(script)-pc {
+		return Instruction{}, 0, ErrTruncatedPush
+	}
+	return Instruction{Opcode: opcode, Data: script[pc : pc+length]}, pc + length, nil
+}
+
+// Disasm disassembles a script into the notation used by the
+// scriptpubkey_asm fields of the mempool files, e.g.
+// "OP_DUP OP_HASH160 OP_PUSHBYTES_20 <hash> OP_EQUALVERIFY OP_CHECKSIG"
+func Disasm(script []byte) (string, error) {
+	instructions, err := Instructions(script)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for i, instruction := range instructions {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(opcodeNames[instruction.Opcode])
+		if instruction.Opcode != Op0 && instruction.Opcode <= OpPushData4 {
+			sb.WriteByte(' ')
+			sb.WriteString(hex.EncodeToString(instruction.Data))
+		}
+	}
+	return sb.String(), nil
+}
+
+// Parse assembles a script from its disassembly. Besides the notation produced
+// by Disasm, a decimal token is pushed as a script number and a 0x-prefixed hex
+// token is pushed with the smallest push opcode.
+func Parse(asm string) ([]byte, error) {
+	var b Builder
+	tokens := strings.Fields(asm)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		opcode, ok := opcodeByName[token]
+		if !ok {
+			if data, found := strings.CutPrefix(token, "0x"); found {
+				pushData, err := hex.DecodeString(data)
+				if err != nil {
+					return nil, fmt.Errorf("script: data %q: %w", token, err)
+				}
+				b.AddData(pushData)
+				continue
+			}
+			n, err := strconv.ParseInt(token, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("script: unknown token %q", token)
+			}
+			b.AddInt64(n)
+			continue
+		}
+		if opcode == Op0 || opcode > OpPushData4 {
+			b.AddOp(opcode)
+			continue
+		}
+
+		// Explicit pushes keep exactly the opcode that was written
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("script: %s without data", token)
+		}
+		i++
+		data, err := hex.DecodeString(tokens[i])
+		if err != nil {
+			return nil, fmt.Errorf("script: %s data: %w", token, err)
+		}
+		if err := b.addPush(opcode, data); err != nil {
+			return nil, err
+		}
+	}
+	return b.Script(), nil
+}
+
+// Builder constructs scripts programmatically, e.g. the coinbase scriptSig
+type Builder struct {
+	script []byte
+}
+
+// AddOp appends an opcode
+func (b *Builder) AddOp(opcode byte) *Builder {
+	b.script = append(b.script, opcode)
+	return b
+}
+
+// AddData appends a push of data using the smallest push opcode
+func (b *Builder) AddData(data []byte) *Builder {
+	switch n := len(data); {
+	case n == 0:
+		b.script = append(b.script, Op0)
+	case n == 1 && data[0] >= 1 && data[0] <= 16:
+		b.script = append(b.script, Op1+data[0]-1)
+	case n == 1 && data[0] == 0x81:
+		b.script = append(b.script, Op1Negate)
+	case n < OpPushData1:
+		b.script = append(append(b.script, byte(n)), data...)
+	case n <= 0xff:
+		b.script = append(append(b.script, OpPushData1, byte(n)), data...)
+	case n <= 0xffff:
+		b.script = append(b.script, OpPushData2)
+		b.script = binary.LittleEndian.AppendUint16(b.script, uint16(n))
+		b.script = append(b.script, data...)
+	default:
+		b.script = append(b.script, OpPushData4)
+		b.script = binary.LittleEndian.AppendUint32(b.script, uint32(n))
+		b.script = append(b.script, data...)
+	}
+	return b
+}
+
+// AddInt64 appends a push of n in the minimal script number encoding
+func (b *Builder) AddInt64(n int64) *Builder {
+	switch {
+	case n == 0:
+		return b.AddOp(Op0)
+	case n == -1:
+		return b.AddOp(Op1Negate)
+	case n >= 1 && n <= 16:
+		return b.AddOp(byte(Op1 + n - 1))
+	}
+	return b.AddData(ScriptNum(n))
+}
+
+// addPush appends data with the given push opcode, which must be able to hold it
+func (b *Builder) addPush(opcode byte, data []byte) error {
+	n := len(data)
+	switch {
+	case opcode < OpPushData1 && n == int(opcode):
+		b.script = append(b.script, opcode)
+	case opcode == OpPushData1 && n <= 0xff:
+		b.script = append(b.script, opcode, byte(n))
+	case opcode == OpPushData2 && n <= 0xffff:
+		b.script = binary.LittleEndian.AppendUint16(append(b.script, opcode), uint16(n))
+	case opcode == OpPushData4:
+		b.script = binary.LittleEndian.AppendUint32(append(b.script, opcode), uint32(n))
+	default:
+		return fmt.Errorf("script: %s cannot push %d bytes", opcodeNames[opcode], n)
+	}
+	b.script = append(b.script, data...)
+	return nil
+}
+
+// Script returns the assembled script
+func (b *Builder) Script() []byte {
+	return b.script
+}
+
+// ScriptNum encodes n as a minimal little-endian sign-magnitude script number
+func ScriptNum(n int64) []byte {
+	if n == 0 {
+		return nil
+	}
+	negative := n < 0
+	magnitude := uint64(n)
+	if negative {
+		magnitude = uint64(-n)
+	}
+	var encoded []byte
+	for magnitude > 0 {
+		encoded = append(encoded, byte(magnitude))
+		magnitude >>= 8
+	}
+	if encoded[len(encoded)-1]&0x80 != 0 {
+		if negative {
+			encoded = append(encoded, 0x80)
+		} else {
+			encoded = append(encoded, 0x00)
+		}
+	} else if negative {
+		encoded[len(encoded)-1] |= 0x80
+	}
+	return encoded
+}
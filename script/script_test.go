@@ -0,0 +1,50 @@
+package script
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestDisasmParse checks scripts disassemble to their ASM and parse back to
+// the same bytes
+func TestDisasmParse(t *testing.T) {
+	tests := []struct {
+		script, asm string
+	}{
+		{"76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", "OP_DUP OP_HASH160 OP_PUSHBYTES_20 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 OP_EQUALVERIFY OP_CHECKSIG"},
+		{"0014751e76e8199196d454941c45d1b3a323f1433bd6", "OP_0 OP_PUSHBYTES_20 751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"6a4c0401020304", "OP_RETURN OP_PUSHDATA1 01020304"},
+		{"512102020202020202020202020202020202020202020202020202020202020202020251ae", "OP_PUSHNUM_1 OP_PUSHBYTES_33 020202020202020202020202020202020202020202020202020202020202020202 OP_PUSHNUM_1 OP_CHECKMULTISIG"},
+		{"03a08601b175", "OP_PUSHBYTES_3 a08601 OP_CLTV OP_DROP"},
+		{"4fbbff", "OP_PUSHNUM_NEG1 OP_RETURN_187 OP_INVALIDOPCODE"},
+	}
+	for _, test := range tests {
+		script, _ := hex.DecodeString(test.script)
+		asm, err := Disasm(script)
+		if err != nil {
+			t.Errorf("%s: %v", test.script, err)
+		} else if asm != test.asm {
+			t.Errorf("%s: disassembled as %q", test.script, asm)
+		}
+		parsed, err := Parse(test.asm)
+		if err != nil {
+			t.Errorf("%q: %v", test.asm, err)
+		} else if got := hex.EncodeToString(parsed); got != test.script {
+			t.Errorf("%q: parsed as %s", test.asm, got)
+		}
+	}
+	if _, err := Disasm([]byte{OpPushData1, 5, 0xaa, 0xbb}); err == nil {
+		t.Error("truncated push disassembled without error")
+	}
+}
+
+// TestParseNumbers checks numbers are pushed with the smallest encoding
+func TestParseNumbers(t *testing.T) {
+	parsed, err := Parse("0 -1 16 17 100000 -255 0xdeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(parsed), "004f60011103a0860102ff8004deadbeef"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package script
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+)
+
+// DefaultSigCacheSize is the number of signatures a cache from NewSigCache
+// remembers when given no size, about 3 MiB of entries
+const DefaultSigCacheSize = 100000
+
+// SigCache remembers signatures found valid, so that validating the same
+// input again skips the elliptic curve arithmetic. Entries are keyed by the
+// signature hash, the public key and the signature, hashed with a random
+// salt so no one can predict which entries collide. It is safe for
+// concurrent use.
+type SigCache struct {
+	mu         sync.RWMutex
+	salt       [32]byte
+	entries    map[[32]byte]struct{}
+	maxEntries int
+}
+
+// NewSigCache creates a cache of at most maxEntries signatures,
+// DefaultSigCacheSize if maxEntries is 0
+func NewSigCache(maxEntries int) *SigCache {
+	if maxEntries == 0 {
+		maxEntries = DefaultSigCacheSize
+	}
+	cache := &SigCache{entries: make(map[[32]byte]struct{}), maxEntries: maxEntries}
+	rand.Read(cache.salt[:])
+	return cache
+}
+
+// key returns the entry of a signature
+func (c *SigCache) key(sigHash, pubKey, sig []byte) [32]byte {
+	h := sha256.New()
+	h.Write(c.salt[:])
+	h.Write(sigHash)
+	// The lengths keep the boundary between the key and the signature unambiguous
+	h.Write([]byte{byte(len(pubKey)), byte(len(sig))})
+	h.Write(pubKey)
+	h.Write(sig)
+	var key [32]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// Contains reports whether sig was added as a valid signature of sigHash by pubKey
+func (c *SigCache) Contains(sigHash, pubKey, sig []byte) bool {
+	key := c.key(sigHash, pubKey, sig)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+// Add records sig as a valid signature of sigHash by pubKey. A full cache
+// first evicts an arbitrary entry.
+func (c *SigCache) Add(sigHash, pubKey, sig []byte) {
+	key := c.key(sigHash, pubKey, sig)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+	c.entries[key] = struct{}{}
+}
+
+// Len returns the number of signatures in the cache
+func (c *SigCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
@@ -0,0 +1,45 @@
+package script
+
+import "testing"
+
+func TestSigCache(t *testing.T) {
+	cache := NewSigCache(2)
+	hash, key := make([]byte, 32), []byte{2, 1}
+	cache.Add(hash, key, []byte{1})
+	cache.Add(hash, key, []byte{2})
+	otherHash := make([]byte, 32)
+	otherHash[0] = 1
+
+	tests := []struct {
+		name           string
+		hash, key, sig []byte
+		want           bool
+	}{
+		{"first added", hash, key, []byte{1}, true},
+		{"second added", hash, key, []byte{2}, true},
+		{"other signature", hash, key, []byte{3}, false},
+		{"other key", hash, []byte{2, 2}, []byte{1}, false},
+		{"other signature hash", otherHash, key, []byte{1}, false},
+		// Moving a byte from the key to the signature is another entry
+		{"key and signature boundary", hash, []byte{2}, []byte{1, 1}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cache.Contains(test.hash, test.key, test.sig); got != test.want {
+				t.Errorf("Contains = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// A full cache evicts an entry to make room
+	cache.Add(hash, key, []byte{3})
+	if cache.Len() != 2 || !cache.Contains(hash, key, []byte{3}) {
+		t.Errorf("full cache holds %d entries after adding a third", cache.Len())
+	}
+	if cache.Contains(hash, key, []byte{1}) && cache.Contains(hash, key, []byte{2}) {
+		t.Error("no entry evicted")
+	}
+	if NewSigCache(0).maxEntries != DefaultSigCacheSize {
+		t.Errorf("cache of size 0 holds %d entries, want %d", NewSigCache(0).maxEntries, DefaultSigCacheSize)
+	}
+}
@@ -0,0 +1,96 @@
+package script
+
+import (
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// WitnessScaleFactor is how much more a legacy signature operation costs than
+// a witness one, matching the weight of non-witness bytes
+const WitnessScaleFactor = 4
+
+// SigOpCount counts the signature operations of a script: one per CHECKSIG
+// and, for CHECKMULTISIG, the key count when accurate and the script states it
+// with a preceding OP_1 through OP_16, otherwise the maximum of 20. Counting
+// stops at a malformed push, as in Bitcoin Core.
+func SigOpCount(script []byte, accurate bool) int {
+	count := 0
+	var previous byte = OpInvalidOpcode
+	for pc := 0; pc < len(script); {
+		instruction, next, err := decodeInstruction(script, pc)
+		if err != nil {
+			break
+		}
+		pc = next
+		switch instruction.Opcode {
+		case OpCheckSig, OpCheckSigVerify:
+			count++
+		case OpCheckMultiSig, OpCheckMultiSigVerify:
+			if accurate && previous >= Op1 && previous <= Op16 {
+				count += int(previous-Op1) + 1
+			} else {
+				count += maxPubKeysPerMultisig
+			}
+		}
+		previous = instruction.Opcode
+	}
+	return count
+}
+
+// lastPush returns the data of the last push of a push-only script
+func lastPush(script []byte) ([]byte, bool) {
+	instructions, err := Instructions(script)
+	if err != nil || len(instructions) == 0 || !IsPushOnly(script) {
+		return nil, false
+	}
+	return instructions[len(instructions)-1].Data, true
+}
+
+// witnessSigOpCount counts the signature operations of a witness program spend
+func witnessSigOpCount(version int, program []byte, witness []tx.HexBytes) int {
+	if version != 0 {
+		return 0
+	}
+	switch len(program) {
+	case 20:
+		return 1
+	case 32:
+		if len(witness) == 0 {
+			return 0
+		}
+		return SigOpCount(witness[len(witness)-1], true)
+	}
+	return 0
+}
+
+// TransactionSigOpCost computes the signature operation cost of a transaction
+// (GetTransactionSigOpCost): legacy and P2SH signature operations count
+// WitnessScaleFactor times, witness ones once
+func TransactionSigOpCost(transaction tx.Transaction) int {
+	legacy := 0
+	witness := 0
+	for _, vout := range transaction.Vout {
+		legacy += SigOpCount(vout.ScriptPubKey, false)
+	}
+	for _, vin := range transaction.Vin {
+		scriptSig, prevout := vin.ScriptSig, vin.PrevOut.ScriptPubKey
+		legacy += SigOpCount(scriptSig, false)
+
+		if version, program, ok := IsWitnessProgram(prevout); ok {
+			witness += witnessSigOpCount(version, program, vin.Witness)
+			continue
+		}
+		if ClassifyScript(prevout) != P2SH {
+			continue
+		}
+		redeemScript, ok := lastPush(scriptSig)
+		if !ok {
+			continue
+		}
+		if version, program, ok := IsWitnessProgram(redeemScript); ok {
+			witness += witnessSigOpCount(version, program, vin.Witness)
+		} else {
+			legacy += SigOpCount(redeemScript, true)
+		}
+	}
+	return legacy*WitnessScaleFactor + witness
+}
@@ -0,0 +1,17 @@
+package script
+
+import "testing"
+
+// TestSigOpCount counts a 2-of-3 multisig by its keys when accurate and as
+// the 20 key maximum otherwise
+func TestSigOpCount(t *testing.T) {
+	scriptPubKey := new(Builder).AddOp(Op1 + 1).
+		AddData(indexKey(1)).AddData(indexKey(2)).AddData(indexKey(3)).
+		AddOp(Op1 + 2).AddOp(OpCheckMultiSig).Script()
+	if got := SigOpCount(scriptPubKey, true); got != 3 {
+		t.Errorf("accurate sigop count %d, want 3", got)
+	}
+	if got := SigOpCount(scriptPubKey, false); got != 20 {
+		t.Errorf("legacy sigop count %d, want 20", got)
+	}
+}
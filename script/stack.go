@@ -0,0 +1,109 @@
+package script
+
+// maxNumSize is the largest script number arithmetic opcodes accept, in bytes
+const maxNumSize = 4
+
+// stack is the main or alternate stack of the interpreter; the top is the last element
+type stack [][]byte
+
+func (s *stack) push(data []byte) {
+	*s = append(*s, data)
+}
+
+func (s *stack) pushBool(value bool) {
+	if value {
+		s.push([]byte{1})
+	} else {
+		s.push(nil)
+	}
+}
+
+func (s *stack) pushInt(n int64) {
+	s.push(ScriptNum(n))
+}
+
+// top returns the element depth places below the top, 0 being the top itself
+func (s stack) top(depth int) ([]byte, error) {
+	if depth < 0 || depth >= len(s) {
+		return nil, ErrInvalidStackOperation
+	}
+	return s[len(s)-1-depth], nil
+}
+
+func (s *stack) pop() ([]byte, error) {
+	data, err := s.top(0)
+	if err != nil {
+		return nil, err
+	}
+	*s = (*s)[:len(*s)-1]
+	return data, nil
+}
+
+func (s *stack) popInt(requireMinimal bool) (int64, error) {
+	data, err := s.pop()
+	if err != nil {
+		return 0, err
+	}
+	return decodeScriptNum(data, requireMinimal, maxNumSize)
+}
+
+func (s *stack) popBool() (bool, error) {
+	data, err := s.pop()
+	if err != nil {
+		return false, err
+	}
+	return castToBool(data), nil
+}
+
+// need fails unless the stack holds at least n elements
+func (s stack) need(n int) error {
+	if len(s) < n {
+		return ErrInvalidStackOperation
+	}
+	return nil
+}
+
+// remove deletes the element depth places below the top and returns it
+func (s *stack) remove(depth int) ([]byte, error) {
+	data, err := s.top(depth)
+	if err != nil {
+		return nil, err
+	}
+	i := len(*s) - 1 - depth
+	*s = append((*s)[:i], (*s)[i+1:]...)
+	return data, nil
+}
+
+// castToBool interprets a stack element as a boolean: false is any encoding
+// of zero, including negative zero
+func castToBool(data []byte) bool {
+	for i, b := range data {
+		if b != 0 {
+			return !(i == len(data)-1 && b == 0x80)
+		}
+	}
+	return false
+}
+
+// decodeScriptNum decodes a little-endian sign-magnitude script number of at
+// most maxSize bytes. With requireMinimal, encodings with needless padding
+// are rejected.
+func decodeScriptNum(data []byte, requireMinimal bool, maxSize int) (int64, error) {
+	if len(data) > maxSize {
+		return 0, ErrNumberOverflow
+	}
+	if requireMinimal && len(data) > 0 && data[len(data)-1]&0x7f == 0 {
+		// The last byte may only be a bare sign byte when the one before needs its high bit
+		if len(data) == 1 || data[len(data)-2]&0x80 == 0 {
+			return 0, ErrNonMinimalNumber
+		}
+	}
+	var n int64
+	for i, b := range data {
+		n |= int64(b) << (8 * i)
+	}
+	if len(data) > 0 && data[len(data)-1]&0x80 != 0 {
+		return -(n &^ (int64(0x80) << (8 * (len(data) - 1)))), nil
+	}
+	return n, nil
+}
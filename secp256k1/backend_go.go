@@ -0,0 +1,24 @@
+//go:build !libsecp256k1 || !cgo
+
+package secp256k1
+
+// Backend names the implementation verifying signatures: "go", or
+// "libsecp256k1" when built with the libsecp256k1 tag and cgo
+const Backend = "go"
+
+// VerifyECDSA reports whether sig is a valid signature of the 32-byte hash by key.
+// Like Bitcoin Core, it accepts either S value; IsLowS tells them apart.
+func VerifyECDSA(key *PublicKey, hash []byte, sig *Signature) bool {
+	return verifyECDSA(key, hash, sig)
+}
+
+// VerifySchnorr reports whether sig, 64 bytes, is a BIP340 signature of the
+// 32-byte message msg by key
+func VerifySchnorr(key *XOnlyPublicKey, msg, sig []byte) bool {
+	return verifySchnorr(key, msg, sig)
+}
+
+// verifySchnorrBatch reports whether all entries are valid signatures
+func verifySchnorrBatch(entries []schnorrEntry) bool {
+	return verifyBatchCombination(entries)
+}
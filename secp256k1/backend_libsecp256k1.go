@@ -0,0 +1,83 @@
+//go:build libsecp256k1 && cgo
+
+package secp256k1
+
+/*
+#cgo LDFLAGS: -lsecp256k1
+#include <secp256k1.h>
+#include <secp256k1_extrakeys.h>
+#include <secp256k1_schnorrsig.h>
+*/
+import "C"
+
+import "unsafe"
+
+// Backend names the implementation verifying signatures: "go", or
+// "libsecp256k1" when built with the libsecp256k1 tag and cgo
+const Backend = "libsecp256k1"
+
+// verifyContext is the libsecp256k1 context of every verification. It is
+// never written to after creation, so it is safe for concurrent use.
+var verifyContext = C.secp256k1_context_create(C.SECP256K1_CONTEXT_VERIFY)
+
+// cBytes points C at the first byte of data, which must not be empty
+func cBytes(data []byte) *C.uchar {
+	return (*C.uchar)(unsafe.Pointer(&data[0]))
+}
+
+// VerifyECDSA reports whether sig is a valid signature of the 32-byte hash by key.
+// Like Bitcoin Core, it accepts either S value; IsLowS tells them apart.
+func VerifyECDSA(key *PublicKey, hash []byte, sig *Signature) bool {
+	if len(hash) != 32 || sig.R.Sign() <= 0 || sig.R.Cmp(N) >= 0 || sig.S.Sign() <= 0 || sig.S.Cmp(N) >= 0 {
+		return false
+	}
+	// Hybrid keys parse as points too, so hand libsecp256k1 the uncompressed encoding
+	encodedKey := make([]byte, 65)
+	encodedKey[0] = 0x04
+	key.X.FillBytes(encodedKey[1:33])
+	key.Y.FillBytes(encodedKey[33:])
+	var pubKey C.secp256k1_pubkey
+	if C.secp256k1_ec_pubkey_parse(verifyContext, &pubKey, cBytes(encodedKey), C.size_t(len(encodedKey))) != 1 {
+		return false
+	}
+	compact := make([]byte, 64)
+	sig.R.FillBytes(compact[:32])
+	sig.S.FillBytes(compact[32:])
+	var parsed C.secp256k1_ecdsa_signature
+	if C.secp256k1_ecdsa_signature_parse_compact(verifyContext, &parsed, cBytes(compact)) != 1 {
+		return false
+	}
+	// libsecp256k1 only verifies low S signatures
+	C.secp256k1_ecdsa_signature_normalize(verifyContext, &parsed, &parsed)
+	return C.secp256k1_ecdsa_verify(verifyContext, &parsed, cBytes(hash), &pubKey) == 1
+}
+
+// VerifySchnorr reports whether sig, 64 bytes, is a BIP340 signature of the
+// 32-byte message msg by key
+func VerifySchnorr(key *XOnlyPublicKey, msg, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	var pubKey C.secp256k1_xonly_pubkey
+	if C.secp256k1_xonly_pubkey_parse(verifyContext, &pubKey, cBytes(key.Serialize())) != 1 {
+		return false
+	}
+	// BIP340 allows messages of any length, even empty ones
+	var msgPtr *C.uchar
+	if len(msg) > 0 {
+		msgPtr = cBytes(msg)
+	}
+	return C.secp256k1_schnorrsig_verify(verifyContext, cBytes(sig), msgPtr, C.size_t(len(msg)), &pubKey) == 1
+}
+
+// verifySchnorrBatch reports whether all entries are valid signatures.
+// libsecp256k1 has no batch verification, and verifies single signatures
+// faster than the Go batch does.
+func verifySchnorrBatch(entries []schnorrEntry) bool {
+	for _, entry := range entries {
+		if !VerifySchnorr(entry.key, entry.msg, entry.sig) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/taggedhash"
+)
+
+// schnorrEntry is a signature waiting in a batch
+type schnorrEntry struct {
+	key *XOnlyPublicKey
+	msg []byte
+	sig []byte
+}
+
+// SchnorrBatch collects BIP340 signatures to verify them together. Checking
+// the random linear combination
+//
+//	(a₁s₁ + … + aₙsₙ)·G = a₁·R₁ + … + aₙ·Rₙ + a₁e₁·P₁ + … + aₙeₙ·Pₙ
+//
+// shares the point doublings of every signature, at the price of only
+// telling whether all signatures are valid, not which one is not.
+type SchnorrBatch struct {
+	entries []schnorrEntry
+}
+
+// Add queues sig, which must be 64 bytes for the batch to verify, as a
+// signature of msg by key
+func (b *SchnorrBatch) Add(key *XOnlyPublicKey, msg, sig []byte) {
+	b.entries = append(b.entries, schnorrEntry{key, msg, sig})
+}
+
+// Merge queues every signature of other
+func (b *SchnorrBatch) Merge(other *SchnorrBatch) {
+	b.entries = append(b.entries, other.entries...)
+}
+
+// Each calls f with every queued signature
+func (b *SchnorrBatch) Each(f func(key *XOnlyPublicKey, msg, sig []byte)) {
+	for _, entry := range b.entries {
+		f(entry.key, entry.msg, entry.sig)
+	}
+}
+
+// Len returns the number of signatures queued
+func (b *SchnorrBatch) Len() int {
+	return len(b.entries)
+}
+
+// Verify reports whether every queued signature is valid. The outcome is the
+// same as calling VerifySchnorr on each, except for a chance of at most 2⁻¹²⁸
+// of accepting a batch containing an invalid signature.
+func (b *SchnorrBatch) Verify() bool {
+	return verifySchnorrBatch(b.entries)
+}
+
+// verifyBatchCombination checks the random linear combination of entries in Go
+func verifyBatchCombination(entries []schnorrEntry) bool {
+	switch len(entries) {
+	case 0:
+		return true
+	case 1:
+		entry := entries[0]
+		return verifySchnorr(entry.key, entry.msg, entry.sig)
+	}
+
+	// The randomizers come from a hash of the whole batch, so that no signer
+	// can predict them (BIP340, batch verification)
+	seed := sha256.New()
+	for _, entry := range entries {
+		seed.Write(entry.key.Serialize())
+		seed.Write(entry.msg)
+		seed.Write(entry.sig)
+	}
+	var seedHash [32]byte
+	seed.Sum(seedHash[:0])
+
+	scalars := make([]*big.Int, 0, 2*len(entries)+1)
+	points := make([]jacobian, 0, 2*len(entries)+1)
+	sum := new(big.Int)
+	for i, entry := range entries {
+		if len(entry.sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(entry.sig[:32])
+		s := new(big.Int).SetBytes(entry.sig[32:])
+		if r.Cmp(P) >= 0 || s.Cmp(N) >= 0 {
+			return false
+		}
+		// -R, R being the point with x r and an even y
+		negRy, ok := liftY(r, true)
+		if !ok {
+			return false
+		}
+		challenge := taggedhash.Sum(taggedhash.TagBIP340Challenge, entry.sig[:32], entry.key.Serialize(), entry.msg)
+		e := new(big.Int).SetBytes(challenge[:])
+
+		a := big.NewInt(1)
+		if i > 0 {
+			randomizer := sha256.Sum256(binary.BigEndian.AppendUint32(seedHash[:], uint32(i)))
+			a.SetBytes(randomizer[:16])
+		}
+		sum.Add(sum, new(big.Int).Mul(a, s))
+		ae := e.Mul(e, a)
+		scalars = append(scalars, a, ae.Mod(ae, N))
+		negPy := new(big.Int).Sub(P, entry.key.point.Y)
+		points = append(points, affineToJacobian(r, negRy), affineToJacobian(entry.key.point.X, negPy))
+	}
+	scalars = append(scalars, sum.Mod(sum, N))
+	points = append(points, generator)
+	return multiScalarMult(scalars, points).infinity()
+}
+
+// multiScalarMult computes the sum of scalars[i]·points[i], doubling once per
+// bit of the longest scalar for all points together (Straus' method)
+func multiScalarMult(scalars []*big.Int, points []jacobian) jacobian {
+	bits := 0
+	for _, k := range scalars {
+		bits = max(bits, k.BitLen())
+	}
+	var result jacobian
+	for bit := bits - 1; bit >= 0; bit-- {
+		result = result.double()
+		for i, k := range scalars {
+			if k.Bit(bit) == 1 {
+				result = result.add(points[i])
+			}
+		}
+	}
+	return result
+}
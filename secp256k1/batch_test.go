@@ -0,0 +1,110 @@
+package secp256k1
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// batchEntries returns the signatures of the BIP340 vectors chosen by indexes,
+// whose keys must parse
+func batchEntries(t *testing.T, indexes ...int) []schnorrEntry {
+	t.Helper()
+	var entries []schnorrEntry
+	for _, i := range indexes {
+		vector := bip340Vectors[i]
+		pubKey, _ := hex.DecodeString(vector.pubKey)
+		msg, _ := hex.DecodeString(vector.msg)
+		sig, _ := hex.DecodeString(vector.sig)
+		key, err := ParseXOnlyPubKey(pubKey)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		entries = append(entries, schnorrEntry{key, msg, sig})
+	}
+	return entries
+}
+
+func TestSchnorrBatchVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		vectors []int
+		valid   bool
+	}{
+		{"empty", nil, true},
+		{"one valid", []int{1}, true},
+		{"one invalid", []int{7}, false},
+		{"all valid", []int{0, 1, 2, 3, 4, 15, 16, 17, 18}, true},
+		{"same signature twice", []int{1, 1}, true},
+		{"R has an odd y first", []int{6, 0, 1, 2}, false},
+		{"negated message in the middle", []int{0, 1, 7, 2, 3}, false},
+		{"negated s last", []int{0, 1, 2, 8}, false},
+		{"R at infinity", []int{0, 9}, false},
+		{"r not on the curve", []int{0, 11}, false},
+		{"r equal to P", []int{0, 12}, false},
+		{"s equal to N", []int{0, 13}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var batch SchnorrBatch
+			for _, entry := range batchEntries(t, test.vectors...) {
+				batch.Add(entry.key, entry.msg, entry.sig)
+			}
+			if batch.Len() != len(test.vectors) {
+				t.Fatalf("batch holds %d signatures, want %d", batch.Len(), len(test.vectors))
+			}
+			if got := batch.Verify(); got != test.valid {
+				t.Errorf("Verify = %v, want %v", got, test.valid)
+			}
+		})
+	}
+
+	t.Run("short signature", func(t *testing.T) {
+		var batch SchnorrBatch
+		for _, entry := range batchEntries(t, 0, 1) {
+			batch.Add(entry.key, entry.msg, entry.sig[:63])
+		}
+		if batch.Verify() {
+			t.Error("batch of 63-byte signatures accepted")
+		}
+	})
+}
+
+// A failed batch tells only that some signature is invalid; verifying its
+// parts, as the miner does per transaction, and then each signature of the
+// failing part finds the same invalid signature as checking them one by one
+func TestSchnorrBatchFallback(t *testing.T) {
+	groups := [][]int{{0, 1}, {2, 7, 3}, {15, 16}}
+	batches := make([]*SchnorrBatch, len(groups))
+	var all SchnorrBatch
+	for i, group := range groups {
+		batches[i] = new(SchnorrBatch)
+		for _, entry := range batchEntries(t, group...) {
+			batches[i].Add(entry.key, entry.msg, entry.sig)
+		}
+		all.Merge(batches[i])
+	}
+	if all.Len() != 7 {
+		t.Fatalf("merged batch holds %d signatures, want 7", all.Len())
+	}
+	if all.Verify() {
+		t.Fatal("merged batch with an invalid signature accepted")
+	}
+
+	for i, batch := range batches {
+		want := i != 1
+		if got := batch.Verify(); got != want {
+			t.Errorf("group %d: Verify = %v, want %v", i, got, want)
+		}
+		var invalid []int
+		position := 0
+		batch.Each(func(key *XOnlyPublicKey, msg, sig []byte) {
+			if !VerifySchnorr(key, msg, sig) {
+				invalid = append(invalid, groups[i][position])
+			}
+			position++
+		})
+		if want && len(invalid) > 0 || !want && (len(invalid) != 1 || invalid[0] != 7) {
+			t.Errorf("group %d: single checks reject vectors %v", i, invalid)
+		}
+	}
+}
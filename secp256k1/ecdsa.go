@@ -0,0 +1,73 @@
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidSignature is returned for a signature that is not strict DER (BIP66)
+var ErrInvalidSignature = errors.New("secp256k1: invalid DER signature")
+
+// Signature is an ECDSA signature
+type Signature struct {
+	R, S *big.Int
+}
+
+// IsValidDER reports whether sig, without a sighash type byte, is a strictly
+// DER-encoded signature as required by BIP66:
+// 0x30 len 0x02 rlen r 0x02 slen s, with minimally encoded positive integers
+func IsValidDER(sig []byte) bool {
+	if len(sig) < 8 || len(sig) > 72 || sig[0] != 0x30 || int(sig[1]) != len(sig)-2 {
+		return false
+	}
+	rLen := int(sig[3])
+	if sig[2] != 0x02 || rLen == 0 || 5+rLen >= len(sig) {
+		return false
+	}
+	sLen := int(sig[5+rLen])
+	if sig[4+rLen] != 0x02 || sLen == 0 || rLen+sLen+6 != len(sig) {
+		return false
+	}
+	validInteger := func(n []byte) bool {
+		return n[0]&0x80 == 0 && (len(n) == 1 || n[0] != 0 || n[1]&0x80 != 0)
+	}
+	return validInteger(sig[4:4+rLen]) && validInteger(sig[6+rLen:])
+}
+
+// ParseDERSignature decodes a strictly DER-encoded signature without a sighash type byte
+func ParseDERSignature(sig []byte) (*Signature, error) {
+	if !IsValidDER(sig) {
+		return nil, ErrInvalidSignature
+	}
+	rLen := int(sig[3])
+	return &Signature{
+		R: new(big.Int).SetBytes(sig[4 : 4+rLen]),
+		S: new(big.Int).SetBytes(sig[6+rLen:]),
+	}, nil
+}
+
+// IsLowS reports whether S is at most N/2, the canonical one of the two valid
+// S values required by the LOW_S policy (BIP146)
+func (sig *Signature) IsLowS() bool {
+	return sig.S.Cmp(halfN) <= 0
+}
+
+// verifyECDSA is VerifyECDSA in Go
+func verifyECDSA(key *PublicKey, hash []byte, sig *Signature) bool {
+	if sig.R.Sign() <= 0 || sig.R.Cmp(N) >= 0 || sig.S.Sign() <= 0 || sig.S.Cmp(N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(hash)
+	w := new(big.Int).ModInverse(sig.S, N)
+	u1 := e.Mul(e, w)
+	u1.Mod(u1, N)
+	u2 := w.Mul(sig.R, w)
+	u2.Mod(u2, N)
+
+	point := doubleScalarMult(u1, key, u2)
+	if point.infinity() {
+		return false
+	}
+	x, _ := point.toAffine()
+	return x.Mod(x, N).Cmp(sig.R) == 0
+}
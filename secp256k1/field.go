@@ -0,0 +1,186 @@
+package secp256k1
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fieldElement is an element of the field of order P as four 64-bit limbs,
+// least significant first, always fully reduced below P so that equal
+// elements have equal limbs
+type fieldElement [4]uint64
+
+// fieldC is 2²⁵⁶ - P: reducing modulo P folds the bits above 256 back in
+// multiplied by it
+const fieldC = 0x1000003d1
+
+var (
+	fieldOne   = fieldElement{1}
+	fieldSeven = fieldElement{7}
+
+	// Exponents of the inverse, P-2, and of the square root, (P+1)/4 as P ≡ 3 mod 4
+	inverseExp = fieldElement{0xfffffffefffffc2d, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
+	sqrtExp    = fieldElement{0xffffffffbfffff0c, 0xffffffffffffffff, 0xffffffffffffffff, 0x3fffffffffffffff}
+)
+
+// fieldFromBytes decodes a 32-byte big-endian number, reporting false if it is not below P
+func fieldFromBytes(data []byte) (fieldElement, bool) {
+	var f fieldElement
+	for i := range f {
+		for _, b := range data[32-8*(i+1) : 32-8*i] {
+			f[i] = f[i]<<8 | uint64(b)
+		}
+	}
+	_, overflow := f.reduceOnce(0)
+	return f, !overflow
+}
+
+// fieldFromBig converts a number in [0, P)
+func fieldFromBig(n *big.Int) fieldElement {
+	f, _ := fieldFromBytes(n.FillBytes(make([]byte, 32)))
+	return f
+}
+
+// bytes returns the 32-byte big-endian encoding of f
+func (f fieldElement) bytes() []byte {
+	data := make([]byte, 32)
+	for i, limb := range f {
+		for j := 0; j < 8; j++ {
+			data[31-8*i-j] = byte(limb >> (8 * j))
+		}
+	}
+	return data
+}
+
+func (f fieldElement) big() *big.Int {
+	return new(big.Int).SetBytes(f.bytes())
+}
+
+func (f fieldElement) isZero() bool {
+	return f == fieldElement{}
+}
+
+func (f fieldElement) isOdd() bool {
+	return f[0]&1 == 1
+}
+
+// reduceOnce reduces carry·2²⁵⁶ + f, which must be below 2P, reporting
+// whether it was at least P
+func (f fieldElement) reduceOnce(carry uint64) (fieldElement, bool) {
+	// Adding fieldC carries out of 256 bits exactly when the value is at least P
+	var r fieldElement
+	var c uint64
+	r[0], c = bits.Add64(f[0], fieldC, 0)
+	r[1], c = bits.Add64(f[1], 0, c)
+	r[2], c = bits.Add64(f[2], 0, c)
+	r[3], c = bits.Add64(f[3], 0, c)
+	if c|carry != 0 {
+		return r, true
+	}
+	return f, false
+}
+
+func (f fieldElement) add(g fieldElement) fieldElement {
+	var r fieldElement
+	var c uint64
+	r[0], c = bits.Add64(f[0], g[0], 0)
+	r[1], c = bits.Add64(f[1], g[1], c)
+	r[2], c = bits.Add64(f[2], g[2], c)
+	r[3], c = bits.Add64(f[3], g[3], c)
+	r, _ = r.reduceOnce(c)
+	return r
+}
+
+func (f fieldElement) sub(g fieldElement) fieldElement {
+	var r fieldElement
+	var borrow uint64
+	r[0], borrow = bits.Sub64(f[0], g[0], 0)
+	r[1], borrow = bits.Sub64(f[1], g[1], borrow)
+	r[2], borrow = bits.Sub64(f[2], g[2], borrow)
+	r[3], borrow = bits.Sub64(f[3], g[3], borrow)
+	if borrow != 0 {
+		// Adding P modulo 2²⁵⁶ is subtracting fieldC, which cannot borrow again
+		r[0], borrow = bits.Sub64(r[0], fieldC, 0)
+		r[1], borrow = bits.Sub64(r[1], 0, borrow)
+		r[2], borrow = bits.Sub64(r[2], 0, borrow)
+		r[3], _ = bits.Sub64(r[3], 0, borrow)
+	}
+	return r
+}
+
+func (f fieldElement) neg() fieldElement {
+	return fieldElement{}.sub(f)
+}
+
+// double returns 2f
+func (f fieldElement) double() fieldElement {
+	return f.add(f)
+}
+
+func (f fieldElement) mul(g fieldElement) fieldElement {
+	// Schoolbook product into eight limbs; a limb product plus two limbs
+	// never exceeds 128 bits
+	var t [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(f[i], g[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j], carry = lo, hi
+		}
+		t[i+4] = carry
+	}
+
+	// Fold the high half in as high·fieldC, leaving at most 34 bits above 2²⁵⁶
+	var r fieldElement
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		hi, lo := bits.Mul64(t[4+i], fieldC)
+		var c uint64
+		lo, c = bits.Add64(lo, t[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, carry, 0)
+		hi += c
+		r[i], carry = lo, hi
+	}
+	// and fold those in again, leaving a value below 2P
+	hi, lo := bits.Mul64(carry, fieldC)
+	var c uint64
+	r[0], c = bits.Add64(r[0], lo, 0)
+	r[1], c = bits.Add64(r[1], hi, c)
+	r[2], c = bits.Add64(r[2], 0, c)
+	r[3], c = bits.Add64(r[3], 0, c)
+	r, _ = r.reduceOnce(c)
+	return r
+}
+
+func (f fieldElement) square() fieldElement {
+	return f.mul(f)
+}
+
+// exp computes f raised to e by square-and-multiply
+func (f fieldElement) exp(e fieldElement) fieldElement {
+	r := fieldOne
+	for i := 255; i >= 0; i-- {
+		r = r.square()
+		if e[i/64]>>(i%64)&1 == 1 {
+			r = r.mul(f)
+		}
+	}
+	return r
+}
+
+// inverse returns 1/f, f being non-zero
+func (f fieldElement) inverse() fieldElement {
+	return f.exp(inverseExp)
+}
+
+// sqrt returns a square root of f, reporting false if f has none
+func (f fieldElement) sqrt() (fieldElement, bool) {
+	r := f.exp(sqrtExp)
+	return r, r.square() == f
+}
@@ -0,0 +1,105 @@
+package secp256k1
+
+import (
+	"math/big"
+	"testing"
+)
+
+// fieldEdgeValues are field elements at the edges of the limbs and of P
+var fieldEdgeValues = []*big.Int{
+	big.NewInt(0),
+	big.NewInt(1),
+	big.NewInt(2),
+	big.NewInt(7),
+	new(big.Int).Sub(P, big.NewInt(1)),
+	new(big.Int).Sub(P, big.NewInt(2)),
+	new(big.Int).Rsh(P, 1),
+	new(big.Int).Lsh(big.NewInt(1), 64),
+	new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)),
+	new(big.Int).Lsh(big.NewInt(1), 255),
+	hexInt("00000000ffffffff00000000ffffffff00000000ffffffff00000000ffffffff"),
+	Gx,
+	Gy,
+}
+
+// mod reduces n modulo P
+func mod(n *big.Int) *big.Int {
+	return n.Mod(n, P)
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	for _, a := range fieldEdgeValues {
+		fa := fieldFromBig(a)
+		if fa.big().Cmp(a) != 0 {
+			t.Fatalf("%x converts back to %x", a, fa.big())
+		}
+		for _, b := range fieldEdgeValues {
+			fb := fieldFromBig(b)
+			if got, want := fa.add(fb).big(), mod(new(big.Int).Add(a, b)); got.Cmp(want) != 0 {
+				t.Errorf("%x + %x = %x, want %x", a, b, got, want)
+			}
+			if got, want := fa.sub(fb).big(), mod(new(big.Int).Sub(a, b)); got.Cmp(want) != 0 {
+				t.Errorf("%x - %x = %x, want %x", a, b, got, want)
+			}
+			if got, want := fa.mul(fb).big(), mod(new(big.Int).Mul(a, b)); got.Cmp(want) != 0 {
+				t.Errorf("%x * %x = %x, want %x", a, b, got, want)
+			}
+		}
+		if got, want := fa.square().big(), mod(new(big.Int).Mul(a, a)); got.Cmp(want) != 0 {
+			t.Errorf("%x² = %x, want %x", a, got, want)
+		}
+		if got, want := fa.neg().big(), mod(new(big.Int).Neg(a)); got.Cmp(want) != 0 {
+			t.Errorf("-%x = %x, want %x", a, got, want)
+		}
+		if a.Sign() != 0 {
+			if got, want := fa.inverse().big(), new(big.Int).ModInverse(a, P); got.Cmp(want) != 0 {
+				t.Errorf("1/%x = %x, want %x", a, got, want)
+			}
+		}
+		root, ok := fa.sqrt()
+		if want := new(big.Int).ModSqrt(a, P); ok != (want != nil) {
+			t.Errorf("sqrt(%x) reported %v, want %v", a, ok, want != nil)
+		} else if ok && mod(new(big.Int).Mul(root.big(), root.big())).Cmp(a) != 0 {
+			t.Errorf("sqrt(%x) = %x, which does not square to it", a, root.big())
+		}
+	}
+}
+
+func TestFieldFromBytes(t *testing.T) {
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	tests := []struct {
+		name string
+		n    *big.Int
+	}{
+		{"zero", big.NewInt(0)},
+		{"P-1", new(big.Int).Sub(P, big.NewInt(1))},
+		{"P", new(big.Int).Set(P)},
+		{"P+1", new(big.Int).Add(P, big.NewInt(1))},
+		{"P+2³²", new(big.Int).Add(P, new(big.Int).Lsh(big.NewInt(1), 32))},
+		{"2²⁵⁶-1", ones},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, ok := fieldFromBytes(test.n.FillBytes(make([]byte, 32)))
+			if want := test.n.Cmp(P) < 0; ok != want {
+				t.Fatalf("below P reported %v, want %v", ok, want)
+			}
+			// Values from P up are reduced by a single subtraction
+			reduced, overflow := f.reduceOnce(0)
+			if overflow == ok {
+				t.Errorf("reduceOnce reported overflow %v", overflow)
+			}
+			want := mod(new(big.Int).Set(test.n))
+			if reduced.big().Cmp(want) != 0 {
+				t.Errorf("reduced to %x, want %x", reduced.big(), want)
+			}
+			// Multiplication reduces any 256-bit operands fully
+			if got := f.mul(fieldOne).big(); got.Cmp(want) != 0 {
+				t.Errorf("times one is %x, want %x", got, want)
+			}
+			if got, want := f.square().big(), mod(new(big.Int).Mul(test.n, test.n)); got.Cmp(want) != 0 {
+				t.Errorf("squared is %x, want %x", got, want)
+			}
+		})
+	}
+}
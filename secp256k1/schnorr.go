@@ -0,0 +1,80 @@
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/taggedhash"
+)
+
+// ErrInvalidXOnlyPubKey is returned for 32 bytes that are not the x coordinate of a point on the curve
+var ErrInvalidXOnlyPubKey = errors.New("secp256k1: invalid x-only public key")
+
+// XOnlyPublicKey is a BIP340 public key: of the two points with its x
+// coordinate, the one with an even y
+type XOnlyPublicKey struct {
+	point PublicKey
+}
+
+// ParseXOnlyPubKey decodes a 32-byte x-only public key with lift_x, failing
+// for x coordinates not below P or without a point on the curve
+func ParseXOnlyPubKey(data []byte) (*XOnlyPublicKey, error) {
+	if len(data) != 32 {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	x := new(big.Int).SetBytes(data)
+	y, ok := liftY(x, false)
+	if !ok {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	return &XOnlyPublicKey{PublicKey{X: x, Y: y}}, nil
+}
+
+// Serialize returns the 32-byte encoding of the key
+func (key *XOnlyPublicKey) Serialize() []byte {
+	return key.point.X.FillBytes(make([]byte, 32))
+}
+
+// Point returns the key as a point, whose y is even
+func (key *XOnlyPublicKey) Point() *PublicKey {
+	return &key.point
+}
+
+// CheckTapTweak reports whether key is internal tweaked by tweak (BIP341):
+// the x coordinate of internal + tweak·G, whose y has the parity odd
+func (key *XOnlyPublicKey) CheckTapTweak(internal *XOnlyPublicKey, tweak [32]byte, odd bool) bool {
+	t := new(big.Int).SetBytes(tweak[:])
+	if t.Cmp(N) >= 0 {
+		return false
+	}
+	point := doubleScalarMult(t, &internal.point, big.NewInt(1))
+	if point.infinity() {
+		return false
+	}
+	x, y := point.toAffine()
+	return x.Cmp(key.point.X) == 0 && (y.Bit(0) == 1) == odd
+}
+
+// verifySchnorr is VerifySchnorr in Go
+func verifySchnorr(key *XOnlyPublicKey, msg, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	pubKey := key.Serialize()
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(P) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	challenge := taggedhash.Sum(taggedhash.TagBIP340Challenge, sig[:32], pubKey, msg)
+	e := new(big.Int).SetBytes(challenge[:])
+	e.Mod(e, N)
+
+	// R = s·G - e·P must have an even y and the x coordinate r
+	point := doubleScalarMult(s, &key.point, e.Sub(N, e))
+	if point.infinity() {
+		return false
+	}
+	x, y := point.toAffine()
+	return y.Bit(0) == 0 && x.Cmp(r) == 0
+}
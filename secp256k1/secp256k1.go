@@ -0,0 +1,180 @@
+// Package secp256k1 implements the arithmetic of the secp256k1 curve that
+// verifying Bitcoin signatures needs: parsing public keys and checking ECDSA
+// and Schnorr signatures against them. Built with the libsecp256k1 tag and
+// cgo, signatures are verified by the C library instead.
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Curve parameters: y² = x³ + 7 over the field of order P, with a base point
+// G of prime order N
+var (
+	P  = hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	N  = hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	Gx = hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	Gy = hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+
+	halfN = new(big.Int).Rsh(N, 1)
+
+	generator = jacobian{fieldFromBig(Gx), fieldFromBig(Gy), fieldOne}
+)
+
+func hexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("secp256k1: bad constant " + s)
+	}
+	return n
+}
+
+// ErrInvalidPubKey is returned for a public key encoding that is not a point on the curve
+var ErrInvalidPubKey = errors.New("secp256k1: invalid public key")
+
+// PublicKey is a point on the curve in affine coordinates
+type PublicKey struct {
+	X, Y *big.Int
+}
+
+// ParsePubKey decodes a public key in compressed (02/03), uncompressed (04)
+// or hybrid (06/07) form, as libsecp256k1 does
+func ParsePubKey(data []byte) (*PublicKey, error) {
+	switch {
+	case len(data) == 33 && (data[0] == 0x02 || data[0] == 0x03):
+		x := new(big.Int).SetBytes(data[1:])
+		y, ok := liftY(x, data[0] == 0x03)
+		if !ok {
+			return nil, ErrInvalidPubKey
+		}
+		return &PublicKey{X: x, Y: y}, nil
+	case len(data) == 65 && (data[0] == 0x04 || data[0] == 0x06 || data[0] == 0x07):
+		key := &PublicKey{X: new(big.Int).SetBytes(data[1:33]), Y: new(big.Int).SetBytes(data[33:])}
+		if !key.onCurve() || (data[0] != 0x04 && key.Y.Bit(0) != uint(data[0]&1)) {
+			return nil, ErrInvalidPubKey
+		}
+		return key, nil
+	}
+	return nil, ErrInvalidPubKey
+}
+
+// liftY returns the y coordinate of the point with x coordinate x and the given
+// parity, reporting false when x is not on the curve
+func liftY(x *big.Int, odd bool) (*big.Int, bool) {
+	if x.Cmp(P) >= 0 {
+		return nil, false
+	}
+	y, ok := curveRHS(fieldFromBig(x)).sqrt()
+	if !ok {
+		return nil, false
+	}
+	if y.isOdd() != odd {
+		y = y.neg()
+	}
+	return y.big(), true
+}
+
+// curveRHS computes x³ + 7
+func curveRHS(x fieldElement) fieldElement {
+	return x.square().mul(x).add(fieldSeven)
+}
+
+func (key *PublicKey) onCurve() bool {
+	if key.X.Cmp(P) >= 0 || key.Y.Cmp(P) >= 0 {
+		return false
+	}
+	return fieldFromBig(key.Y).square() == curveRHS(fieldFromBig(key.X))
+}
+
+// jacobian is a point (x/z², y/z³); z = 0, as in the zero value, is the
+// point at infinity
+type jacobian struct {
+	x, y, z fieldElement
+}
+
+// affineToJacobian converts the point (x, y), both in [0, P)
+func affineToJacobian(x, y *big.Int) jacobian {
+	return jacobian{fieldFromBig(x), fieldFromBig(y), fieldOne}
+}
+
+func (p jacobian) infinity() bool {
+	return p.z.isZero()
+}
+
+// toAffine converts p, which must not be the point at infinity
+func (p jacobian) toAffine() (x, y *big.Int) {
+	zInv := p.z.inverse()
+	zInv2 := zInv.square()
+	return p.x.mul(zInv2).big(), p.y.mul(zInv2.mul(zInv)).big()
+}
+
+// double returns 2p (dbl-2009-l, the curve has a = 0)
+func (p jacobian) double() jacobian {
+	if p.infinity() || p.y.isZero() {
+		return jacobian{}
+	}
+	a := p.x.square()
+	b := p.y.square()
+	c := b.square()
+	d := p.x.add(b).square().sub(a).sub(c).double()
+	e := a.double().add(a)
+	f := e.square()
+	x3 := f.sub(d.double())
+	c8 := c.double().double().double()
+	y3 := e.mul(d.sub(x3)).sub(c8)
+	z3 := p.y.double().mul(p.z)
+	return jacobian{x3, y3, z3}
+}
+
+// add returns p + q (add-2007-bl)
+func (p jacobian) add(q jacobian) jacobian {
+	switch {
+	case p.infinity():
+		return q
+	case q.infinity():
+		return p
+	}
+	z1z1 := p.z.square()
+	z2z2 := q.z.square()
+	u1 := p.x.mul(z2z2)
+	u2 := q.x.mul(z1z1)
+	s1 := p.y.mul(q.z).mul(z2z2)
+	s2 := q.y.mul(p.z).mul(z1z1)
+	if u1 == u2 {
+		if s1 == s2 {
+			return p.double()
+		}
+		return jacobian{}
+	}
+	h := u2.sub(u1)
+	i := h.double().square()
+	j := h.mul(i)
+	r := s2.sub(s1).double()
+	v := u1.mul(i)
+	x3 := r.square().sub(j).sub(v.double())
+	y3 := r.mul(v.sub(x3)).sub(s1.mul(j).double())
+	z3 := p.z.add(q.z).square().sub(z1z1).sub(z2z2).mul(h)
+	return jacobian{x3, y3, z3}
+}
+
+// doubleScalarMult computes u1·G + u2·Q with a single pass over the bits of
+// both scalars (Shamir's trick)
+func doubleScalarMult(u1 *big.Int, q *PublicKey, u2 *big.Int) jacobian {
+	g := generator
+	pq := affineToJacobian(q.X, q.Y)
+	sum := g.add(pq)
+	var result jacobian
+	for bit := max(u1.BitLen(), u2.BitLen()) - 1; bit >= 0; bit-- {
+		result = result.double()
+		switch {
+		case u1.Bit(bit) == 1 && u2.Bit(bit) == 1:
+			result = result.add(sum)
+		case u1.Bit(bit) == 1:
+			result = result.add(g)
+		case u2.Bit(bit) == 1:
+			result = result.add(pq)
+		}
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+// Package stratum hands block templates to external miners as Stratum v1 jobs
+// and validates the shares they submit.
+package stratum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/merkle"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Extranonce sizes. The server assigns extranonce1 per connection and the
+// miner rolls extranonce2; together they end the coinbase scriptSig.
+const (
+	Extranonce1Size = 4
+	Extranonce2Size = 4
+	extranonceSize  = Extranonce1Size + Extranonce2Size
+)
+
+// Job is a block template split the way Stratum miners expect it: the
+// coinbase around the extranonce and the merkle branch of the coinbase
+type Job struct {
+	ID        string
+	Template  block.Block // coinbase first, without the extranonce
+	Coinbase1 []byte      // serialized coinbase up to the extranonce
+	Coinbase2 []byte      // serialized coinbase after the extranonce
+	Branch    []merkle.Hash
+
+	scriptSig []byte // coinbase scriptSig before the extranonce
+}
+
+// NewJob splits a block template into a job. The coinbase is serialized
+// without its witness, as it is hashed for the txid.
+func NewJob(id string, template block.Block) (*Job, error) {
+	if len(template.Transactions) == 0 || !template.Transactions[0].Vin[0].IsCoinbase {
+		return nil, errors.New("template has no coinbase transaction")
+	}
+	coinbase := template.Transactions[0]
+	// A copy, so the placeholder extranonce is never appended into the template
+	scriptSig := bytes.Clone(coinbase.Vin[0].ScriptSig)
+	// The extranonce the miners roll must still fit in the scriptSig
+	if err := miner.CheckCoinbaseScript(append(scriptSig, make([]byte, extranonceSize)...)); err != nil {
+		return nil, fmt.Errorf("coinbase scriptsig with extranonce: %w", err)
+	}
+
+	// Serialize the coinbase with a placeholder extranonce and cut it out. The
+	// scriptSig follows the version, input count, outpoint and script length.
+	withPlaceholder := coinbase
+	withPlaceholder.Vin = []tx.TxInput{coinbase.Vin[0]}
+	withPlaceholder.Vin[0].ScriptSig = append(scriptSig, make([]byte, extranonceSize)...)
+	serialized, err := tx.Serialize(withPlaceholder, false)
+	if err != nil {
+		return nil, err
+	}
+	offset := 4 + tx.VarIntSize(1) + 32 + 4 + tx.VarIntSize(uint64(len(scriptSig)+extranonceSize)) + len(scriptSig)
+
+	// The coinbase is always the leftmost leaf, so its branch does not depend on it
+	txids := make([]merkle.Hash, len(template.Transactions))
+	for i, transaction := range template.Transactions[1:] {
+		txid, err := tx.Txid(transaction)
+		if err != nil {
+			return nil, err
+		}
+		if txids[i+1], err = merkle.ParseHash(txid); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Job{
+		ID:        id,
+		Template:  template,
+		Coinbase1: serialized[:offset],
+		Coinbase2: serialized[offset+extranonceSize:],
+		Branch:    merkle.CoinbaseBranch(txids),
+		scriptSig: scriptSig,
+	}, nil
+}
+
+// NotifyParams returns the parameters of the mining.notify message of the job
+func (j *Job) NotifyParams(cleanJobs bool) []any {
+	branch := make([]string, len(j.Branch))
+	for i, hash := range j.Branch {
+		branch[i] = hex.EncodeToString(hash[:])
+	}
+	header := j.Template.Header
+	return []any{
+		j.ID,
+		encodePrevHash(header.PreviousBlockHash),
+		hex.EncodeToString(j.Coinbase1),
+		hex.EncodeToString(j.Coinbase2),
+		branch,
+		fmt.Sprintf("%08x", header.Version),
+		fmt.Sprintf("%08x", header.Bits),
+		fmt.Sprintf("%08x", header.Timestamp),
+		cleanJobs,
+	}
+}
+
+// encodePrevHash encodes a previous block hash the way Stratum v1 sends it:
+// the internal byte order with every 4-byte word reversed
+func encodePrevHash(hash [32]byte) string {
+	var swapped [32]byte
+	for i := 0; i < len(hash); i += 4 {
+		binary.BigEndian.PutUint32(swapped[i:], binary.LittleEndian.Uint32(hash[i:]))
+	}
+	return hex.EncodeToString(swapped[:])
+}
+
+// Solve assembles the block a share describes and returns it with its header hash
+func (j *Job) Solve(extranonce1, extranonce2 []byte, timestamp, nonce uint32) (block.Block, [32]byte, error) {
+	if len(extranonce1) != Extranonce1Size || len(extranonce2) != Extranonce2Size {
+		return block.Block{}, [32]byte{}, fmt.Errorf("extranonce must be %d+%d bytes", Extranonce1Size, Extranonce2Size)
+	}
+	coinbase := make([]byte, 0, len(j.Coinbase1)+extranonceSize+len(j.Coinbase2))
+	coinbase = append(coinbase, j.Coinbase1...)
+	coinbase = append(coinbase, extranonce1...)
+	coinbase = append(coinbase, extranonce2...)
+	coinbase = append(coinbase, j.Coinbase2...)
+
+	solved := j.Template
+	solved.Header.MerkleRoot = merkle.ProofRoot(merkle.Hash(hashutil.Hash256(coinbase)), j.Branch, 0)
+	solved.Header.Timestamp = timestamp
+	solved.Header.Nonce = nonce
+
+	solved.Transactions = append([]tx.Transaction(nil), j.Template.Transactions...)
+	coinbaseTx := solved.Transactions[0]
+	coinbaseTx.Vin = []tx.TxInput{coinbaseTx.Vin[0]}
+	scriptSig := append(append(append([]byte(nil), j.scriptSig...), extranonce1...), extranonce2...)
+	coinbaseTx.Vin[0].ScriptSig = scriptSig
+	solved.Transactions[0] = coinbaseTx
+
+	return solved, block.HashHeader(block.SerializeHeader(solved.Header)), nil
+}
@@ -0,0 +1,354 @@
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/block"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/miner"
+)
+
+// maxTimeDrift is how far past the template timestamp a share's ntime may roll
+const maxTimeDrift = 2 * 60 * 60
+
+// maxLineSize bounds a single JSON-RPC line read from a miner
+const maxLineSize = 16 * 1024
+
+// diff1Target is the target of difficulty 1 as defined by Stratum, 0x00000000ffff0000...
+var diff1Target = new(big.Int).Lsh(big.NewInt(0xffff), 208)
+
+// Stratum error codes
+const (
+	errOther         = 20
+	errJobNotFound   = 21
+	errDuplicate     = 22
+	errLowDifficulty = 23
+	errUnauthorized  = 24
+	errNotSubscribed = 25
+)
+
+// Options configures a Server
+type Options struct {
+	Target      [32]byte // block target, from the header bits of the job if zero
+	ShareTarget [32]byte // target a share must meet to be accepted, Target if zero
+	// OnBlock is called for every share that meets the block target
+	OnBlock func(solved block.Block, hash [32]byte)
+	// OnShare is called for every accepted share
+	OnShare func(worker string, hash [32]byte)
+}
+
+// Server serves the current job to connected miners and validates their shares
+type Server struct {
+	options Options
+
+	mu              sync.Mutex
+	job             *Job
+	clients         map[*client]struct{}
+	nextExtranonce1 uint32
+}
+
+// NewServer creates a Server with no job yet
+func NewServer(options Options) *Server {
+	return &Server{options: options, clients: make(map[*client]struct{})}
+}
+
+// targets returns the block and share targets of a job. A zero block target
+// option defers to the job's header bits, a zero share target to the block target.
+func (s *Server) targets(job *Job) (blockTarget, shareTarget [32]byte) {
+	blockTarget = s.options.Target
+	if blockTarget == ([32]byte{}) {
+		blockTarget, _ = block.CompactToTarget(job.Template.Header.Bits)
+	}
+	shareTarget = s.options.ShareTarget
+	if shareTarget == ([32]byte{}) {
+		shareTarget = blockTarget
+	}
+	return blockTarget, shareTarget
+}
+
+// Difficulty returns the Stratum difficulty of a share target
+func Difficulty(shareTarget [32]byte) float64 {
+	target := new(big.Int).SetBytes(shareTarget[:])
+	if target.Sign() == 0 {
+		return 0
+	}
+	difficulty, _ := new(big.Float).Quo(new(big.Float).SetInt(diff1Target), new(big.Float).SetInt(target)).Float64()
+	return difficulty
+}
+
+// SetJob makes job the current job and notifies every subscribed miner.
+// Shares for earlier jobs are rejected from then on.
+func (s *Server) SetJob(job *Job) {
+	s.mu.Lock()
+	s.job = job
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		if c.isSubscribed() {
+			c.notify(job)
+		}
+	}
+}
+
+// Serve accepts miners on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		c := s.newClient(conn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+			c.run()
+		}()
+	}
+}
+
+// client is a connected miner
+type client struct {
+	server      *Server
+	conn        net.Conn
+	extranonce1 []byte
+
+	mu         sync.Mutex // guards the fields below and serialises writes
+	subscribed bool
+	workers    map[string]bool
+	shares     map[string]bool // submitted shares of the current job
+	sharesJob  string
+}
+
+// newClient registers a connection and assigns it an extranonce1
+func (s *Server) newClient(conn net.Conn) *client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := &client{
+		server:      s,
+		conn:        conn,
+		extranonce1: binary.BigEndian.AppendUint32(nil, s.nextExtranonce1),
+		workers:     make(map[string]bool),
+	}
+	s.nextExtranonce1++
+	s.clients[c] = struct{}{}
+	return c
+}
+
+// request is a JSON-RPC call from a miner
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// rpcError is a Stratum error, sent as [code, message, null]
+type rpcError struct {
+	Code    int
+	Message string
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func (e *rpcError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Code, e.Message, nil})
+}
+
+// run reads requests until the connection closes
+func (c *client) run() {
+	defer func() {
+		c.conn.Close()
+		c.server.mu.Lock()
+		delete(c.server.clients, c)
+		c.server.mu.Unlock()
+	}()
+	remote := c.conn.RemoteAddr().String()
+	slog.Debug("stratum client connected", "remote", remote)
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			slog.Debug("invalid stratum request", "remote", remote, "err", err)
+			return
+		}
+		result, err := c.handle(req)
+		var rpcErr *rpcError
+		if err != nil && !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: errOther, Message: err.Error()}
+		}
+		response := map[string]any{"id": req.ID, "result": result, "error": nil}
+		if rpcErr != nil {
+			response["error"] = rpcErr
+		}
+		if err := c.send(response); err != nil {
+			return
+		}
+		if req.Method == "mining.subscribe" && rpcErr == nil {
+			c.server.mu.Lock()
+			job := c.server.job
+			c.server.mu.Unlock()
+			if job != nil {
+				c.notify(job)
+			}
+		}
+	}
+	slog.Debug("stratum client disconnected", "remote", remote, "err", scanner.Err())
+}
+
+// send writes one JSON-RPC message as a line
+func (c *client) send(message any) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.conn.Write(append(line, '\n'))
+	return err
+}
+
+// notify sends the share difficulty and a job with clean_jobs set, as every
+// job replaces the previous template
+func (c *client) notify(job *Job) {
+	_, shareTarget := c.server.targets(job)
+	c.send(map[string]any{"id": nil, "method": "mining.set_difficulty", "params": []any{Difficulty(shareTarget)}})
+	c.send(map[string]any{"id": nil, "method": "mining.notify", "params": job.NotifyParams(true)})
+}
+
+func (c *client) isSubscribed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscribed
+}
+
+// handle dispatches a request and returns its result
+func (c *client) handle(req request) (any, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		c.mu.Lock()
+		c.subscribed = true
+		c.mu.Unlock()
+		subscription := hex.EncodeToString(c.extranonce1)
+		return []any{
+			[][]string{{"mining.set_difficulty", subscription}, {"mining.notify", subscription}},
+			hex.EncodeToString(c.extranonce1),
+			Extranonce2Size,
+		}, nil
+	case "mining.authorize":
+		var worker string
+		if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &worker) != nil {
+			return nil, errors.New("mining.authorize expects a worker name")
+		}
+		c.mu.Lock()
+		c.workers[worker] = true
+		c.mu.Unlock()
+		return true, nil
+	case "mining.submit":
+		return c.submit(req.Params)
+	case "mining.extranonce.subscribe":
+		return false, nil
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// submit validates a share: [worker, job id, extranonce2, ntime, nonce]
+func (c *client) submit(params []json.RawMessage) (any, error) {
+	var fields [5]string
+	if len(params) < len(fields) {
+		return nil, fmt.Errorf("mining.submit expects %d parameters, got %d", len(fields), len(params))
+	}
+	for i := range fields {
+		if err := json.Unmarshal(params[i], &fields[i]); err != nil {
+			return nil, fmt.Errorf("mining.submit parameter %d: %w", i, err)
+		}
+	}
+	worker, jobID := fields[0], fields[1]
+
+	if !c.isSubscribed() {
+		return nil, &rpcError{Code: errNotSubscribed, Message: "Not subscribed"}
+	}
+	c.mu.Lock()
+	authorized := c.workers[worker]
+	c.mu.Unlock()
+	if !authorized {
+		return nil, &rpcError{Code: errUnauthorized, Message: "Unauthorized worker"}
+	}
+	c.server.mu.Lock()
+	job := c.server.job
+	c.server.mu.Unlock()
+	if job == nil || job.ID != jobID {
+		return nil, &rpcError{Code: errJobNotFound, Message: "Job not found"}
+	}
+
+	extranonce2, err := hex.DecodeString(fields[2])
+	if err != nil || len(extranonce2) != Extranonce2Size {
+		return nil, fmt.Errorf("extranonce2 must be %d hex bytes", Extranonce2Size)
+	}
+	timestamp, err := strconv.ParseUint(fields[3], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ntime: %w", err)
+	}
+	if base := uint64(job.Template.Header.Timestamp); timestamp < base || timestamp > base+maxTimeDrift {
+		return nil, errors.New("ntime out of range")
+	}
+	nonce, err := strconv.ParseUint(fields[4], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+
+	key := fields[2] + fields[3] + fields[4]
+	c.mu.Lock()
+	if c.sharesJob != job.ID {
+		c.shares, c.sharesJob = make(map[string]bool), job.ID
+	}
+	duplicate := c.shares[key]
+	c.shares[key] = true
+	c.mu.Unlock()
+	if duplicate {
+		return nil, &rpcError{Code: errDuplicate, Message: "Duplicate share"}
+	}
+
+	solved, hash, err := job.Solve(c.extranonce1, extranonce2, uint32(timestamp), uint32(nonce))
+	if err != nil {
+		return nil, err
+	}
+	blockTarget, shareTarget := c.server.targets(job)
+	if !miner.HashMeetsTarget(hash, shareTarget) {
+		return nil, &rpcError{Code: errLowDifficulty, Message: "Low difficulty share"}
+	}
+	slog.Debug("share accepted", "worker", worker, "job", job.ID, "hash", block.HashToString(hash))
+	if c.server.options.OnShare != nil {
+		c.server.options.OnShare(worker, hash)
+	}
+	if miner.HashMeetsTarget(hash, blockTarget) && c.server.options.OnBlock != nil {
+		c.server.options.OnBlock(solved, hash)
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+// Package taggedhash implements the BIP340 tagged hashes used by taproot:
+// SHA256(SHA256(tag) || SHA256(tag) || data).
+package taggedhash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Tags defined by BIP340 and BIP341
+const (
+	TagTapLeaf         = "TapLeaf"
+	TagTapBranch       = "TapBranch"
+	TagTapTweak        = "TapTweak"
+	TagTapSighash      = "TapSighash"
+	TagBIP340Aux       = "BIP0340/aux"
+	TagBIP340Nonce     = "BIP0340/nonce"
+	TagBIP340Challenge = "BIP0340/challenge"
+)
+
+// tagPrefixes caches SHA256(tag) || SHA256(tag) for each tag seen
+var tagPrefixes sync.Map
+
+// prefix returns the 64-byte prefix hashed in front of the data of a tag
+func prefix(tag string) []byte {
+	if cached, ok := tagPrefixes.Load(tag); ok {
+		return cached.([]byte)
+	}
+	tagHash := sha256.Sum256([]byte(tag))
+	p := append(tagHash[:], tagHash[:]...)
+	tagPrefixes.Store(tag, p)
+	return p
+}
+
+// Sum computes the tagged hash of the concatenation of data
+func Sum(tag string, data ...[]byte) [32]byte {
+	h := sha256.New()
+	h.Write(prefix(tag))
+	for _, d := range data {
+		h.Write(d)
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// TapLeaf computes the hash of a script tree leaf
+func TapLeaf(leafVersion byte, script []byte) [32]byte {
+	return Sum(TagTapLeaf, []byte{leafVersion}, tx.AppendVarInt(nil, uint64(len(script))), script)
+}
+
+// TapBranch computes the hash of a script tree node from its children, which
+// are hashed in lexicographic order
+func TapBranch(a, b [32]byte) [32]byte {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return Sum(TagTapBranch, a[:], b[:])
+}
+
+// TapTweak computes the tweak added to an x-only internal key. merkleRoot is
+// nil for outputs without a script tree.
+func TapTweak(internalKey []byte, merkleRoot []byte) [32]byte {
+	return Sum(TagTapTweak, internalKey, merkleRoot)
+}
+
+// TapSighash computes the BIP341 signature hash of a serialized sighash message,
+// which starts with the sighash epoch byte
+func TapSighash(message []byte) [32]byte {
+	return Sum(TagTapSighash, message)
+}
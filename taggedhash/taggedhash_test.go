@@ -0,0 +1,24 @@
+package taggedhash
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestTapLeafTweak checks scriptPubKey test vector 1 of the BIP341 wallet test
+// vectors
+func TestTapLeafTweak(t *testing.T) {
+	leafScript, _ := hex.DecodeString("20d85a959b0290bf19bb89ed43c916be835475d013da4b362117393e25a48229b8ac")
+	internalKey, _ := hex.DecodeString("187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27")
+	leafHash := TapLeaf(0xc0, leafScript)
+	if got, want := hex.EncodeToString(leafHash[:]), "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21"; got != want {
+		t.Errorf("leaf hash %s, want %s", got, want)
+	}
+	tweak := TapTweak(internalKey, leafHash[:])
+	if got, want := hex.EncodeToString(tweak[:]), "cbd8679ba636c1110ea247542cfbd964131a6be84f873f7f3b62a777528ed001"; got != want {
+		t.Errorf("tweak %s, want %s", got, want)
+	}
+	if TapBranch(leafHash, tweak) != TapBranch(tweak, leafHash) {
+		t.Error("TapBranch depends on the order of its children")
+	}
+}
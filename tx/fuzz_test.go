@@ -0,0 +1,31 @@
+package tx
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzParseTransaction checks that any transaction Parse accepts serializes
+// back to the same bytes. The corpus in testdata/fuzz holds one mempool
+// transaction of each input type.
+func FuzzParseTransaction(f *testing.F) {
+	serialized, err := Serialize(p2wpkhTransaction, true)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(serialized)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		transaction, err := Parse(data)
+		if err != nil {
+			return // rejecting malformed input is fine, panicking is not
+		}
+		withWitness := HasWitness(transaction)
+		reserialized, err := Serialize(transaction, withWitness)
+		if err != nil {
+			t.Fatalf("parsed transaction does not serialize: %v", err)
+		}
+		if !bytes.Equal(reserialized, data) {
+			t.Fatalf("round trip mismatch:\n got %x\nwant %x", reserialized, data)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package tx
+
+import "encoding/hex"
+
+// HexBytes is binary data, such as a script or witness item, that JSON holds
+// as a hex string. It is decoded once when a transaction is loaded and
+// encoded again only when written out.
+type HexBytes []byte
+
+// MarshalText implements encoding.TextMarshaler
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return hex.AppendEncode(nil, b), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	decoded, err := hex.AppendDecode(make([]byte, 0, len(text)/2), text)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// String returns the hex encoding of b
+func (b HexBytes) String() string {
+	return hex.EncodeToString(b)
+}
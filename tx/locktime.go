@@ -0,0 +1,19 @@
+package tx
+
+// Lock time and relative lock time encodings (BIP65, BIP68)
+const (
+	// LockTimeThreshold separates lock times that are block heights, below
+	// it, from ones that are Unix timestamps
+	LockTimeThreshold = 500000000
+
+	// SequenceFinal opts an input out of the transaction's lock time
+	SequenceFinal = 0xffffffff
+
+	// SequenceLockTimeDisableFlag opts an input out of BIP68 relative lock times
+	SequenceLockTimeDisableFlag = 1 << 31
+	// SequenceLockTimeTypeFlag makes a relative lock time count units of
+	// 512 seconds instead of blocks
+	SequenceLockTimeTypeFlag = 1 << 22
+	// SequenceLockTimeMask extracts the relative lock time value
+	SequenceLockTimeMask = 0x0000ffff
+)
@@ -0,0 +1,269 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// reverseBytes returns a reversed copy of a byte slice (txids are displayed byte-reversed)
+func reverseBytes(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for i := range data {
+		reversed[len(data)-1-i] = data[i]
+	}
+	return reversed
+}
+
+// decodeTxid decodes a displayed txid into the byte order of the wire format
+// without allocating
+func decodeTxid(txid string) ([32]byte, error) {
+	var decoded [32]byte
+	if len(txid)%2 != 0 {
+		return decoded, hex.ErrLength
+	}
+	if len(txid) != 64 {
+		return decoded, fmt.Errorf("invalid txid length %d", len(txid)/2)
+	}
+	for i := range decoded {
+		hi, ok := fromHexChar(txid[2*i])
+		if !ok {
+			return decoded, hex.InvalidByteError(txid[2*i])
+		}
+		lo, ok := fromHexChar(txid[2*i+1])
+		if !ok {
+			return decoded, hex.InvalidByteError(txid[2*i+1])
+		}
+		decoded[31-i] = hi<<4 | lo
+	}
+	return decoded, nil
+}
+
+// fromHexChar returns the value of a hex digit
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// SerializedSize returns the length of Serialize(tx, includeWitness) without
+// serializing the transaction
+func SerializedSize(tx Transaction, includeWitness bool) int {
+	size := 4 + VarIntSize(uint64(len(tx.Vin))) + VarIntSize(uint64(len(tx.Vout))) + 4
+	for _, vin := range tx.Vin {
+		size += 36 + VarIntSize(uint64(len(vin.ScriptSig))) + len(vin.ScriptSig) + 4
+	}
+	for _, vout := range tx.Vout {
+		size += 8 + VarIntSize(uint64(len(vout.ScriptPubKey))) + len(vout.ScriptPubKey)
+	}
+	if includeWitness && HasWitness(tx) {
+		size += 2
+		for _, vin := range tx.Vin {
+			size += VarIntSize(uint64(len(vin.Witness)))
+			for _, item := range vin.Witness {
+				size += VarIntSize(uint64(len(item))) + len(item)
+			}
+		}
+	}
+	return size
+}
+
+// Serialize serializes a transaction in the Bitcoin wire format.
+// The segwit marker, flag and witness stacks are only written when includeWitness
+// is set and the transaction actually has witness data.
+func Serialize(tx Transaction, includeWitness bool) ([]byte, error) {
+	return AppendSerialized(make([]byte, 0, SerializedSize(tx, includeWitness)), tx, includeWitness)
+}
+
+// AppendSerialized appends the serialization of a transaction to dst, so
+// callers hashing many transactions can reuse one buffer
+func AppendSerialized(dst []byte, tx Transaction, includeWitness bool) ([]byte, error) {
+	withWitness := includeWitness && HasWitness(tx)
+	serializedTx := binary.LittleEndian.AppendUint32(dst, tx.Version)
+	if withWitness {
+		serializedTx = append(serializedTx, 0x00, 0x01) // segwit marker and flag
+	}
+
+	// Serialize inputs
+	serializedTx = AppendVarInt(serializedTx, uint64(len(tx.Vin)))
+	for _, vin := range tx.Vin {
+		var err error
+		if serializedTx, err = appendOutpoint(serializedTx, vin); err != nil {
+			return nil, err
+		}
+		serializedTx = AppendVarInt(serializedTx, uint64(len(vin.ScriptSig)))
+		serializedTx = append(serializedTx, vin.ScriptSig...)
+		serializedTx = binary.LittleEndian.AppendUint32(serializedTx, vin.Sequence)
+	}
+
+	// Serialize outputs
+	serializedTx = AppendVarInt(serializedTx, uint64(len(tx.Vout)))
+	for _, vout := range tx.Vout {
+		serializedTx = binary.LittleEndian.AppendUint64(serializedTx, uint64(vout.Value))
+		serializedTx = AppendVarInt(serializedTx, uint64(len(vout.ScriptPubKey)))
+		serializedTx = append(serializedTx, vout.ScriptPubKey...)
+	}
+
+	// Serialize witness stacks, one per input
+	if withWitness {
+		for _, vin := range tx.Vin {
+			serializedTx = AppendVarInt(serializedTx, uint64(len(vin.Witness)))
+			for _, item := range vin.Witness {
+				serializedTx = AppendVarInt(serializedTx, uint64(len(item)))
+				serializedTx = append(serializedTx, item...)
+			}
+		}
+	}
+
+	serializedTx = binary.LittleEndian.AppendUint32(serializedTx, tx.Locktime)
+	return serializedTx, nil
+}
+
+// txReader reads wire-format fields from a byte slice. The first out-of-bounds
+// read sets err, after which all reads return zero values.
+type txReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// read returns the next n bytes
+func (r *txReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = fmt.Errorf("unexpected end of data at offset %d", r.pos)
+		return nil
+	}
+	data := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return data
+}
+
+// readUint32 reads a little-endian uint32
+func (r *txReader) readUint32() uint32 {
+	if data := r.read(4); data != nil {
+		return binary.LittleEndian.Uint32(data)
+	}
+	return 0
+}
+
+// readUint64 reads a little-endian uint64
+func (r *txReader) readUint64() uint64 {
+	if data := r.read(8); data != nil {
+		return binary.LittleEndian.Uint64(data)
+	}
+	return 0
+}
+
+// readVarInt reads a CompactSize integer, rejecting non-canonical encodings
+func (r *txReader) readVarInt() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	reader := bytes.NewReader(r.data[r.pos:])
+	value, err := ReadVarInt(reader)
+	switch {
+	case errors.Is(err, ErrNonCanonicalVarInt):
+		r.err = fmt.Errorf("%w at offset %d", err, r.pos)
+	case err != nil:
+		r.err = fmt.Errorf("unexpected end of data at offset %d", r.pos)
+	}
+	r.pos = len(r.data) - reader.Len()
+	return value
+}
+
+// readCount reads a CompactSize item count, rejecting counts that cannot fit in
+// the remaining data (each item takes at least minItemSize bytes)
+func (r *txReader) readCount(minItemSize int) int {
+	count := r.readVarInt()
+	if r.err == nil && count > uint64(len(r.data)-r.pos)/uint64(minItemSize) {
+		r.err = fmt.Errorf("count %d at offset %d exceeds remaining data", count, r.pos)
+		return 0
+	}
+	return int(count)
+}
+
+// readVarBytes reads a CompactSize length-prefixed byte string
+func (r *txReader) readVarBytes() []byte {
+	return r.read(r.readCount(1))
+}
+
+// Parse deserializes a transaction in the Bitcoin wire format, with or
+// without segwit data. Trailing bytes after the locktime are rejected.
+func Parse(data []byte) (Transaction, error) {
+	tx, n, err := ParsePrefix(data)
+	if err != nil {
+		return tx, err
+	}
+	if n != len(data) {
+		return tx, fmt.Errorf("%d trailing bytes after transaction", len(data)-n)
+	}
+	return tx, nil
+}
+
+// ParsePrefix deserializes the transaction at the start of data and returns it
+// with the number of bytes it occupies, so transactions can be read one after
+// another from a block
+func ParsePrefix(data []byte) (Transaction, int, error) {
+	r := &txReader{data: data}
+	var tx Transaction
+
+	tx.Version = r.readUint32()
+	withWitness := false
+	if r.err == nil && len(data) > r.pos+1 && data[r.pos] == 0x00 {
+		marker := r.read(2)
+		if marker[1] != 0x01 {
+			return tx, 0, fmt.Errorf("invalid segwit flag %#x", marker[1])
+		}
+		withWitness = true
+	}
+
+	// Parse inputs: outpoint (36 bytes), scriptSig and sequence
+	tx.Vin = make([]TxInput, r.readCount(41))
+	for i := range tx.Vin {
+		vin := &tx.Vin[i]
+		vin.Txid = hex.EncodeToString(reverseBytes(r.read(32)))
+		vout := r.readUint32()
+		vin.Vout = int(vout)
+		vin.ScriptSig = bytes.Clone(r.readVarBytes())
+		vin.Sequence = r.readUint32()
+		vin.IsCoinbase = vin.Txid == strings.Repeat("0", 64) && vout == math.MaxUint32
+	}
+
+	// Parse outputs: value and scriptPubKey
+	tx.Vout = make([]TxOutput, r.readCount(9))
+	for i := range tx.Vout {
+		tx.Vout[i].Value = int64(r.readUint64())
+		tx.Vout[i].ScriptPubKey = bytes.Clone(r.readVarBytes())
+	}
+
+	if withWitness {
+		for i := range tx.Vin {
+			tx.Vin[i].Witness = make([]HexBytes, r.readCount(1))
+			for j := range tx.Vin[i].Witness {
+				tx.Vin[i].Witness[j] = bytes.Clone(r.readVarBytes())
+			}
+		}
+		if r.err == nil && !HasWitness(tx) {
+			return tx, 0, fmt.Errorf("segwit flag set but no witness data")
+		}
+	}
+
+	tx.Locktime = r.readUint32()
+	if r.err != nil {
+		return tx, 0, r.err
+	}
+	return tx, r.pos, nil
+}
@@ -0,0 +1,83 @@
+package tx
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// mustHex decodes a hex test fixture
+func mustHex(s string) HexBytes {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// p2wpkhTransaction is a typical one-input, two-output P2WPKH transaction
+var p2wpkhTransaction = Transaction{
+	Version: 2,
+	Vin: []TxInput{{
+		Txid: "4ea9a7c2e42a2b2e5dee1ba6a05c1fcbd5e9a4e8ab44e4566e6c9bd1b7d49d7e",
+		Vout: 1,
+		Witness: []HexBytes{
+			mustHex("3044022100884219ecbb54a6ec4d09597ca6aca49692ded3c2ffb13d1858ca5b70e59fabb4021f2de73021471a01d8f03a71a923b662f00120d181d0f7fa8e06faa1bb750e8f01"),
+			mustHex("0271d4e7a84804c075017593271c370e8983f704f123d22aa747cd321268981cba"),
+		},
+		Sequence: 0xFFFFFFFD,
+		PrevOut: Prevout{
+			ScriptPubKey:     mustHex("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a"),
+			ScriptPubKeyType: "v0_p2wpkh",
+			Value:            100001,
+		},
+	}},
+	Vout: []TxOutput{
+		{ScriptPubKey: mustHex("a91450feb99697a4901d3fe082eca341204fb6711b9487"), ScriptPubKeyType: "p2sh", Value: 60000},
+		{ScriptPubKey: mustHex("0014d5bfb7a6d05d44c1e14443919b30d284c0c0a10a"), ScriptPubKeyType: "v0_p2wpkh", Value: 30001},
+	},
+}
+
+// genesisCoinbase is the coinbase transaction of the mainnet genesis block
+var genesisCoinbase = Transaction{
+	Version: 1,
+	Vin: []TxInput{{
+		Txid:       "0000000000000000000000000000000000000000000000000000000000000000",
+		Vout:       -1,
+		ScriptSig:  mustHex("04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73"),
+		IsCoinbase: true,
+		Sequence:   0xFFFFFFFF,
+	}},
+	Vout: []TxOutput{{
+		ScriptPubKey: mustHex("4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac"),
+		Value:        5000000000,
+	}},
+}
+
+// TestGenesisCoinbaseTxid checks the txid of the genesis coinbase and that
+// SerializedSize agrees with the serialization
+func TestGenesisCoinbaseTxid(t *testing.T) {
+	txid, err := Txid(genesisCoinbase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
+		t.Errorf("got txid %s", txid)
+	}
+	serializedTx, err := Serialize(genesisCoinbase, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := SerializedSize(genesisCoinbase, true); size != len(serializedTx) {
+		t.Errorf("serialized size %d, serialization is %d bytes", size, len(serializedTx))
+	}
+}
+
+// BenchmarkSerializeTx measures the witness serialization of a single transaction
+func BenchmarkSerializeTx(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Serialize(p2wpkhTransaction, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
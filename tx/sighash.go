@@ -0,0 +1,313 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+)
+
+// Signature hash types, the last byte of an ECDSA signature in a script
+const (
+	SigHashAll          = 0x01
+	SigHashNone         = 0x02
+	SigHashSingle       = 0x03
+	SigHashAnyoneCanPay = 0x80
+)
+
+// appendOutpoint appends the 36-byte outpoint an input spends
+func appendOutpoint(dst []byte, vin TxInput) ([]byte, error) {
+	txid, err := decodeTxid(vin.Txid)
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst, txid[:]...)
+	return binary.LittleEndian.AppendUint32(dst, uint32(vin.Vout)), nil
+}
+
+// appendOutput appends an output as serialized in a transaction
+func appendOutput(dst []byte, vout TxOutput) []byte {
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(vout.Value))
+	return appendScript(dst, vout.ScriptPubKey)
+}
+
+// LegacySignatureHash computes the hash an input spending a non-witness output
+// signs: the transaction serialized with every scriptSig emptied except the
+// one of the input at index, replaced by scriptCode, and with inputs and outputs
+// blanked out as hashType requires.
+func LegacySignatureHash(tx Transaction, index int, scriptCode []byte, hashType uint32) ([32]byte, error) {
+	if index < 0 || index >= len(tx.Vin) {
+		return [32]byte{}, fmt.Errorf("input %d out of range", index)
+	}
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+	baseType := hashType & 0x1f
+	if baseType == SigHashSingle && index >= len(tx.Vout) {
+		// Bitcoin Core signs the number one instead of failing
+		return [32]byte{1}, nil
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, tx.Version)
+	inputs := tx.Vin
+	if anyoneCanPay {
+		inputs = tx.Vin[index : index+1]
+		data = AppendVarInt(data, 1)
+	} else {
+		data = AppendVarInt(data, uint64(len(inputs)))
+	}
+	for i, vin := range inputs {
+		signed := anyoneCanPay || i == index
+		var err error
+		if data, err = appendOutpoint(data, vin); err != nil {
+			return [32]byte{}, err
+		}
+		if signed {
+			data = AppendVarInt(data, uint64(len(scriptCode)))
+			data = append(data, scriptCode...)
+		} else {
+			data = AppendVarInt(data, 0)
+		}
+		sequence := vin.Sequence
+		if !signed && (baseType == SigHashNone || baseType == SigHashSingle) {
+			sequence = 0
+		}
+		data = binary.LittleEndian.AppendUint32(data, sequence)
+	}
+
+	switch baseType {
+	case SigHashNone:
+		data = AppendVarInt(data, 0)
+	case SigHashSingle:
+		// Outputs before the signed one are blanked to value -1 and an empty script
+		data = AppendVarInt(data, uint64(index+1))
+		for i := 0; i < index; i++ {
+			data = binary.LittleEndian.AppendUint64(data, 0xffffffffffffffff)
+			data = AppendVarInt(data, 0)
+		}
+		data = appendOutput(data, tx.Vout[index])
+	default:
+		data = AppendVarInt(data, uint64(len(tx.Vout)))
+		for _, vout := range tx.Vout {
+			data = appendOutput(data, vout)
+		}
+	}
+
+	data = binary.LittleEndian.AppendUint32(data, tx.Locktime)
+	data = binary.LittleEndian.AppendUint32(data, hashType)
+	return hashutil.Hash256(data), nil
+}
+
+// WitnessV0SignatureHash computes the hash an input spending a version 0
+// witness program signs (BIP143). amount is the value of the spent output.
+func WitnessV0SignatureHash(tx Transaction, index int, scriptCode []byte, amount int64, hashType uint32) ([32]byte, error) {
+	return NewSigHashCache(tx).WitnessV0SignatureHash(index, scriptCode, amount, hashType)
+}
+
+// SigHashDefault is the taproot sighash type of 64-byte signatures, which
+// signs like SigHashAll (BIP341)
+const SigHashDefault = 0x00
+
+// TapscriptSpend is what a signature in a tapscript commits to beyond a key
+// path signature (BIP342)
+type TapscriptSpend struct {
+	LeafHash         [32]byte // TapLeaf hash of the executed script
+	CodeSeparatorPos uint32   // opcode position of the last executed OP_CODESEPARATOR, 0xffffffff if none
+}
+
+// TaprootSignatureMessage builds the message an input spending a taproot
+// output signs (BIP341), starting with the sighash epoch 0. Its TapSighash
+// tagged hash is the signature hash. annex is the annex of the input with
+// its 0x50 prefix, nil if it has none; tapscript is nil for key path spends.
+func TaprootSignatureMessage(tx Transaction, index int, hashType byte, annex []byte, tapscript *TapscriptSpend) ([]byte, error) {
+	return NewSigHashCache(tx).TaprootSignatureMessage(index, hashType, annex, tapscript)
+}
+
+// appendScript appends a script with its length
+func appendScript(dst, script []byte) []byte {
+	dst = AppendVarInt(dst, uint64(len(script)))
+	return append(dst, script...)
+}
+
+// SigHashCache computes the signature hashes of the inputs of a transaction,
+// hashing what BIP143 and BIP341 signatures commit to about all inputs and
+// outputs only once for the whole transaction rather than once per input.
+// Each hash is computed when first needed. It is not safe for concurrent use.
+type SigHashCache struct {
+	tx Transaction
+
+	// Single SHA256 of the outpoints, sequences and outputs, which BIP143
+	// hashes once more, and of the amounts and scriptPubKeys for BIP341
+	prevouts, sequences, outputs       [32]byte
+	amounts, scriptPubKeys             [32]byte
+	inputsDone, spentDone, outputsDone bool
+}
+
+// NewSigHashCache creates the cache of the signature hashes of tx
+func NewSigHashCache(tx Transaction) *SigHashCache {
+	return &SigHashCache{tx: tx}
+}
+
+// inputHashes returns the hashes of the outpoints and sequences of all inputs
+func (c *SigHashCache) inputHashes() (prevouts, sequences [32]byte, err error) {
+	if !c.inputsDone {
+		var prevoutData, sequenceData []byte
+		for _, vin := range c.tx.Vin {
+			if prevoutData, err = appendOutpoint(prevoutData, vin); err != nil {
+				return prevouts, sequences, err
+			}
+			sequenceData = binary.LittleEndian.AppendUint32(sequenceData, vin.Sequence)
+		}
+		c.prevouts, c.sequences = sha256.Sum256(prevoutData), sha256.Sum256(sequenceData)
+		c.inputsDone = true
+	}
+	return c.prevouts, c.sequences, nil
+}
+
+// spentHashes returns the hashes of the amounts and scriptPubKeys of the
+// outputs all inputs spend
+func (c *SigHashCache) spentHashes() (amounts, scriptPubKeys [32]byte) {
+	if !c.spentDone {
+		var amountData, scriptPubKeyData []byte
+		for _, vin := range c.tx.Vin {
+			amountData = binary.LittleEndian.AppendUint64(amountData, uint64(vin.PrevOut.Value))
+			scriptPubKeyData = appendScript(scriptPubKeyData, vin.PrevOut.ScriptPubKey)
+		}
+		c.amounts, c.scriptPubKeys = sha256.Sum256(amountData), sha256.Sum256(scriptPubKeyData)
+		c.spentDone = true
+	}
+	return c.amounts, c.scriptPubKeys
+}
+
+// outputHash returns the hash of all outputs
+func (c *SigHashCache) outputHash() [32]byte {
+	if !c.outputsDone {
+		var outputData []byte
+		for _, vout := range c.tx.Vout {
+			outputData = appendOutput(outputData, vout)
+		}
+		c.outputs = sha256.Sum256(outputData)
+		c.outputsDone = true
+	}
+	return c.outputs
+}
+
+// WitnessV0SignatureHash computes the hash input index spending a version 0
+// witness program signs (BIP143). amount is the value of the spent output.
+func (c *SigHashCache) WitnessV0SignatureHash(index int, scriptCode []byte, amount int64, hashType uint32) ([32]byte, error) {
+	tx := c.tx
+	if index < 0 || index >= len(tx.Vin) {
+		return [32]byte{}, fmt.Errorf("input %d out of range", index)
+	}
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+	baseType := hashType & 0x1f
+
+	var hashPrevouts, hashSequence, hashOutputs [32]byte
+	if !anyoneCanPay {
+		prevouts, sequences, err := c.inputHashes()
+		if err != nil {
+			return [32]byte{}, err
+		}
+		hashPrevouts = sha256.Sum256(prevouts[:])
+		if baseType != SigHashSingle && baseType != SigHashNone {
+			hashSequence = sha256.Sum256(sequences[:])
+		}
+	}
+	switch {
+	case baseType != SigHashSingle && baseType != SigHashNone:
+		outputs := c.outputHash()
+		hashOutputs = sha256.Sum256(outputs[:])
+	case baseType == SigHashSingle && index < len(tx.Vout):
+		hashOutputs = hashutil.Hash256(appendOutput(nil, tx.Vout[index]))
+	}
+
+	vin := tx.Vin[index]
+	data := binary.LittleEndian.AppendUint32(nil, tx.Version)
+	data = append(data, hashPrevouts[:]...)
+	data = append(data, hashSequence[:]...)
+	data, err := appendOutpoint(data, vin)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	data = AppendVarInt(data, uint64(len(scriptCode)))
+	data = append(data, scriptCode...)
+	data = binary.LittleEndian.AppendUint64(data, uint64(amount))
+	data = binary.LittleEndian.AppendUint32(data, vin.Sequence)
+	data = append(data, hashOutputs[:]...)
+	data = binary.LittleEndian.AppendUint32(data, tx.Locktime)
+	data = binary.LittleEndian.AppendUint32(data, hashType)
+	return hashutil.Hash256(data), nil
+}
+
+// TaprootSignatureMessage builds the message input index spending a taproot
+// output signs (BIP341), as TaprootSignatureMessage does
+func (c *SigHashCache) TaprootSignatureMessage(index int, hashType byte, annex []byte, tapscript *TapscriptSpend) ([]byte, error) {
+	tx := c.tx
+	if index < 0 || index >= len(tx.Vin) {
+		return nil, fmt.Errorf("input %d out of range", index)
+	}
+	if hashType > SigHashSingle && (hashType < SigHashAnyoneCanPay|SigHashAll || hashType > SigHashAnyoneCanPay|SigHashSingle) {
+		return nil, fmt.Errorf("undefined taproot sighash type %#x", hashType)
+	}
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+	outputType := hashType & 0x03
+	if outputType == SigHashDefault {
+		outputType = SigHashAll
+	}
+	if outputType == SigHashSingle && index >= len(tx.Vout) {
+		return nil, fmt.Errorf("SIGHASH_SINGLE input %d has no matching output", index)
+	}
+
+	data := []byte{0x00, hashType} // epoch, hash type
+	data = binary.LittleEndian.AppendUint32(data, tx.Version)
+	data = binary.LittleEndian.AppendUint32(data, tx.Locktime)
+	if !anyoneCanPay {
+		prevouts, sequences, err := c.inputHashes()
+		if err != nil {
+			return nil, err
+		}
+		amounts, scriptPubKeys := c.spentHashes()
+		for _, hash := range [][32]byte{prevouts, amounts, scriptPubKeys, sequences} {
+			data = append(data, hash[:]...)
+		}
+	}
+	if outputType == SigHashAll {
+		outputs := c.outputHash()
+		data = append(data, outputs[:]...)
+	}
+
+	spendType := byte(0)
+	if tapscript != nil {
+		spendType |= 2
+	}
+	if annex != nil {
+		spendType |= 1
+	}
+	data = append(data, spendType)
+	vin := tx.Vin[index]
+	if anyoneCanPay {
+		var err error
+		if data, err = appendOutpoint(data, vin); err != nil {
+			return nil, err
+		}
+		data = binary.LittleEndian.AppendUint64(data, uint64(vin.PrevOut.Value))
+		data = appendScript(data, vin.PrevOut.ScriptPubKey)
+		data = binary.LittleEndian.AppendUint32(data, vin.Sequence)
+	} else {
+		data = binary.LittleEndian.AppendUint32(data, uint32(index))
+	}
+	if annex != nil {
+		hash := sha256.Sum256(append(AppendVarInt(nil, uint64(len(annex))), annex...))
+		data = append(data, hash[:]...)
+	}
+
+	if outputType == SigHashSingle {
+		hash := sha256.Sum256(appendOutput(nil, tx.Vout[index]))
+		data = append(data, hash[:]...)
+	}
+	if tapscript != nil {
+		data = append(data, tapscript.LeafHash[:]...)
+		data = append(data, 0x00) // key version
+		data = binary.LittleEndian.AppendUint32(data, tapscript.CodeSeparatorPos)
+	}
+	return data, nil
+}
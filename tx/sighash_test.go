@@ -0,0 +1,166 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// mustParse parses a raw transaction test fixture
+func mustParse(raw string) Transaction {
+	transaction, err := Parse(mustHex(raw))
+	if err != nil {
+		panic(err)
+	}
+	return transaction
+}
+
+// TestWitnessV0SignatureHash checks every signature hash of the BIP143 examples
+func TestWitnessV0SignatureHash(t *testing.T) {
+	const (
+		p2wpkh              = "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000"
+		p2shP2wpkh          = "0100000001db6b1b20aa0fd7b23880be2ecbd4a98130974cf4748fb66092ac4d3ceb1a54770100000000feffffff02b8b4eb0b000000001976a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac0008af2f000000001976a914fd270b1ee6abcaea97fea7ad0402e8bd8ad6d77c88ac92040000"
+		p2wshExecutedSep    = "0100000002fe3dc9208094f3ffd12645477b3dc56f60ec4fa8e6f5d67c565d1c6b9216b36e0000000000ffffffff0815cf020f013ed6cf91d29f4202e8a58726b1ac6c79da47c23d1bee0a6925f80000000000ffffffff0100f2052a010000001976a914a30741f8145e5acadf23f751864167f32e0963f788ac00000000"
+		p2wshUnexecutedSep  = "0100000002e9b542c5176808107ff1df906f46bb1f2583b16112b95ee5380665ba7fcfc0010000000000ffffffff80e68831516392fcd100d186b3c2c7b95c80b53c77e77c35ba03a66b429a2a1b0000000000ffffffff0280969800000000001976a914de4b231626ef508c9a74a8517e6783c0546d6b2888ac80969800000000001976a9146648a8cd4531e1ec47f35916de8e259237294d1e88ac00000000"
+		p2shP2wsh           = "010000000136641869ca081e70f394c6948e8af409e18b619df2ed74aa106c1ca29787b96e0100000000ffffffff0200e9a435000000001976a914389ffce9cd9ae88dcc0631e88a821ffdbe9bfe2688acc0832f05000000001976a9147480a33f950689af511e6e84c138dbbd3c3ee41588ac00000000"
+		noFindAndDelete     = "010000000169c12106097dc2e0526493ef67f21269fe888ef05c7a3a5dacab38e1ac8387f14c1d000000ffffffff0101000000000000000000000000"
+		noFindAndDeleteMsig = "010000000001019275cb8d4a485ce95741c013f7c0d28722160008021bb469a11982d47a6628964c1d000000ffffffff0101000000000000000007004830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0148304502205286f726690b2e9b0207f0345711e63fa7012045b9eb0f19c2458ce1db90cf43022100e89f17f86abc5b149eba4115d4f128bcf45d77fb3ecdd34f594091340c0395960101022102966f109c54e85d3aee8321301136cedeb9fc710fdef58a9de8a73942f8e567c021034ffc99dd9a79dd3cb31e2ab3e0b09e0e67db41ac068c625cd1f491576016c84e9552af4830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0148304502205286f726690b2e9b0207f0345711e63fa7012045b9eb0f19c2458ce1db90cf43022100e89f17f86abc5b149eba4115d4f128bcf45d77fb3ecdd34f594091340c039596017500000000"
+
+		multisig6of6 = "56210307b8ae49ac90a048e9b53357a2354b3334e9c8bee813ecb98e99a7e07e8c3ba32103b28f0c28bfab54554ae8c658ac5c3e0ce6e79ad336331f78c428dd43eea8449b21034b8113d703413d57761b8b9781957b8c0ac1dfe69f492580ca4195f50376ba4a21033400f6afecb833092a9a21cfdf1ed1376e58c5d1f47de74683123987e967a8f42103a6d48b1131e94ba04d9737d61acdaa1322008af9602b3b14862c07a1789aac162102d8b661b0b3302ee2f162b09e07a55ad5dfbe673a9f01d9f0c19617681024306b56ae"
+	)
+	tests := []struct {
+		name       string
+		tx         string
+		index      int
+		scriptCode string
+		amount     int64
+		hashType   uint32
+		want       string
+	}{
+		{"native P2WPKH", p2wpkh, 1, "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac", 600000000, SigHashAll,
+			"c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670"},
+		{"P2SH-P2WPKH", p2shP2wpkh, 0, "76a91479091972186c449eb1ded22b78e40d009bdf008988ac", 1000000000, SigHashAll,
+			"64f3b0f4dd2bb3aa1ce8566d220cc74dda9df97d8490cc81d89d735c92e59fb6"},
+		// SIGHASH_SINGLE of an input without a matching output commits to no output
+		{"P2WSH before OP_CODESEPARATOR", p2wshExecutedSep, 1, "21026dccc749adc2a9d0d89497ac511f760f45c47dc5ed9cf352a58ac706453880aeadab210255a9626aebf5e29c0e6538428ba0d1dcf6ca98ffdf086aa8ced5e0d0215ea465ac", 4900000000, SigHashSingle,
+			"82dde6e4f1e94d02c2b7ad03d2115d691f48d064e9d52f58194a6637e4194391"},
+		{"P2WSH after OP_CODESEPARATOR", p2wshExecutedSep, 1, "210255a9626aebf5e29c0e6538428ba0d1dcf6ca98ffdf086aa8ced5e0d0215ea465ac", 4900000000, SigHashSingle,
+			"fef7bd749cce710c5c052bd796df1af0d935e59cea63736268bcbe2d2134fc47"},
+		{"P2WSH unexecuted OP_CODESEPARATOR", p2wshUnexecutedSep, 0, "0063ab68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac", 16777215, SigHashSingle | SigHashAnyoneCanPay,
+			"e9071e75e25b8a1e298a72f0d2e9f4f95a0f5cdf86a533cda597eb402ed13b3a"},
+		{"P2WSH executed OP_CODESEPARATOR", p2wshUnexecutedSep, 1, "68210392972e2eb617b2388771abe27235fd5ac44af8e61693261550447a4c3e39da98ac", 16777215, SigHashSingle | SigHashAnyoneCanPay,
+			"cd72f1f1a433ee9df816857fad88d8ebd97e09a75cd481583eb841c330275e54"},
+		{"P2SH-P2WSH ALL", p2shP2wsh, 0, multisig6of6, 987654321, SigHashAll,
+			"185c0be5263dce5b4bb50a047973c1b6272bfbd0103a89444597dc40b248ee7c"},
+		{"P2SH-P2WSH NONE", p2shP2wsh, 0, multisig6of6, 987654321, SigHashNone,
+			"e9733bc60ea13c95c6527066bb975a2ff29a925e80aa14c213f686cbae5d2f36"},
+		{"P2SH-P2WSH SINGLE", p2shP2wsh, 0, multisig6of6, 987654321, SigHashSingle,
+			"1e1f1c303dc025bd664acb72e583e933fae4cff9148bf78c157d1e8f78530aea"},
+		{"P2SH-P2WSH ALL|ANYONECANPAY", p2shP2wsh, 0, multisig6of6, 987654321, SigHashAll | SigHashAnyoneCanPay,
+			"2a67f03e63a6a422125878b40b82da593be8d4efaafe88ee528af6e5a9955c6e"},
+		{"P2SH-P2WSH NONE|ANYONECANPAY", p2shP2wsh, 0, multisig6of6, 987654321, SigHashNone | SigHashAnyoneCanPay,
+			"781ba15f3779d5542ce8ecb5c18716733a5ee42a6f51488ec96154934e2c890a"},
+		{"P2SH-P2WSH SINGLE|ANYONECANPAY", p2shP2wsh, 0, multisig6of6, 987654321, SigHashSingle | SigHashAnyoneCanPay,
+			"511e8e52ed574121fc1b654970395502128263f62662e076dc6baf05c2e6a99b"},
+		// The signature is left in the scriptCode, which FindAndDelete would remove
+		{"no FindAndDelete", noFindAndDelete, 0, "ad4830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e01", 200000, SigHashAll,
+			"71c9cd9b2869b9c70b01b1f0360c148f42dee72297db312638df136f43311f23"},
+		{"no FindAndDelete OP_CHECKMULTISIGVERIFY", noFindAndDeleteMsig, 0, "52af4830450220487fb382c4974de3f7d834c1b617fe15860828c7f96454490edd6d891556dcc9022100baf95feb48f845d5bfc9882eb6aeefa1bc3790e39f59eaa46ff7f15ae626c53e0148304502205286f726690b2e9b0207f0345711e63fa7012045b9eb0f19c2458ce1db90cf43022100e89f17f86abc5b149eba4115d4f128bcf45d77fb3ecdd34f594091340c0395960175", 200000, SigHashAll,
+			"c1628a1e7c67f14ca0c27c06e4fdeec2e6d1a73c7a91d7c046ff83e835aebb72"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transaction := mustParse(test.tx)
+			hash, err := WitnessV0SignatureHash(transaction, test.index, mustHex(test.scriptCode), test.amount, test.hashType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(hash[:]); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+
+			// A cache shared by the inputs gives the same hash, whatever was hashed before
+			cache := NewSigHashCache(transaction)
+			for _, hashType := range []uint32{SigHashSingle | SigHashAnyoneCanPay, SigHashNone, SigHashAll} {
+				for index := range transaction.Vin {
+					if _, err := cache.WitnessV0SignatureHash(index, nil, 1000, hashType); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if hash, err = cache.WitnessV0SignatureHash(test.index, mustHex(test.scriptCode), test.amount, test.hashType); err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(hash[:]); got != test.want {
+				t.Errorf("shared cache: got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// bip341Transaction is the unsigned transaction of the BIP341 keyPathSpending
+// wallet test vectors, with the outputs its inputs spend
+func bip341Transaction() Transaction {
+	transaction := mustParse("02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d")
+	spent := []Prevout{
+		{ScriptPubKey: mustHex("512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343"), Value: 420000000},
+		{ScriptPubKey: mustHex("5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3"), Value: 462000000},
+		{ScriptPubKey: mustHex("76a914751e76e8199196d454941c45d1b3a323f1433bd688ac"), Value: 294000000},
+		{ScriptPubKey: mustHex("5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e"), Value: 504000000},
+		{ScriptPubKey: mustHex("512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605"), Value: 630000000},
+		{ScriptPubKey: mustHex("00147dd65592d0ab2fe0d0257d571abf032cd9db93dc"), Value: 378000000},
+		{ScriptPubKey: mustHex("512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831"), Value: 672000000},
+		{ScriptPubKey: mustHex("5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5"), Value: 546000000},
+		{ScriptPubKey: mustHex("512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220"), Value: 588000000},
+	}
+	for i := range transaction.Vin {
+		transaction.Vin[i].PrevOut = spent[i]
+	}
+	return transaction
+}
+
+// tapSighash is the TapSighash tagged hash of a signature message
+func tapSighash(message []byte) [32]byte {
+	tag := sha256.Sum256([]byte("TapSighash"))
+	return sha256.Sum256(append(append(tag[:], tag[:]...), message...))
+}
+
+// TestTaprootSignatureMessage checks the signature message and hash of every
+// input of the BIP341 keyPathSpending wallet test vectors
+func TestTaprootSignatureMessage(t *testing.T) {
+	tests := []struct {
+		index    int
+		hashType byte
+		sigMsg   string
+		sigHash  string
+	}{
+		{0, 0x03, "0003020000000065cd1de3b33bb4ef3a52ad1fffb555c0d82828eb22737036eaeb02a235d82b909c4c3f58a6964a4f5f8f0b642ded0a8a553be7622a719da71d1f5befcefcdee8e0fde623ad0f61ad2bca5ba6a7693f50fce988e17c3780bf2b1e720cfbb38fbdd52e2118959c7221ab5ce9e26c3cd67b22c24f8baa54bac281d8e6b05e400e6c3a957e0000000000d0418f0e9a36245b9a50ec87f8bf5be5bcae434337b87139c3a5b1f56e33cba0", "2514a6272f85cfa0f45eb907fcb0d121b808ed37c6ea160a5a9046ed5526d555"},
+		{1, 0x83, "0083020000000065cd1d00d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd9900000000808f891b00000000225120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3ffffffffffcef8fb4ca7efc5433f591ecfc57391811ce1e186a3793024def5c884cba51d", "325a644af47e8a5a2591cda0ab0723978537318f10e6a63d4eed783b96a71a4d"},
+		{3, 0x01, "0001020000000065cd1de3b33bb4ef3a52ad1fffb555c0d82828eb22737036eaeb02a235d82b909c4c3f58a6964a4f5f8f0b642ded0a8a553be7622a719da71d1f5befcefcdee8e0fde623ad0f61ad2bca5ba6a7693f50fce988e17c3780bf2b1e720cfbb38fbdd52e2118959c7221ab5ce9e26c3cd67b22c24f8baa54bac281d8e6b05e400e6c3a957ea2e6dab7c1f0dcd297c8d61647fd17d821541ea69c3cc37dcbad7f90d4eb4bc50003000000", "bf013ea93474aa67815b1b6cc441d23b64fa310911d991e713cd34c7f5d46669"},
+		{4, 0x00, "0000020000000065cd1de3b33bb4ef3a52ad1fffb555c0d82828eb22737036eaeb02a235d82b909c4c3f58a6964a4f5f8f0b642ded0a8a553be7622a719da71d1f5befcefcdee8e0fde623ad0f61ad2bca5ba6a7693f50fce988e17c3780bf2b1e720cfbb38fbdd52e2118959c7221ab5ce9e26c3cd67b22c24f8baa54bac281d8e6b05e400e6c3a957ea2e6dab7c1f0dcd297c8d61647fd17d821541ea69c3cc37dcbad7f90d4eb4bc50004000000", "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef"},
+		{6, 0x02, "0002020000000065cd1de3b33bb4ef3a52ad1fffb555c0d82828eb22737036eaeb02a235d82b909c4c3f58a6964a4f5f8f0b642ded0a8a553be7622a719da71d1f5befcefcdee8e0fde623ad0f61ad2bca5ba6a7693f50fce988e17c3780bf2b1e720cfbb38fbdd52e2118959c7221ab5ce9e26c3cd67b22c24f8baa54bac281d8e6b05e400e6c3a957e0006000000", "15f25c298eb5cdc7eb1d638dd2d45c97c4c59dcaec6679cfc16ad84f30876b85"},
+		{7, 0x82, "0082020000000065cd1d00e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf00000000804c8b2000000000225120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5ffffffff", "cd292de50313804dabe4685e83f923d2969577191a3e1d2882220dca88cbeb10"},
+		{8, 0x81, "0081020000000065cd1da2e6dab7c1f0dcd297c8d61647fd17d821541ea69c3cc37dcbad7f90d4eb4bc500a778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af101000000002b0c230000000022512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220ffffffff", "cccb739eca6c13a8a89e6e5cd317ffe55669bbda23f2fd37b0f18755e008edd2"},
+	}
+	transaction := bip341Transaction()
+	cache := NewSigHashCache(transaction)
+	for _, test := range tests {
+		message, err := TaprootSignatureMessage(transaction, test.index, test.hashType, nil, nil)
+		if err != nil {
+			t.Fatalf("input %d: %v", test.index, err)
+		}
+		if got := hex.EncodeToString(message); got != test.sigMsg {
+			t.Errorf("input %d: got message %s, want %s", test.index, got, test.sigMsg)
+		}
+		if hash := tapSighash(message); hex.EncodeToString(hash[:]) != test.sigHash {
+			t.Errorf("input %d: got hash %x, want %s", test.index, hash, test.sigHash)
+		}
+		// The inputs share the cached hashes of the transaction
+		if message, err = cache.TaprootSignatureMessage(test.index, test.hashType, nil, nil); err != nil {
+			t.Fatalf("input %d: %v", test.index, err)
+		}
+		if got := hex.EncodeToString(message); got != test.sigMsg {
+			t.Errorf("input %d: shared cache: got message %s, want %s", test.index, got, test.sigMsg)
+		}
+	}
+}
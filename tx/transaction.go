@@ -0,0 +1,227 @@
+// Package tx defines Bitcoin transactions as found in the mempool JSON files,
+// together with their wire serialization, weight and fee calculations.
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/hashutil"
+)
+
+const (
+	MaxCoinValue       = 21000000 // Maximum number of bitcoins
+	MinTransactionSize = 100      // Minimum transaction size in bytes
+	MinTransactionFee  = 1000     // Minimum transaction fee
+
+	// MaxMoney is the largest amount of satoshis a value or sum of values may
+	// hold, MAX_MONEY. It is checked before summing, so sums never overflow.
+	MaxMoney int64 = MaxCoinValue * 100000000
+)
+
+// Errors returned by CheckValues
+var (
+	ErrValueOutOfRange    = errors.New("value out of range") // an amount or sum is outside [0, MaxMoney]
+	ErrNegativeOutput     = errors.New("negative output value")
+	ErrNonPositivePrevout = errors.New("prevout value is not positive") // the spent output is worthless or missing
+)
+
+// ErrDuplicateInput is returned by CheckDuplicateInputs
+var ErrDuplicateInput = errors.New("outpoint spent twice by the same transaction")
+
+// CheckDuplicateInputs verifies that no two inputs of a transaction spend the
+// same outpoint. Each would add the prevout value to the inputs again, creating
+// money out of nothing (CVE-2018-17144).
+func CheckDuplicateInputs(tx Transaction) error {
+	type outpoint struct {
+		txid string
+		vout int
+	}
+	spent := make(map[outpoint]int, len(tx.Vin))
+	for i, vin := range tx.Vin {
+		key := outpoint{vin.Txid, vin.Vout}
+		if first, ok := spent[key]; ok {
+			return fmt.Errorf("%w: inputs %d and %d spend %s:%d", ErrDuplicateInput, first, i, vin.Txid, vin.Vout)
+		}
+		spent[key] = i
+	}
+	return nil
+}
+
+// MoneyRange reports whether an amount of satoshis is within [0, MaxMoney]
+func MoneyRange(value int64) bool {
+	return value >= 0 && value <= MaxMoney
+}
+
+// CheckValues verifies that every output value and prevout value of a
+// transaction, and their running sums, are within MoneyRange, as Bitcoin Core
+// does in CheckTransaction and CheckTxInputs. Negative outputs and prevouts
+// without a positive value are reported with their own errors, since they would
+// otherwise only show up as a skewed fee.
+func CheckValues(tx Transaction) error {
+	var output int64
+	for i, vout := range tx.Vout {
+		if vout.Value < 0 {
+			return fmt.Errorf("%w: output %d has value %d", ErrNegativeOutput, i, vout.Value)
+		}
+		if !MoneyRange(vout.Value) {
+			return fmt.Errorf("%w: output %d has value %d", ErrValueOutOfRange, i, vout.Value)
+		}
+		output += vout.Value
+		if !MoneyRange(output) {
+			return fmt.Errorf("%w: outputs up to %d sum to %d", ErrValueOutOfRange, i, output)
+		}
+	}
+	var input int64
+	for i, vin := range tx.Vin {
+		if vin.PrevOut.Value <= 0 {
+			return fmt.Errorf("%w: input %d spends value %d", ErrNonPositivePrevout, i, vin.PrevOut.Value)
+		}
+		if !MoneyRange(vin.PrevOut.Value) {
+			return fmt.Errorf("%w: input %d spends value %d", ErrValueOutOfRange, i, vin.PrevOut.Value)
+		}
+		input += vin.PrevOut.Value
+		if !MoneyRange(input) {
+			return fmt.Errorf("%w: inputs up to %d sum to %d", ErrValueOutOfRange, i, input)
+		}
+	}
+	return nil
+}
+
+// Transaction represents a Bitcoin transaction
+type Transaction struct {
+	Version  uint32     `json:"version"`
+	Locktime uint32     `json:"locktime"`
+	Vin      []TxInput  `json:"vin"`
+	Vout     []TxOutput `json:"vout"`
+	File     string     `json:"-"` // mempool file it was read from, empty if it was not
+}
+
+// TxInput is a transaction input together with the output it spends
+type TxInput struct {
+	Txid       string     `json:"txid"`
+	Vout       int        `json:"vout"`
+	ScriptSig  HexBytes   `json:"scriptsig"`
+	Witness    []HexBytes `json:"witness"`
+	IsCoinbase bool       `json:"is_coinbase"`
+	Sequence   uint32     `json:"sequence"`
+	PrevOut    Prevout    `json:"prevout"`
+}
+
+// Prevout is the output spent by an input, as supplied in the mempool JSON
+type Prevout struct {
+	ScriptPubKey     HexBytes `json:"scriptpubkey"`
+	ScriptPubKeyASM  string   `json:"scriptpubkey_asm"`
+	ScriptPubKeyType string   `json:"scriptpubkey_type"`
+	ScriptPubKeyAddr string   `json:"scriptpubkey_address"`
+	Value            int64    `json:"value"`
+}
+
+// TxOutput is a transaction output
+type TxOutput struct {
+	ScriptPubKey     HexBytes `json:"scriptpubkey"`
+	ScriptPubKeyASM  string   `json:"scriptpubkey_asm"`
+	ScriptPubKeyType string   `json:"scriptpubkey_type"`
+	ScriptPubKeyAddr string   `json:"scriptpubkey_address"`
+	Value            int64    `json:"value"`
+}
+
+// HasWitness reports whether any input of the transaction carries witness data
+func HasWitness(tx Transaction) bool {
+	for _, vin := range tx.Vin {
+		if len(vin.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Txid computes the transaction id: the byte-reversed double SHA256 of the
+// serialization without witness data
+func Txid(tx Transaction) (string, error) {
+	return hashSerialized(tx, false)
+}
+
+// Wtxid computes the witness transaction id: the byte-reversed double SHA256
+// of the serialization with witness data. It equals the txid of a transaction
+// without witness data.
+func Wtxid(tx Transaction) (string, error) {
+	return hashSerialized(tx, true)
+}
+
+// serializeBuffers holds the buffers transactions are serialized into to be
+// hashed, so computing ids does not allocate a serialization each time.
+// Buffers grown past maxPooledBuffer by an unusually large transaction are
+// not kept.
+var serializeBuffers = sync.Pool{New: func() any { return new([]byte) }}
+
+const maxPooledBuffer = 1 << 16
+
+// hashSerialized returns the byte-reversed hex of the double SHA256 of the
+// serialization of tx
+func hashSerialized(tx Transaction, includeWitness bool) (string, error) {
+	buffer := serializeBuffers.Get().(*[]byte)
+	serializedTx, err := AppendSerialized((*buffer)[:0], tx, includeWitness)
+	if err != nil {
+		serializeBuffers.Put(buffer)
+		return "", err
+	}
+	hash := hashutil.Hash256(serializedTx)
+	if cap(serializedTx) <= maxPooledBuffer {
+		*buffer = serializedTx
+		serializeBuffers.Put(buffer)
+	}
+	slices.Reverse(hash[:])
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Weight calculates the weight of a transaction in weight units (BIP141)
+func Weight(tx Transaction) (int, error) {
+	// Only the txids can make a transaction unserializable
+	for _, vin := range tx.Vin {
+		if _, err := decodeTxid(vin.Txid); err != nil {
+			return 0, err
+		}
+	}
+	return SerializedSize(tx, false)*3 + SerializedSize(tx, true), nil
+}
+
+// Fee returns the fee paid by a transaction, its prevout values minus its
+// output values. A transaction failing CheckValues, or spending less than it
+// creates, pays no fee and Fee returns 0.
+func Fee(tx Transaction) int64 {
+	if CheckValues(tx) != nil {
+		return 0
+	}
+	var fee int64
+	for _, vin := range tx.Vin {
+		fee += vin.PrevOut.Value
+	}
+	for _, vout := range tx.Vout {
+		fee -= vout.Value
+	}
+	return max(fee, 0)
+}
+
+// VirtualSize converts a weight to virtual bytes, rounding up (BIP141)
+func VirtualSize(weight int) int {
+	return (weight + 3) / 4
+}
+
+// FeeRate returns the fee rate of a transaction in sat/vB, or 0 if it pays no
+// fee or cannot be serialized
+func FeeRate(tx Transaction) float64 {
+	weight, err := Weight(tx)
+	if err != nil || weight == 0 {
+		return 0
+	}
+	return float64(Fee(tx)) / float64(VirtualSize(weight))
+}
+
+// Validate verifies that a transaction spends no outpoint twice and pays a fee
+func Validate(tx Transaction) bool {
+	return CheckDuplicateInputs(tx) == nil && Fee(tx) > 0
+}
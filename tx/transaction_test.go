@@ -0,0 +1,82 @@
+package tx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestCheckValues checks amounts and their sums are kept within MoneyRange
+func TestCheckValues(t *testing.T) {
+	for value, want := range map[int64]bool{-1: false, 0: true, MaxMoney: true, MaxMoney + 1: false} {
+		if MoneyRange(value) != want {
+			t.Errorf("MoneyRange(%d) is %v", value, !want)
+		}
+	}
+	tests := []struct {
+		name string
+		tx   Transaction
+		want error
+	}{
+		// Two outputs of MaxMoney each are in range on their own, not together
+		{"outputs summing past MaxMoney", Transaction{Vout: []TxOutput{{Value: MaxMoney}, {Value: MaxMoney}}}, ErrValueOutOfRange},
+		// A value that would wrap the sum to a small positive fee
+		{"negative outputs", Transaction{
+			Vin:  []TxInput{{PrevOut: Prevout{Value: 1000}}},
+			Vout: []TxOutput{{Value: -1 << 62}, {Value: -1 << 62}},
+		}, ErrNegativeOutput},
+		{"zero-value prevout", Transaction{Vin: []TxInput{{PrevOut: Prevout{Value: 0}}}}, ErrNonPositivePrevout},
+	}
+	for _, test := range tests {
+		if err := CheckValues(test.tx); !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+		if Validate(test.tx) {
+			t.Errorf("%s: transaction validates", test.name)
+		}
+	}
+}
+
+// TestCheckDuplicateInputs checks a transaction may not spend an outpoint twice
+func TestCheckDuplicateInputs(t *testing.T) {
+	input := TxInput{Txid: strings.Repeat("11", 32), Vout: 1, PrevOut: Prevout{Value: 50000}}
+	other := input
+	other.Vout = 2
+	inflating := Transaction{Vin: []TxInput{input, other, input}, Vout: []TxOutput{{Value: 140000}}}
+	if err := CheckDuplicateInputs(inflating); !errors.Is(err, ErrDuplicateInput) {
+		t.Errorf("repeated outpoint: got %v", err)
+	}
+	if Validate(inflating) {
+		t.Error("transaction spending an outpoint twice validates")
+	}
+	if err := CheckDuplicateInputs(Transaction{Vin: []TxInput{input, other}}); err != nil {
+		t.Errorf("distinct outpoints: %v", err)
+	}
+}
+
+// TestFee checks fees, fee rates and that transactions creating money pay none
+func TestFee(t *testing.T) {
+	input := TxInput{Txid: strings.Repeat("11", 32), PrevOut: Prevout{Value: 50000}}
+	tests := []struct {
+		name string
+		vout []TxOutput
+		want int64
+	}{
+		{"paying", []TxOutput{{Value: 40000}}, 10000},
+		{"outputs exceeding inputs", []TxOutput{{Value: 60000}}, 0},
+		{"negative output", []TxOutput{{Value: -1}}, 0},
+	}
+	for _, test := range tests {
+		if fee := Fee(Transaction{Version: 2, Vin: []TxInput{input}, Vout: test.vout}); fee != test.want {
+			t.Errorf("%s: fee %d, want %d", test.name, fee, test.want)
+		}
+	}
+	// 60 bytes without a witness are 240 weight units, 60 vbytes
+	paying := Transaction{Version: 2, Vin: []TxInput{input}, Vout: []TxOutput{{Value: 40000}}}
+	if rate := FeeRate(paying); rate != 10000.0/60 {
+		t.Errorf("fee rate %v, want %v", rate, 10000.0/60)
+	}
+	if size := VirtualSize(245); size != 62 {
+		t.Errorf("245 weight units are %d vbytes, want 62", size)
+	}
+}
@@ -0,0 +1,90 @@
+package tx
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrNonCanonicalVarInt is returned when a CompactSize integer is not encoded in
+// its shortest form, which consensus rules reject
+var ErrNonCanonicalVarInt = errors.New("non-canonical CompactSize encoding")
+
+// VarIntSize returns the number of bytes of the CompactSize encoding of value
+func VarIntSize(value uint64) int {
+	switch {
+	case value < 0xfd:
+		return 1
+	case value <= 0xffff:
+		return 3
+	case value <= 0xffffffff:
+		return 5
+	}
+	return 9
+}
+
+// AppendVarInt appends the CompactSize encoding of value to dst
+func AppendVarInt(dst []byte, value uint64) []byte {
+	switch {
+	case value < 0xfd:
+		return append(dst, byte(value))
+	case value <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(dst, 0xfd), uint16(value))
+	case value <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(dst, 0xfe), uint32(value))
+	}
+	return binary.LittleEndian.AppendUint64(append(dst, 0xff), value)
+}
+
+// SerializeVarInt serializes an integer using Bitcoin's CompactSize encoding
+func SerializeVarInt(value uint64) []byte {
+	return AppendVarInt(make([]byte, 0, VarIntSize(value)), value)
+}
+
+// WriteVarInt writes the CompactSize encoding of value to w
+func WriteVarInt(w io.Writer, value uint64) error {
+	var buf [9]byte
+	_, err := w.Write(AppendVarInt(buf[:0], value))
+	return err
+}
+
+// ReadVarInt reads a CompactSize integer from r. The 0xfd, 0xfe and 0xff
+// prefixes are followed by a 2, 4 or 8-byte little-endian value, which must not
+// fit in a shorter encoding.
+func ReadVarInt(r io.Reader) (uint64, error) {
+	var buf [9]byte
+	if _, err := io.ReadFull(r, buf[:1]); err != nil {
+		return 0, err
+	}
+	var size int
+	var min uint64
+	switch buf[0] {
+	case 0xfd:
+		size, min = 2, 0xfd
+	case 0xfe:
+		size, min = 4, 0x10000
+	case 0xff:
+		size, min = 8, 0x100000000
+	default:
+		return uint64(buf[0]), nil
+	}
+	if _, err := io.ReadFull(r, buf[1:1+size]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	var value uint64
+	switch size {
+	case 2:
+		value = uint64(binary.LittleEndian.Uint16(buf[1:]))
+	case 4:
+		value = uint64(binary.LittleEndian.Uint32(buf[1:]))
+	default:
+		value = binary.LittleEndian.Uint64(buf[1:])
+	}
+	if value < min {
+		return 0, ErrNonCanonicalVarInt
+	}
+	return value, nil
+}
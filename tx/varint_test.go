@@ -0,0 +1,75 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// TestSerializeVarInt checks the encoding of the largest and smallest value of
+// every CompactSize length
+func TestSerializeVarInt(t *testing.T) {
+	tests := []struct {
+		value uint64
+		want  string
+	}{
+		{0, "00"},
+		{0xfc, "fc"},
+		{0xfd, "fdfd00"},
+		{0xffff, "fdffff"},
+		{0x10000, "fe00000100"},
+		{0xffffffff, "feffffffff"},
+		{0x100000000, "ff0000000001000000"},
+		{math.MaxUint64, "ffffffffffffffffff"},
+	}
+	for _, test := range tests {
+		if got := hex.EncodeToString(SerializeVarInt(test.value)); got != test.want {
+			t.Errorf("value %d: got %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+// TestVarIntRoundTrip writes and reads back every value next to an encoding
+// boundary
+func TestVarIntRoundTrip(t *testing.T) {
+	for _, boundary := range []uint64{0, 0xfd, 0x10000, 0x100000000, math.MaxUint64} {
+		for delta := -2; delta <= 2; delta++ {
+			value := boundary + uint64(delta)
+			var buf bytes.Buffer
+			if err := WriteVarInt(&buf, value); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() != VarIntSize(value) {
+				t.Errorf("value %d: wrote %d bytes, VarIntSize says %d", value, buf.Len(), VarIntSize(value))
+			}
+			decoded, err := ReadVarInt(&buf)
+			if err != nil || decoded != value {
+				t.Errorf("value %d: read back %d, %v", value, decoded, err)
+			}
+		}
+	}
+}
+
+// TestReadVarIntRejected checks non-canonical and truncated encodings are errors
+func TestReadVarIntRejected(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     error
+	}{
+		{"fdfc00", ErrNonCanonicalVarInt},
+		{"feffff0000", ErrNonCanonicalVarInt},
+		{"ffffffffff00000000", ErrNonCanonicalVarInt},
+		{"", io.EOF},
+		{"fd00", io.ErrUnexpectedEOF},
+		{"fe000001", io.ErrUnexpectedEOF},
+		{"ff00000000010000", io.ErrUnexpectedEOF},
+	}
+	for _, test := range tests {
+		if _, err := ReadVarInt(bytes.NewReader(mustHex(test.encoding))); !errors.Is(err, test.want) {
+			t.Errorf("%q: got error %v, want %v", test.encoding, err, test.want)
+		}
+	}
+}
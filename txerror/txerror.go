@@ -0,0 +1,94 @@
+// Package txerror attaches to errors what is needed to find the transaction
+// at fault in a large mempool: the file it was read from, its txid and the
+// input that failed.
+package txerror
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// Error is an error about one transaction, with as much of its context as is
+// known. Its message reads "file: tx txid: input n: err", leaving out what is
+// unknown.
+type Error struct {
+	File  string // mempool file the transaction was read from, empty if unknown
+	Txid  string // empty if unknown, e.g. when the transaction cannot be serialized
+	Input int    // index of the failing input, -1 if the error is not about one
+	Err   error
+}
+
+func (e *Error) Error() string {
+	var message strings.Builder
+	if e.File != "" {
+		message.WriteString(e.File + ": ")
+	}
+	if e.Txid != "" {
+		message.WriteString("tx " + e.Txid + ": ")
+	}
+	if e.Input >= 0 {
+		message.WriteString("input " + strconv.Itoa(e.Input) + ": ")
+	}
+	message.WriteString(e.Err.Error())
+	return message.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// with returns err with the context set by update. The context is added to
+// err itself when it already is an *Error, so each part of it reads once.
+func with(err error, update func(*Error)) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*Error)
+	if ok {
+		copied := *e
+		e = &copied
+	} else {
+		e = &Error{Input: -1, Err: err}
+	}
+	update(e)
+	return e
+}
+
+// File returns err as an error about the transaction read from the mempool
+// file name
+func File(name string, err error) error {
+	return with(err, func(e *Error) { e.File = name })
+}
+
+// Txid returns err as an error about the transaction txid
+func Txid(txid string, err error) error {
+	return with(err, func(e *Error) { e.Txid = txid })
+}
+
+// Input returns err as an error about the input index of a transaction
+func Input(index int, err error) error {
+	return with(err, func(e *Error) { e.Input = index })
+}
+
+// Transaction returns err as an error about transaction, with its txid if it
+// can be serialized and the file it was loaded from if known
+func Transaction(transaction tx.Transaction, err error) error {
+	return with(err, func(e *Error) {
+		if txid, txidErr := tx.Txid(transaction); txidErr == nil {
+			e.Txid = txid
+		}
+		if transaction.File != "" {
+			e.File = transaction.File
+		}
+	})
+}
+
+// Context returns the context attached to err, reporting false if there is
+// none
+func Context(err error) (*Error, bool) {
+	var e *Error
+	return e, errors.As(err, &e)
+}
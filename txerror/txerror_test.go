@@ -0,0 +1,33 @@
+package txerror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TestTransaction checks context added at each layer reads once, in a fixed
+// order, and can be recovered through further wrapping
+func TestTransaction(t *testing.T) {
+	errSig := errors.New("invalid signature")
+	transaction := tx.Transaction{Version: 2, File: "a.json"}
+	txid, err := tx.Txid(transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Transaction(transaction, Input(0, errSig))
+	if want := "a.json: tx " + txid + ": input 0: " + errSig.Error(); err.Error() != want {
+		t.Errorf("message %q, want %q", err, want)
+	}
+	if !errors.Is(err, errSig) {
+		t.Errorf("%v does not wrap the input error", err)
+	}
+	if context, ok := Context(fmt.Errorf("loading: %w", err)); !ok || context.File != "a.json" || context.Txid != txid || context.Input != 0 {
+		t.Errorf("context %+v, %v", context, ok)
+	}
+	if err := Txid(txid, nil); err != nil {
+		t.Errorf("context added to no error: %v", err)
+	}
+}
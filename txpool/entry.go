@@ -0,0 +1,51 @@
+package txpool
+
+import "github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+
+// Entry is a mempool transaction annotated with the values every stage of
+// block building asks for. They are computed once, when the transaction is
+// loaded, so selection and assembly never serialize it again.
+type Entry struct {
+	Tx     tx.Transaction
+	Txid   string
+	Wtxid  string
+	Fee    int64 // see tx.Fee
+	Weight int
+}
+
+// NewEntry annotates a transaction, failing if it cannot be serialized
+func NewEntry(transaction tx.Transaction) (Entry, error) {
+	txid, err := tx.Txid(transaction)
+	if err != nil {
+		return Entry{}, err
+	}
+	wtxid, err := tx.Wtxid(transaction)
+	if err != nil {
+		return Entry{}, err
+	}
+	weight, err := tx.Weight(transaction)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Tx: transaction, Txid: txid, Wtxid: wtxid, Fee: tx.Fee(transaction), Weight: weight}, nil
+}
+
+// NewEntries annotates transactions, calling reject with the error of every
+// one that cannot be serialized and leaving it out
+func NewEntries(transactions []tx.Transaction, reject func(tx.Transaction, error)) []Entry {
+	entries := make([]Entry, 0, len(transactions))
+	for _, transaction := range transactions {
+		entry, err := NewEntry(transaction)
+		if err != nil {
+			reject(transaction, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// FeeRate returns the fee rate in sat/vB, as tx.FeeRate does
+func (e Entry) FeeRate() float64 {
+	return float64(e.Fee) / float64(tx.VirtualSize(e.Weight))
+}
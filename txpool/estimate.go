@@ -0,0 +1,59 @@
+package txpool
+
+import (
+	"math"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// fullBlockMargin is how close to the weight limit, in weight units, a block
+// must be to count as full: closer than this, no typical transaction fits
+const fullBlockMargin = 4000
+
+// FeeEstimate is the fee rate a transaction needs to be included in the next
+// block, judged from a block template assembled from the mempool
+type FeeEstimate struct {
+	FeeRate float64 // sat/vB; 0 when the block is not full, as any fee gets in
+	Full    bool    // whether the block has no room for more transactions
+	CutOff  string  // txid of the transaction setting FeeRate, empty when not full
+}
+
+// EstimateNextBlockFee estimates the fee rate for next-block inclusion from
+// the transactions of an assembled block, coinbase first, and its weight
+// limit. A full block adds transactions by decreasing fee rate, so a new
+// transaction must pay more than the cheapest one in the block to displace it.
+// That is the cheapest transaction no other transaction in the block spends:
+// a parent paying less is in only because its child pays for it.
+func EstimateNextBlockFee(blockTransactions []tx.Transaction, maxWeight int) FeeEstimate {
+	spent := make(map[string]bool)
+	weight := 0
+	for _, transaction := range blockTransactions {
+		if w, err := tx.Weight(transaction); err == nil {
+			weight += w
+		}
+		for _, vin := range transaction.Vin {
+			spent[vin.Txid] = true
+		}
+	}
+	if weight+fullBlockMargin <= maxWeight {
+		return FeeEstimate{}
+	}
+
+	estimate := FeeEstimate{FeeRate: math.Inf(1), Full: true}
+	for _, transaction := range blockTransactions {
+		if len(transaction.Vin) > 0 && transaction.Vin[0].IsCoinbase {
+			continue
+		}
+		txid, err := tx.Txid(transaction)
+		if err != nil || spent[txid] {
+			continue
+		}
+		if feeRate := tx.FeeRate(transaction); feeRate < estimate.FeeRate {
+			estimate.FeeRate, estimate.CutOff = feeRate, txid
+		}
+	}
+	if estimate.CutOff == "" {
+		estimate.FeeRate = 0
+	}
+	return estimate
+}
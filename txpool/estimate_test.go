@@ -0,0 +1,47 @@
+package txpool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TestEstimateNextBlockFee checks a full block is cut off at its cheapest
+// transaction no other one spends, and a block with room needs no fee
+func TestEstimateNextBlockFee(t *testing.T) {
+	spend := func(txid string, value, fee int64) tx.Transaction {
+		return tx.Transaction{
+			Version: 2,
+			Vin:     []tx.TxInput{{Txid: txid, PrevOut: tx.Prevout{Value: value}}},
+			Vout:    []tx.TxOutput{{Value: value - fee}},
+		}
+	}
+	coinbase := tx.Transaction{
+		Version: 1,
+		Vin:     []tx.TxInput{{Txid: strings.Repeat("00", 32), Vout: -1, ScriptSig: []byte{0x01, 0x01}, IsCoinbase: true}},
+		Vout:    []tx.TxOutput{{Value: 5000000000}},
+	}
+	// The parent pays least but is in only for its child
+	parent := spend(strings.Repeat("11", 32), 100000, 60)
+	parentTxid, _ := tx.Txid(parent)
+	child := spend(parentTxid, 99940, 6000)
+	other := spend(strings.Repeat("22", 32), 100000, 600)
+	otherTxid, _ := tx.Txid(other)
+	transactions := []tx.Transaction{coinbase, parent, child, other}
+	weight := 0
+	for _, transaction := range transactions {
+		w, err := tx.Weight(transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weight += w
+	}
+
+	if estimate := EstimateNextBlockFee(transactions, weight); !estimate.Full || estimate.CutOff != otherTxid || estimate.FeeRate != 10 {
+		t.Errorf("full block: got %+v, want a cut off at %s paying 10 sat/vB", estimate, otherTxid)
+	}
+	if estimate := EstimateNextBlockFee(transactions, 4000000); estimate.Full || estimate.FeeRate != 0 {
+		t.Errorf("block with room: got %+v", estimate)
+	}
+}
@@ -0,0 +1,39 @@
+package txpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// NodeTemplate is the block template a Bitcoin Core node assembles from its
+// own mempool, as getblocktemplate returns it
+type NodeTemplate struct {
+	PreviousBlockHash string                    `json:"previousblockhash"` // as block explorers show it
+	Height            uint32                    `json:"height"`
+	CoinbaseValue     int64                     `json:"coinbasevalue"` // subsidy plus fees, in sats
+	WeightLimit       int                       `json:"weightlimit"`
+	Transactions      []NodeTemplateTransaction `json:"transactions"` // coinbase excluded, in block order
+}
+
+// NodeTemplateTransaction is a transaction of a NodeTemplate
+type NodeTemplateTransaction struct {
+	Txid   string `json:"txid"`
+	Wtxid  string `json:"hash"`
+	Fee    int64  `json:"fee"`
+	Weight int    `json:"weight"`
+}
+
+// BlockTemplate asks the node for the block it would mine next, with the
+// segwit rules every node since 0.16 requires
+func (s RPCSource) BlockTemplate(ctx context.Context) (NodeTemplate, error) {
+	var template NodeTemplate
+	result, err := s.Call(ctx, "getblocktemplate", map[string]any{"rules": []string{"segwit"}})
+	if err != nil {
+		return template, err
+	}
+	if err := json.Unmarshal(result, &template); err != nil {
+		return template, fmt.Errorf("decoding getblocktemplate result: %w", err)
+	}
+	return template, nil
+}
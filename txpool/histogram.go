@@ -0,0 +1,44 @@
+package txpool
+
+import "github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+
+// FeeRateBucket counts transactions whose fee rate falls in [Min, Max) sat/vB
+type FeeRateBucket struct {
+	Min          float64
+	Max          float64 // 0 means unbounded
+	MempoolCount int
+	BlockCount   int
+}
+
+// feeRateBucketBounds are the lower bounds (sat/vB) of the fee histogram buckets
+var feeRateBucketBounds = []float64{0, 1, 5, 10, 20, 50, 100}
+
+// BuildFeeHistogram buckets the mempool and block transactions by fee rate
+func BuildFeeHistogram(mempool []tx.Transaction, blockTransactions []tx.Transaction) []FeeRateBucket {
+	buckets := make([]FeeRateBucket, len(feeRateBucketBounds))
+	for i, min := range feeRateBucketBounds {
+		buckets[i].Min = min
+		if i+1 < len(feeRateBucketBounds) {
+			buckets[i].Max = feeRateBucketBounds[i+1]
+		}
+	}
+
+	// bucketIndex finds the bucket for a fee rate; anything below the first bound goes in the first bucket
+	bucketIndex := func(feeRate float64) int {
+		index := 0
+		for i, min := range feeRateBucketBounds {
+			if feeRate >= min {
+				index = i
+			}
+		}
+		return index
+	}
+
+	for _, transaction := range mempool {
+		buckets[bucketIndex(tx.FeeRate(transaction))].MempoolCount++
+	}
+	for _, transaction := range blockTransactions {
+		buckets[bucketIndex(tx.FeeRate(transaction))].BlockCount++
+	}
+	return buckets
+}
@@ -0,0 +1,164 @@
+package txpool
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// IndexEntry summarizes the transaction of one mempool file, so that a
+// snapshot too large to hold in memory can be ranked before any of it is
+// loaded for validation
+type IndexEntry struct {
+	File    string
+	Txid    string
+	Fee     int64
+	Weight  int
+	Parents []int // positions in the index of the transactions it spends outputs of
+}
+
+// IndexFolder reads the JSON files of a folder one at a time, keeping only the
+// entry of each transaction. Files are parsed only as far as the entry needs;
+// witnesses and prevout scripts are left for when candidates are loaded. Duplicates are skipped as by LoadFromFolder, and
+// so are transactions without a txid, which could never be mined. Variants of
+// a txid with different witnesses are all kept, and a child of the txid has
+// every one of them as a parent, so that whichever variant is valid is loaded
+// with it. Witnesses are compared as they are written in the JSON.
+// If ctx is cancelled, the entries indexed so far are returned with ctx's error.
+func IndexFolder(ctx context.Context, folderPath string) ([]IndexEntry, error) {
+	files, err := ioutil.ReadDir(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var index []IndexEntry
+	positions := make(map[string][]int) // txid to its entries, one per variant
+	seen := make(map[summaryKey]int)    // txid and witness to the entry
+	var spent [][]string                // txids of the outpoints of every entry, resolved once all are known
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return index, err
+		}
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		summary, err := readSummary(folderPath, file.Name())
+		if err != nil {
+			return nil, err
+		}
+		if summary.txid == "" {
+			slog.Debug("skipping transaction without a txid", "file", file.Name())
+			continue
+		}
+		key := summaryKey{summary.txid, summary.witness}
+		if first, duplicate := seen[key]; duplicate {
+			slog.Warn("skipping duplicate transaction", "txid", summary.txid, "file", file.Name(), "first", index[first].File)
+			continue
+		}
+		seen[key] = len(index)
+		positions[summary.txid] = append(positions[summary.txid], len(index))
+		index = append(index, IndexEntry{File: file.Name(), Txid: summary.txid, Fee: summary.fee, Weight: summary.weight})
+		spent = append(spent, summary.spends)
+	}
+
+	for i, outpoints := range spent {
+		for _, txid := range outpoints {
+			for _, parent := range positions[txid] {
+				if !slices.Contains(index[i].Parents, parent) {
+					index[i].Parents = append(index[i].Parents, parent)
+				}
+			}
+		}
+	}
+	return index, nil
+}
+
+// summaryKey identifies a transaction of the index, witness included
+type summaryKey struct {
+	txid    string
+	witness [sha256.Size]byte
+}
+
+// SelectCandidates returns the positions in index of the transactions worth
+// loading: by decreasing fee rate, each transaction together with its
+// ancestors in the index, as long as their weight fits in maxWeight. A budget
+// of a few blocks leaves room for the transactions that fail validation.
+func SelectCandidates(index []IndexEntry, maxWeight int) []int {
+	order := make([]int, len(index))
+	for i := range order {
+		order[i] = i
+	}
+	// Highest fee rate first
+	feeRate := func(entry IndexEntry) float64 { return float64(entry.Fee) / float64(max(entry.Weight, 1)) }
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(feeRate(index[b]), feeRate(index[a])) })
+
+	chosen := make([]bool, len(index))
+	var selected []int
+	weight := 0
+	for _, position := range order {
+		if chosen[position] {
+			continue
+		}
+		// The transaction and every ancestor not chosen yet
+		group := []int{position}
+		groupWeight := 0
+		seen := map[int]bool{position: true}
+		for i := 0; i < len(group); i++ {
+			groupWeight += index[group[i]].Weight
+			for _, parent := range index[group[i]].Parents {
+				if !chosen[parent] && !seen[parent] {
+					seen[parent] = true
+					group = append(group, parent)
+				}
+			}
+		}
+		if weight+groupWeight > maxWeight {
+			continue
+		}
+		weight += groupWeight
+		for _, member := range group {
+			chosen[member] = true
+		}
+		selected = append(selected, group...)
+	}
+	slices.Sort(selected)
+	return selected
+}
+
+// IndexedFolderSource reads a folder of mempool JSON files in two passes: it
+// indexes every file, then loads only the transactions chosen by
+// SelectCandidates, so memory is bounded by the candidates rather than the
+// whole snapshot
+type IndexedFolderSource struct {
+	Path      string
+	MaxWeight int // total weight of the transactions loaded
+}
+
+// Transactions indexes the folder and loads the best candidates, in the order
+// of their files
+func (s IndexedFolderSource) Transactions(ctx context.Context) ([]tx.Transaction, error) {
+	index, err := IndexFolder(ctx, s.Path)
+	if err != nil {
+		return nil, err
+	}
+	candidates := SelectCandidates(index, s.MaxWeight)
+	slog.Debug("indexed mempool", "transactions", len(index), "candidates", len(candidates))
+	transactions := make([]tx.Transaction, 0, len(candidates))
+	for _, position := range candidates {
+		if err := ctx.Err(); err != nil {
+			return transactions, err
+		}
+		transaction, err := readTransaction(s.Path, index[position].File)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
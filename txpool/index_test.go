@@ -0,0 +1,67 @@
+package txpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+func TestIndexFolderWitnessVariants(t *testing.T) {
+	var spend tx.Transaction
+	if err := json.Unmarshal([]byte(spendJSON), &spend); err != nil {
+		t.Fatal(err)
+	}
+	txid, err := tx.Txid(spend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := fmt.Sprintf(`{"version": 2, "locktime": 0, "vin": [{"txid": %q, "vout": 0, "prevout": {"scriptpubkey": "a9141741054896848103389bc291d3959c0c79de8bb487", "value": 66413}, "scriptsig": "", "sequence": 0}], "vout": [{"scriptpubkey": "a9141741054896848103389bc291d3959c0c79de8bb487", "value": 66000}]}`, txid)
+
+	index, err := IndexFolder(context.Background(), writeFolder(t, map[string]string{
+		"a.json": malleatedJSON,
+		"b.json": spendJSON,
+		"c.json": spendJSON,
+		"d.json": child,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, entry := range index {
+		files = append(files, entry.File)
+	}
+	if want := []string{"a.json", "b.json", "d.json"}; !slices.Equal(files, want) {
+		t.Fatalf("indexed %v, want %v", files, want)
+	}
+	if index[0].Txid != txid || index[1].Txid != txid {
+		t.Errorf("variant txids %s and %s, want %s", index[0].Txid, index[1].Txid, txid)
+	}
+	if index[0].Fee != index[1].Fee || index[0].Weight != index[1].Weight {
+		t.Errorf("variants of the same size summarized differently: %+v and %+v", index[0], index[1])
+	}
+	// The child needs whichever variant is valid loaded with it
+	if want := []int{0, 1}; !slices.Equal(index[2].Parents, want) {
+		t.Errorf("child parents %v, want %v", index[2].Parents, want)
+	}
+	if got := SelectCandidates(index, 4000000); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("candidates %v, want every entry", got)
+	}
+}
+
+// A low fee parent comes with its high fee child; the next best transaction
+// no longer fits, the smaller one after it does
+func TestSelectCandidates(t *testing.T) {
+	index := []IndexEntry{
+		{Fee: 100, Weight: 400},
+		{Fee: 8000, Weight: 400, Parents: []int{0}},
+		{Fee: 4000, Weight: 800},
+		{Fee: 1000, Weight: 400},
+	}
+	if got := SelectCandidates(index, 1200); !slices.Equal(got, []int{0, 1, 3}) {
+		t.Errorf("selected %v, want [0 1 3]", got)
+	}
+}
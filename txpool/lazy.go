@@ -0,0 +1,139 @@
+package txpool
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+)
+
+// lazyTransaction is the part of a mempool JSON transaction the index needs.
+// Only what the txid commits to is decoded: the outpoints, scriptSigs and
+// output scripts. Witness items are measured without being decoded, and the
+// scripts, disassembly and addresses of the prevouts are skipped; all of them
+// are parsed only if the transaction becomes a candidate.
+type lazyTransaction struct {
+	Version  uint32 `json:"version"`
+	Locktime uint32 `json:"locktime"`
+	Vin      []struct {
+		Txid      string      `json:"txid"`
+		Vout      int         `json:"vout"`
+		ScriptSig tx.HexBytes `json:"scriptsig"`
+		Witness   witnessSize `json:"witness"`
+		Sequence  uint32      `json:"sequence"`
+		PrevOut   struct {
+			Value int64 `json:"value"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		ScriptPubKey tx.HexBytes `json:"scriptpubkey"`
+		Value        int64       `json:"value"`
+	} `json:"vout"`
+}
+
+// witnessSize is the item count and serialized size of a witness stack,
+// measured from the lengths of its hex strings
+type witnessSize struct {
+	items  int
+	size   int               // serialized size of the items, without their count
+	digest [sha256.Size]byte // of the JSON of the stack, zero if it has none
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The value is already valid JSON;
+// hex strings are measured directly, anything escaped is decoded to be sure.
+func (w *witnessSize) UnmarshalJSON(data []byte) error {
+	*w = witnessSize{}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	w.digest = sha256.Sum256(data)
+	if bytes.IndexByte(data, '\\') >= 0 {
+		var items []tx.HexBytes
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			w.add(len(item))
+		}
+		return nil
+	}
+	for rest := data; ; {
+		open := bytes.IndexByte(rest, '"')
+		if open < 0 {
+			return nil
+		}
+		closing := bytes.IndexByte(rest[open+1:], '"')
+		if closing < 0 {
+			return errors.New("unterminated witness item")
+		}
+		if closing%2 != 0 {
+			return fmt.Errorf("witness item %d: odd length hex", w.items)
+		}
+		w.add(closing / 2)
+		rest = rest[open+1+closing+1:]
+	}
+}
+
+func (w *witnessSize) add(length int) {
+	w.items++
+	w.size += tx.VarIntSize(uint64(length)) + length
+}
+
+// summary is what the index keeps of a transaction
+type summary struct {
+	txid    string            // empty if the transaction cannot be serialized
+	witness [sha256.Size]byte // tells apart the variants of a txid, as the wtxid would
+	fee     int64
+	weight  int
+	spends  []string // txids of the outputs it spends
+}
+
+// readSummary reads the summary of the transaction of one mempool JSON file
+// without parsing the whole transaction
+func readSummary(folderPath, name string) (summary, error) {
+	data, err := ioutil.ReadFile(folderPath + "/" + name)
+	if err != nil {
+		return summary{}, err
+	}
+	var lazy lazyTransaction
+	if err := json.Unmarshal(data, &lazy); err != nil {
+		return summary{}, txerror.File(name, err)
+	}
+
+	// The transaction without its witness, which is all the txid covers
+	stripped := tx.Transaction{Version: lazy.Version, Locktime: lazy.Locktime}
+	var s summary
+	witnessSize, hasWitness := 0, false
+	witness := sha256.New()
+	for _, vin := range lazy.Vin {
+		stripped.Vin = append(stripped.Vin, tx.TxInput{
+			Txid:      vin.Txid,
+			Vout:      vin.Vout,
+			ScriptSig: vin.ScriptSig,
+			Sequence:  vin.Sequence,
+			PrevOut:   tx.Prevout{Value: vin.PrevOut.Value},
+		})
+		s.spends = append(s.spends, vin.Txid)
+		// Inputs without a witness still have an empty stack once any input has one
+		witnessSize += tx.VarIntSize(uint64(vin.Witness.items)) + vin.Witness.size
+		hasWitness = hasWitness || vin.Witness.items > 0
+		witness.Write(vin.Witness.digest[:])
+	}
+	witness.Sum(s.witness[:0])
+	for _, vout := range lazy.Vout {
+		stripped.Vout = append(stripped.Vout, tx.TxOutput{ScriptPubKey: vout.ScriptPubKey, Value: vout.Value})
+	}
+	s.txid, _ = tx.Txid(stripped)
+	s.fee = tx.Fee(stripped)
+	s.weight, _ = tx.Weight(stripped)
+	if hasWitness {
+		// The segwit marker and flag and the witness stacks count once
+		s.weight += 2 + witnessSize
+	}
+	return s, nil
+}
@@ -0,0 +1,240 @@
+package txpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+)
+
+// rpcBatchSize is how many getrawtransaction calls are sent in one JSON-RPC batch
+const rpcBatchSize = 500
+
+// RPCSource reads the mempool of a Bitcoin Core node over JSON-RPC. It needs a
+// node recent enough to return prevouts from getrawtransaction with verbosity 2.
+type RPCSource struct {
+	URL      string
+	User     string
+	Password string
+	Client   *http.Client // http.DefaultClient if nil
+}
+
+// rpcRequest is a JSON-RPC 1.0 request as accepted by Bitcoin Core
+type rpcRequest struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC response with its result left undecoded
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// rpcScriptPubKey is the scriptPubKey object of getrawtransaction
+type rpcScriptPubKey struct {
+	Asm     string      `json:"asm"`
+	Hex     tx.HexBytes `json:"hex"`
+	Type    string      `json:"type"`
+	Address string      `json:"address"`
+}
+
+// rpcTransaction is the verbose (verbosity 2) result of getrawtransaction
+type rpcTransaction struct {
+	Version  uint32 `json:"version"`
+	Locktime uint32 `json:"locktime"`
+	Vin      []struct {
+		Txid      string `json:"txid"`
+		Vout      int    `json:"vout"`
+		Coinbase  string `json:"coinbase"`
+		ScriptSig struct {
+			Hex tx.HexBytes `json:"hex"`
+		} `json:"scriptSig"`
+		Witness  []tx.HexBytes `json:"txinwitness"`
+		Sequence uint32        `json:"sequence"`
+		Prevout  *struct {
+			Value        json.Number     `json:"value"`
+			ScriptPubKey rpcScriptPubKey `json:"scriptPubKey"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		Value        json.Number     `json:"value"`
+		ScriptPubKey rpcScriptPubKey `json:"scriptPubKey"`
+	} `json:"vout"`
+}
+
+// rpcScriptTypes maps Bitcoin Core script type names to the names used in the mempool JSON files
+var rpcScriptTypes = map[string]string{
+	"pubkey":                "p2pk",
+	"pubkeyhash":            "p2pkh",
+	"scripthash":            "p2sh",
+	"witness_v0_keyhash":    "v0_p2wpkh",
+	"witness_v0_scripthash": "v0_p2wsh",
+	"witness_v1_taproot":    "v1_p2tr",
+	"nulldata":              "op_return",
+}
+
+// call sends a batch of JSON-RPC requests and returns the results in request order
+func (s RPCSource) call(ctx context.Context, requests []rpcRequest) ([]json.RawMessage, error) {
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if s.User != "" || s.Password != "" {
+		httpRequest.SetBasicAuth(s.User, s.Password)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResponse, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	var responses []rpcResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("decoding RPC response (HTTP %s): %w", httpResponse.Status, err)
+	}
+	results := make([]json.RawMessage, len(requests))
+	for _, response := range responses {
+		if response.ID < 0 || response.ID >= len(requests) {
+			return nil, fmt.Errorf("RPC response with unknown id %d", response.ID)
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("RPC %s: %s (code %d)", requests[response.ID].Method, response.Error.Message, response.Error.Code)
+		}
+		results[response.ID] = response.Result
+	}
+	return results, nil
+}
+
+// Call sends a single JSON-RPC request to the node and returns its result
+func (s RPCSource) Call(ctx context.Context, method string, params ...any) (json.RawMessage, error) {
+	results, err := s.call(ctx, []rpcRequest{{ID: 0, Method: method, Params: append([]any{}, params...)}})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// Transactions fetches every transaction in the node's mempool
+func (s RPCSource) Transactions(ctx context.Context) ([]tx.Transaction, error) {
+	result, err := s.Call(ctx, "getrawmempool")
+	if err != nil {
+		return nil, err
+	}
+	var txids []string
+	if err := json.Unmarshal(result, &txids); err != nil {
+		return nil, fmt.Errorf("decoding getrawmempool result: %w", err)
+	}
+
+	var transactions []tx.Transaction
+	for batchStart := 0; batchStart < len(txids); batchStart += rpcBatchSize {
+		batch := txids[batchStart:min(batchStart+rpcBatchSize, len(txids))]
+		requests := make([]rpcRequest, len(batch))
+		for i, txid := range batch {
+			requests[i] = rpcRequest{ID: i, Method: "getrawtransaction", Params: []any{txid, 2}}
+		}
+		results, err := s.call(ctx, requests)
+		if err != nil {
+			return transactions, err
+		}
+		for i, result := range results {
+			var rpcTx rpcTransaction
+			if err := json.Unmarshal(result, &rpcTx); err != nil {
+				return transactions, txerror.Txid(batch[i], fmt.Errorf("decoding getrawtransaction result: %w", err))
+			}
+			transaction, err := rpcTx.toTransaction()
+			if err != nil {
+				return transactions, txerror.Txid(batch[i], err)
+			}
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+// toTransaction converts a getrawtransaction result to the mempool JSON representation
+func (rpcTx rpcTransaction) toTransaction() (tx.Transaction, error) {
+	transaction := tx.Transaction{Version: rpcTx.Version, Locktime: rpcTx.Locktime}
+	for i, vin := range rpcTx.Vin {
+		input := tx.TxInput{
+			Txid:       vin.Txid,
+			Vout:       vin.Vout,
+			ScriptSig:  vin.ScriptSig.Hex,
+			Witness:    vin.Witness,
+			IsCoinbase: vin.Coinbase != "",
+			Sequence:   vin.Sequence,
+		}
+		if vin.Prevout == nil && !input.IsCoinbase {
+			return transaction, txerror.Input(i, fmt.Errorf("node did not return prevouts (getrawtransaction verbosity 2 needs Bitcoin Core 25 or later)"))
+		}
+		if vin.Prevout != nil {
+			value, err := btcToSats(vin.Prevout.Value)
+			if err != nil {
+				return transaction, txerror.Input(i, fmt.Errorf("prevout: %w", err))
+			}
+			input.PrevOut = tx.Prevout{
+				ScriptPubKey:     vin.Prevout.ScriptPubKey.Hex,
+				ScriptPubKeyASM:  vin.Prevout.ScriptPubKey.Asm,
+				ScriptPubKeyType: scriptTypeName(vin.Prevout.ScriptPubKey.Type),
+				ScriptPubKeyAddr: vin.Prevout.ScriptPubKey.Address,
+				Value:            value,
+			}
+		}
+		transaction.Vin = append(transaction.Vin, input)
+	}
+	for i, vout := range rpcTx.Vout {
+		value, err := btcToSats(vout.Value)
+		if err != nil {
+			return transaction, fmt.Errorf("output %d: %w", i, err)
+		}
+		transaction.Vout = append(transaction.Vout, tx.TxOutput{
+			ScriptPubKey:     vout.ScriptPubKey.Hex,
+			ScriptPubKeyASM:  vout.ScriptPubKey.Asm,
+			ScriptPubKeyType: scriptTypeName(vout.ScriptPubKey.Type),
+			ScriptPubKeyAddr: vout.ScriptPubKey.Address,
+			Value:            value,
+		})
+	}
+	return transaction, nil
+}
+
+// scriptTypeName translates a Bitcoin Core script type, keeping unknown names as they are
+func scriptTypeName(coreType string) string {
+	if name, ok := rpcScriptTypes[coreType]; ok {
+		return name
+	}
+	return coreType
+}
+
+// btcToSats converts an exact decimal BTC amount to satoshis without float rounding
+func btcToSats(value json.Number) (int64, error) {
+	amount, ok := new(big.Rat).SetString(string(value))
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	sats := amount.Mul(amount, big.NewRat(100000000, 1))
+	if !sats.IsInt() || !sats.Num().IsInt64() {
+		return 0, fmt.Errorf("amount %q is not a whole number of satoshis", value)
+	}
+	return sats.Num().Int64(), nil
+}
@@ -0,0 +1,55 @@
+package txpool
+
+import (
+	"slices"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/script"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// ScriptTypeCount counts the inputs and outputs of one script type in the
+// mempool and in a block. An input has the type of the prevout it spends.
+type ScriptTypeCount struct {
+	Type           script.ScriptType
+	MempoolInputs  int
+	BlockInputs    int
+	MempoolOutputs int
+	BlockOutputs   int
+}
+
+// CountScriptTypes counts the inputs and outputs of the mempool and block
+// transactions by script type, classified from the scripts themselves rather
+// than the scriptpubkey_type fields. Only the types found in the mempool or the
+// block are returned, in script.ScriptType order.
+func CountScriptTypes(mempool []tx.Transaction, blockTransactions []tx.Transaction) []ScriptTypeCount {
+	counts := make(map[script.ScriptType]*ScriptTypeCount)
+	count := func(scriptType script.ScriptType) *ScriptTypeCount {
+		if counts[scriptType] == nil {
+			counts[scriptType] = &ScriptTypeCount{Type: scriptType}
+		}
+		return counts[scriptType]
+	}
+	for _, transaction := range mempool {
+		for _, vin := range transaction.Vin {
+			count(script.ClassifyScript(vin.PrevOut.ScriptPubKey)).MempoolInputs++
+		}
+		for _, vout := range transaction.Vout {
+			count(script.ClassifyScript(vout.ScriptPubKey)).MempoolOutputs++
+		}
+	}
+	for _, transaction := range blockTransactions {
+		for _, vin := range transaction.Vin {
+			count(script.ClassifyScript(vin.PrevOut.ScriptPubKey)).BlockInputs++
+		}
+		for _, vout := range transaction.Vout {
+			count(script.ClassifyScript(vout.ScriptPubKey)).BlockOutputs++
+		}
+	}
+
+	sorted := make([]ScriptTypeCount, 0, len(counts))
+	for _, c := range counts {
+		sorted = append(sorted, *c)
+	}
+	slices.SortFunc(sorted, func(a, b ScriptTypeCount) int { return int(a.Type) - int(b.Type) })
+	return sorted
+}
@@ -0,0 +1,33 @@
+package txpool
+
+import (
+	"context"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+)
+
+// TxSource provides the candidate transactions for a block
+type TxSource interface {
+	Transactions(ctx context.Context) ([]tx.Transaction, error)
+}
+
+// FolderSource reads transactions from a folder of mempool JSON files
+type FolderSource struct {
+	Path string
+}
+
+// Transactions loads the JSON files in the folder
+func (s FolderSource) Transactions(ctx context.Context) ([]tx.Transaction, error) {
+	return LoadFromFolder(ctx, s.Path)
+}
+
+// MemorySource serves a fixed set of transactions, e.g. fixtures built in code
+type MemorySource []tx.Transaction
+
+// Transactions returns a copy of the transactions
+func (s MemorySource) Transactions(ctx context.Context) ([]tx.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append([]tx.Transaction(nil), s...), nil
+}
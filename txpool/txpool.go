@@ -0,0 +1,71 @@
+// Package txpool loads mempool snapshots and summarizes their contents.
+package txpool
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/tx"
+	"github.com/SummerOfBitcoin/code-challenge-2024-himanshu5133/txerror"
+)
+
+// DefaultPath is the mempool folder read by the miner
+// const DefaultPath = `C:\Users\himan\Desktop\SOB\code-challenge-2024-himanshu5133\mempool` //path of mempool folder
+const DefaultPath = "mempool"
+
+// LoadFromFolder loads transactions from JSON files in a folder. A transaction
+// stored under several filenames is loaded once, from the first of them. Only
+// identical transactions are duplicates: variants with the same txid but
+// different witnesses are all loaded, for validation to reject the invalid ones
+// and conflict resolution to keep one of the rest.
+// If ctx is cancelled, the transactions loaded so far are returned with ctx's error.
+func LoadFromFolder(ctx context.Context, folderPath string) ([]tx.Transaction, error) {
+	var transactions []tx.Transaction
+	loadedFrom := make(map[string]string) // wtxid to the file it was loaded from
+
+	files, err := ioutil.ReadDir(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return transactions, err
+		}
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			transaction, err := readTransaction(folderPath, file.Name())
+			if err != nil {
+				return nil, err
+			}
+			// Transactions without a wtxid are left for validation to reject
+			if wtxid, err := tx.Wtxid(transaction); err == nil {
+				if first, seen := loadedFrom[wtxid]; seen {
+					slog.Warn("skipping duplicate transaction", "wtxid", wtxid, "file", file.Name(), "first", first)
+					continue
+				}
+				loadedFrom[wtxid] = file.Name()
+			}
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+// readTransaction parses the transaction of one mempool JSON file
+func readTransaction(folderPath, name string) (tx.Transaction, error) {
+	var transaction tx.Transaction
+	data, err := ioutil.ReadFile(folderPath + "/" + name)
+	if err != nil {
+		return transaction, err
+	}
+	// Scripts and witness items are decoded from hex here, so bad hex fails the file
+	if err := json.Unmarshal(data, &transaction); err != nil {
+		return transaction, txerror.File(name, err)
+	}
+	transaction.File = name
+	return transaction, nil
+}
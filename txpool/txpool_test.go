@@ -0,0 +1,56 @@
+package txpool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// spendJSON is mempool transaction f615cdc4…, a P2WPKH spend
+const spendJSON = `{"version": 1, "locktime": 0, "vin": [{"txid": "9fdc20cdda2b7c68d54e6849dba6c0e4dfa6fd96583ee8c7bbc92c6a41041ee6", "vout": 0, "prevout": {"scriptpubkey": "001418b87714d53f15850a030a13ac61de8c0b1e2416", "scriptpubkey_asm": "OP_0 OP_PUSHBYTES_20 18b87714d53f15850a030a13ac61de8c0b1e2416", "scriptpubkey_type": "v0_p2wpkh", "scriptpubkey_address": "bc1qrzu8w9x48u2c2zsrpgf6ccw73s93ufqkk40np6", "value": 69410}, "scriptsig": "", "scriptsig_asm": "", "witness": ["30440220532c2c26abb3fc10fe9c41ff2790c2536d622d5d077337d53a19f90fabac05d202206e57ecde0fffa9b27c55263906ab0363f9d86f9d417ea651f186e266cd673ba601", "031f2de1fd3ee1e61372e8cf58e27b2b88d2cefaa6c3fb16ae8679a60901db396e"], "is_coinbase": false, "sequence": 0}], "vout": [{"scriptpubkey": "a9141741054896848103389bc291d3959c0c79de8bb487", "scriptpubkey_asm": "OP_HASH160 OP_PUSHBYTES_20 1741054896848103389bc291d3959c0c79de8bb4 OP_EQUAL", "scriptpubkey_type": "p2sh", "scriptpubkey_address": "33oyMfkum1r8gTm5EMWkRAdQUV3gLVHqk2", "value": 66413}]}`
+
+// malleatedJSON is spendJSON with a different signature, so the same txid
+// under another wtxid
+var malleatedJSON = strings.Replace(spendJSON, "673ba601", "673ba701", 1)
+
+// writeFolder writes each file of files into a new temporary folder
+func writeFolder(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFromFolderDuplicates(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // files loaded
+	}{
+		{"identical copies", map[string]string{"a.json": spendJSON, "b.json": spendJSON}, []string{"a.json"}},
+		{"witness variants", map[string]string{"a.json": malleatedJSON, "b.json": spendJSON}, []string{"a.json", "b.json"}},
+		{"variants and a copy", map[string]string{"a.json": malleatedJSON, "b.json": spendJSON, "c.json": malleatedJSON}, []string{"a.json", "b.json"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transactions, err := LoadFromFolder(context.Background(), writeFolder(t, test.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, transaction := range transactions {
+				got = append(got, transaction.File)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("loaded %v, want %v", got, test.want)
+			}
+		})
+	}
+}